@@ -120,3 +120,212 @@ func TestNumberPlaceholdersIsQuoteAware(t *testing.T) {
 	in := "?,?,?,?,?,?,?,?,?,?,?"
 	assert.Equal(t, "$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11", numberPlaceholders(PostgresDialect, in))
 }
+
+func TestRawAdapterClickHouseDialect(t *testing.T) {
+	render := func(e ...Expr) (SQLQuery, string) {
+		f := New()
+		for _, x := range e {
+			f.AddFilter(x)
+		}
+		f.Build(RawAdapter{Dialect: ClickHouseDialect})
+		q, ok := f.GetQuery(RawContext{Table: "events"}).(SQLQuery)
+		require.True(t, ok)
+		return q, f.GetSqlString(RawContext{Table: "events"})
+	}
+
+	t.Run("QuotingPlaceholdersAndPaging", func(t *testing.T) {
+		f := New()
+		require.NoError(t, f.AddFiltersFromString(`id=1 and name="x" sort=id:desc page=skip:5,take:10`))
+		f.Build(RawAdapter{Dialect: ClickHouseDialect})
+		q := f.GetQuery(RawContext{Table: "events"}).(SQLQuery)
+		assert.Equal(t,
+			"SELECT * FROM `events` WHERE (`id` = ? AND `name` = ?) ORDER BY `id` DESC LIMIT 10 OFFSET 5",
+			q.SQL)
+		assert.Equal(t, []any{int64(1), "x"}, q.Args)
+
+		f.SetPage(7, 0)
+		assert.Contains(t, f.GetSqlString(RawContext{Table: "events"}), "LIMIT 18446744073709551615 OFFSET 7")
+	})
+
+	t.Run("RegexAndILike", func(t *testing.T) {
+		f := New()
+		require.NoError(t, f.AddFiltersFromString(`email=~"gmail" and name.=^"al%" and not (host=~"^test")`))
+		f.Build(RawAdapter{Dialect: ClickHouseDialect})
+		q := f.GetQuery(RawContext{Table: "events"}).(SQLQuery)
+		assert.Equal(t,
+			"SELECT * FROM `events` WHERE (match(`email`, ?) AND `name` ILIKE ? AND NOT (match(`host`, ?)))",
+			q.SQL)
+		assert.Equal(t, []any{"gmail", "al%", "^test"}, q.Args)
+	})
+
+	t.Run("Arrays", func(t *testing.T) {
+		q, sql := render(
+			ArrayContainsExpr{Field: "tags", Values: []any{"a", "b"}},
+			ArrayContainsExpr{Field: "flags", Values: []any{int64(3)}},
+			ArrayOverlapsExpr{Field: "tags", Values: []any{"x", "y"}},
+		)
+		assert.Equal(t,
+			"SELECT * FROM `events` WHERE (has(`tags`, ?) AND has(`tags`, ?)) AND has(`flags`, ?) AND hasAny(`tags`, [?, ?])",
+			q.SQL)
+		assert.Equal(t, []any{"a", "b", int64(3), "x", "y"}, q.Args)
+		assert.Contains(t, sql, "hasAny(`tags`, ['x', 'y'])")
+
+		q, _ = render(ArrayContainsExpr{Field: "tags"}, ArrayOverlapsExpr{Field: "tags"})
+		assert.Equal(t, "SELECT * FROM `events` WHERE 1=1 AND 1=0", q.SQL)
+	})
+
+	t.Run("JSONPaths", func(t *testing.T) {
+		q, _ := render(
+			JsonPathExpr{Field: "data", Path: "$.user.name", Value: "ann", Op: "="},
+			JsonPathExpr{Field: "data", Path: "$.items[0].qty", Value: int64(2), Op: ">="},
+			JsonPathExpr{Field: "data", Path: "$.score", Value: 1.5, Op: "<"},
+			JsonPathExpr{Field: "data", Path: "$.flags.beta", Value: true},
+			JsonPathExpr{Field: "data", Path: "$.tags", Value: "red", Op: "contains"},
+			JsonPathExpr{Field: "data", Path: "$.user", Op: "exists"},
+		)
+		assert.Equal(t, "SELECT * FROM `events` WHERE "+
+			"JSONExtractString(`data`, ?, ?) = ? AND "+
+			"JSONExtractInt(`data`, ?, ?, ?) >= ? AND "+
+			"JSONExtractFloat(`data`, ?) < ? AND "+
+			"JSONExtractBool(`data`, ?, ?) = ? AND "+
+			"has(JSONExtract(`data`, ?, 'Array(String)'), ?) AND "+
+			"JSONHas(`data`, ?)", q.SQL)
+		assert.Equal(t, []any{
+			"user", "name", "ann",
+			"items", int64(1), "qty", int64(2),
+			"score", 1.5,
+			"flags", "beta", true,
+			"tags", "red",
+			"user",
+		}, q.Args)
+	})
+
+	t.Run("EscapesBackslashes", func(t *testing.T) {
+		_, sql := render(EqExpr{Field: "a\\`b", Value: `x\'y`})
+		assert.Equal(t, "SELECT * FROM `events` WHERE `a\\\\``b` = 'x\\\\''y'", sql)
+	})
+
+	t.Run("UnrenderableFailsClosed", func(t *testing.T) {
+		for _, e := range []Expr{
+			JsonPathExpr{Field: "data", Path: "$.a", Op: "="},
+			JsonPathExpr{Field: "data", Path: "$", Value: 1},
+			JsonPathExpr{Field: "data", Path: "$.a[x]", Value: 1},
+			JsonPathExpr{Field: "data", Path: "$.a", Value: []int{1}},
+			JsonPathExpr{Field: "data", Path: "$.a", Value: 1, Op: "like"},
+		} {
+			f := New()
+			f.AddFilter(e)
+			f.Build(RawAdapter{Dialect: ClickHouseDialect})
+			_, _, err := BuildRawWhere(f)
+			assert.Error(t, err, "%+v", e)
+		}
+	})
+
+	// The other dialects still have no rendering for the advanced types.
+	for _, d := range []*SQLDialect{MySQLDialect, PostgresDialect, SQLiteDialect} {
+		f := New()
+		f.AddFilter(ArrayOverlapsExpr{Field: "tags", Values: []any{"a"}})
+		f.Build(RawAdapter{Dialect: d})
+		_, _, err := BuildRawWhere(f)
+		require.Error(t, err, d.Name)
+		assert.Contains(t, err.Error(), "unsupported expression type")
+	}
+}
+
+func TestRawAdapterSQLServerDialect(t *testing.T) {
+	build := func(dsl string) Figo {
+		f := New()
+		require.NoError(t, f.AddFiltersFromString(dsl))
+		f.Build(RawAdapter{Dialect: SQLServerDialect})
+		return f
+	}
+
+	t.Run("BracketsAtPlaceholdersAndOffsetFetch", func(t *testing.T) {
+		q := build(`id=1 and name<in>["x","y"] sort=id:desc page=skip:5,take:10`).GetQuery(RawContext{Table: "users"}).(SQLQuery)
+		assert.Equal(t,
+			"SELECT * FROM [users] WHERE ([id] = @p1 AND [name] IN (@p2,@p3)) ORDER BY [id] DESC OFFSET 5 ROWS FETCH NEXT 10 ROWS ONLY",
+			q.SQL)
+		assert.Equal(t, []any{int64(1), "x", "y"}, q.Args)
+	})
+
+	t.Run("UnsortedPageOrdersBySelectNull", func(t *testing.T) {
+		q := build(`id>1 page=skip:0,take:3`).GetQuery(RawContext{Table: "users"}).(SQLQuery)
+		assert.Equal(t, "SELECT * FROM [users] WHERE [id] > @p1 ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 3 ROWS ONLY", q.SQL)
+
+		f := build(`id>1 sort=id:asc`)
+		f.SetPage(4, 0)
+		assert.Contains(t, f.GetSqlString(RawContext{Table: "users"}), "ORDER BY [id] ASC OFFSET 4 ROWS")
+	})
+
+	t.Run("ClosingBracketIsDoubledAndNotABind", func(t *testing.T) {
+		f := New()
+		f.AddFilter(EqExpr{Field: "a]?b", Value: true})
+		f.AddFilter(EqExpr{Field: "c", Value: false})
+		f.Build(RawAdapter{Dialect: SQLServerDialect})
+		q := f.GetQuery(RawContext{Table: "t"}).(SQLQuery)
+		assert.Equal(t, "SELECT * FROM [t] WHERE [a]]?b] = @p1 AND [c] = @p2", q.SQL)
+		assert.Equal(t, "SELECT * FROM [t] WHERE [a]]?b] = 1 AND [c] = 0", f.GetSqlString(RawContext{Table: "t"}))
+	})
+
+	t.Run("SegmentsNeedPage", func(t *testing.T) {
+		f := build(`id=1 sort=id:asc page=skip:2,take:2`)
+		sql, ok := RawAdapter{Dialect: SQLServerDialect}.GetSqlString(f, "t", "WHERE", "SORT", "PAGE")
+		require.True(t, ok)
+		assert.Equal(t, "WHERE [id] = 1 ORDER BY [id] ASC OFFSET 2 ROWS FETCH NEXT 2 ROWS ONLY", sql)
+
+		_, ok = RawAdapter{Dialect: SQLServerDialect}.GetSqlString(f, "t", "WHERE", "LIMIT")
+		assert.False(t, ok, "half of an OFFSET/FETCH clause must not render")
+	})
+
+	t.Run("RegexFailsClearly", func(t *testing.T) {
+		f := build(`email=~"gmail"`)
+		_, _, err := BuildRawWhere(f)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "sqlserver dialect has no regex operator")
+		_, ok := RawAdapter{Dialect: SQLServerDialect}.GetQuery(f, "t")
+		assert.False(t, ok)
+	})
+}
+
+func TestRawAdapterOracleDialect(t *testing.T) {
+	build := func(dsl string) Figo {
+		f := New()
+		require.NoError(t, f.AddFiltersFromString(dsl))
+		f.Build(RawAdapter{Dialect: OracleDialect})
+		return f
+	}
+
+	t.Run("ColonBindsRegexAndOffsetFetch", func(t *testing.T) {
+		q := build(`id=1 and email=~"^a" and name.=^"b%" page=skip:0,take:10`).GetQuery(RawContext{Table: "users"}).(SQLQuery)
+		assert.Equal(t,
+			`SELECT * FROM "users" WHERE ("id" = :1 AND REGEXP_LIKE("email", :2) AND LOWER("name") LIKE LOWER(:3)) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY`,
+			q.SQL)
+		assert.Equal(t, []any{int64(1), "^a", "b%"}, q.Args)
+	})
+
+	t.Run("NoBooleanLiterals", func(t *testing.T) {
+		sql := build(`active=true or deleted=false`).GetSqlString(RawContext{Table: "users"})
+		assert.Equal(t, `SELECT * FROM "users" WHERE ("active" = 1 OR "deleted" = 0)`, sql)
+	})
+
+	t.Run("RelationAliasHasNoAS", func(t *testing.T) {
+		f := New()
+		require.NoError(t, f.AddFiltersFromString(`orders<any>[total>10]`))
+		f.Build(RawAdapter{Dialect: OracleDialect, Relations: map[string]SQLRelation{"orders": {Table: "orders", ForeignKey: "user_id"}}})
+		q := f.GetQuery(RawContext{Table: "users"}).(SQLQuery)
+		assert.Equal(t,
+			`SELECT * FROM "users" WHERE EXISTS (SELECT 1 FROM "orders" "figo_rel1" WHERE "figo_rel1"."user_id" = "users"."id" AND ("total" > :1))`,
+			q.SQL)
+	})
+
+	t.Run("CustomPlaceholder", func(t *testing.T) {
+		named := *OracleDialect
+		named.Placeholder = func(n int) string { return ":p" + string(rune('0'+n)) }
+		f := New()
+		require.NoError(t, f.AddFiltersFromString(`a=1 and b=2`))
+		f.Build(RawAdapter{Dialect: &named})
+		where, _, err := BuildRawWhere(f)
+		require.NoError(t, err)
+		assert.Equal(t, `("a" = :p1 AND "b" = :p2)`, where)
+	})
+}
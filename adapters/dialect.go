@@ -9,7 +9,8 @@ import (
 
 // SQLDialect describes how the raw adapter renders dialect-specific SQL:
 // identifier quoting, bind-placeholder style, the regex operator, string
-// literal escaping, and the "no limit" token for bare OFFSET.
+// literal escaping, and paging (LIMIT/OFFSET with a "no limit" token for bare
+// OFFSET, or a Paginate renderer such as OFFSET/FETCH).
 //
 // Select one on the adapter — the zero-value adapter keeps the historical
 // MySQL rendering:
@@ -17,6 +18,9 @@ import (
 //	f.Build(figo.RawAdapter{})                            // MySQL (default)
 //	f.Build(figo.RawAdapter{Dialect: figo.PostgresDialect}) // "col", $1, ~
 //	f.Build(figo.RawAdapter{Dialect: figo.SQLiteDialect})   // "col", ?, REGEXP
+//	f.Build(figo.RawAdapter{Dialect: figo.ClickHouseDialect}) // `col`, ?, match()
+//	f.Build(figo.RawAdapter{Dialect: figo.SQLServerDialect})  // [col], @p1, OFFSET/FETCH
+//	f.Build(figo.RawAdapter{Dialect: figo.OracleDialect})     // "col", :1, REGEXP_LIKE
 //
 // For a variant, copy a built-in and adjust it:
 //
@@ -31,11 +35,23 @@ type SQLDialect struct {
 	// the injection defense on both the GetSqlString and GetQuery paths.
 	QuoteRune rune
 
+	// CloseQuoteRune closes an identifier when it differs from QuoteRune
+	// (SQL Server's [name]); zero means QuoteRune closes it too. The closing
+	// rune is the one doubled inside a name.
+	CloseQuoteRune rune
+
 	// NumberedPlaceholders renders binds as $1..$N (PostgreSQL) instead of ?.
 	NumberedPlaceholders bool
 
+	// Placeholder, when set, renders the n-th bind (counting from 1) and
+	// supersedes NumberedPlaceholders: @p1 on SQL Server, :1 on Oracle. The
+	// builders still emit '?' and rewrite the assembled statement once (see
+	// numberPlaceholders).
+	Placeholder func(n int) string
+
 	// RegexOperator renders figo.RegexExpr (=~ / !=~): REGEXP on MySQL/SQLite,
-	// ~ (or ~* for case-insensitive) on PostgreSQL.
+	// ~ (or ~* for case-insensitive) on PostgreSQL. A dialect with neither
+	// RegexOperator nor RegexFunction has no regex, and =~ fails the render.
 	RegexOperator string
 
 	// EscapeBackslash doubles backslashes in interpolated string literals.
@@ -45,8 +61,51 @@ type SQLDialect struct {
 	EscapeBackslash bool
 
 	// NoLimitToken is the LIMIT value paired with a bare OFFSET (OFFSET
-	// without LIMIT is a syntax error on MySQL/SQLite).
+	// without LIMIT is a syntax error on MySQL/SQLite). Unused with Paginate.
 	NoLimitToken string
+
+	// Paginate, when set, renders the paging clause in place of LIMIT/OFFSET.
+	// It is called only when take or skip is positive; ordered reports
+	// whether the statement has an ORDER BY, for engines where OFFSET/FETCH
+	// is only valid after one. The clause is one unit, so a segment render
+	// must ask for PAGE rather than LIMIT or OFFSET alone.
+	Paginate func(take, skip int, ordered bool) string
+
+	// NoBooleanLiterals renders true/false as 1/0 in interpolated SQL, for
+	// engines without boolean literals (SQL Server, Oracle before 23ai).
+	// Bound args are handed to the driver unchanged.
+	NoBooleanLiterals bool
+
+	// OmitAliasAS writes a table alias without the AS keyword, which Oracle
+	// rejects in a FROM clause.
+	OmitAliasAS bool
+
+	// EscapeIdentBackslash doubles backslashes inside quoted identifiers too.
+	// ClickHouse reads '\' as an escape in a backtick identifier as well as in
+	// a string literal, so a name ending in a backslash would otherwise escape
+	// the doubled quote rune meant to close it.
+	EscapeIdentBackslash bool
+
+	// ILikeOperator renders figo.ILikeExpr natively (col ILIKE ?). Empty keeps
+	// the portable LOWER(col) LIKE LOWER(?).
+	ILikeOperator string
+
+	// RegexFunction, when set, renders figo.RegexExpr as a call fn(col, ?)
+	// (ClickHouse's match) instead of through RegexOperator.
+	RegexFunction string
+
+	// ArrayContainsFunction and ArrayOverlapsFunction render the array
+	// predicates: figo.ArrayContainsExpr as fn(col, ?) per required element,
+	// figo.ArrayOverlapsExpr as fn(col, [?, ...]) — has and hasAny on
+	// ClickHouse. Empty means the dialect has no array columns and the
+	// expressions fail the render, as they always have.
+	ArrayContainsFunction string
+	ArrayOverlapsFunction string
+
+	// JSONExtractFunctions renders figo.JsonPathExpr with ClickHouse's
+	// JSONExtract*/JSONHas family (see jsonPathToSQL). Without it the
+	// expression fails the render.
+	JSONExtractFunctions bool
 }
 
 // MySQLDialect is the default: backtick identifiers, ? placeholders, REGEXP.
@@ -80,6 +139,105 @@ var SQLiteDialect = &SQLDialect{
 	NoLimitToken:         "-1",
 }
 
+// ClickHouseDialect: backtick identifiers, ? placeholders, match() regex,
+// native ILIKE, has/hasAny for the array predicates and JSONExtract* for JSON
+// paths. Backslash is an escape character in both string literals and quoted
+// identifiers. LIMIT is a UInt64, so a bare OFFSET pairs with its maximum.
+var ClickHouseDialect = &SQLDialect{
+	Name:                  "clickhouse",
+	QuoteRune:             '`',
+	NumberedPlaceholders:  false,
+	RegexFunction:         "match",
+	EscapeBackslash:       true,
+	NoLimitToken:          "18446744073709551615",
+	EscapeIdentBackslash:  true,
+	ILikeOperator:         "ILIKE",
+	ArrayContainsFunction: "has",
+	ArrayOverlapsFunction: "hasAny",
+	JSONExtractFunctions:  true,
+}
+
+// SQLServerDialect: [bracket] identifiers, @p1..@pN placeholders and
+// ORDER BY ... OFFSET n ROWS FETCH NEXT m ROWS ONLY paging. OFFSET/FETCH is
+// only valid after an ORDER BY, so an unsorted page orders by (SELECT NULL) —
+// valid, but as arbitrary as any unsorted page. SQL Server has no regex
+// operator (=~ fails the render) and no boolean literals.
+var SQLServerDialect = &SQLDialect{
+	Name:              "sqlserver",
+	QuoteRune:         '[',
+	CloseQuoteRune:    ']',
+	Placeholder:       func(n int) string { return "@p" + itoa(n) },
+	EscapeBackslash:   false,
+	Paginate:          sqlServerPaginate,
+	NoBooleanLiterals: true,
+}
+
+// OracleDialect: double-quoted identifiers, :1..:N placeholders,
+// REGEXP_LIKE(col, pattern) and OFFSET/FETCH paging (12c and later). Quoted
+// identifiers are case-sensitive on Oracle and unquoted ones fold to upper
+// case, so a schema created without quotes needs an upper-casing naming func.
+// Oracle also stores the empty string as NULL, so name="" never matches.
+var OracleDialect = &SQLDialect{
+	Name:              "oracle",
+	QuoteRune:         '"',
+	Placeholder:       func(n int) string { return ":" + itoa(n) },
+	RegexFunction:     "REGEXP_LIKE",
+	EscapeBackslash:   false,
+	Paginate:          offsetFetch,
+	NoBooleanLiterals: true,
+	OmitAliasAS:       true,
+}
+
+// offsetFetch renders SQL:2008 paging, which Oracle accepts with or without
+// an ORDER BY.
+func offsetFetch(take, skip int, ordered bool) string {
+	if take <= 0 {
+		return fmt.Sprintf("OFFSET %d ROWS", skip)
+	}
+	return fmt.Sprintf("OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", skip, take)
+}
+
+func sqlServerPaginate(take, skip int, ordered bool) string {
+	if !ordered {
+		return "ORDER BY (SELECT NULL) " + offsetFetch(take, skip, true)
+	}
+	return offsetFetch(take, skip, true)
+}
+
+// numbered reports whether binds are rewritten from '?' after assembly.
+func (d *SQLDialect) numbered() bool {
+	return d.NumberedPlaceholders || d.Placeholder != nil
+}
+
+// closeQuote returns the rune that closes a quoted identifier.
+func (d *SQLDialect) closeQuote() string {
+	if d.CloseQuoteRune != 0 {
+		return string(d.CloseQuoteRune)
+	}
+	return string(d.QuoteRune)
+}
+
+// bracketIdentEnd reports whether sql[i] opens a [bracket] identifier and
+// returns the index of the ']' closing it ("]]" inside is an escaped
+// bracket). Only a dialect quoting with '[' has them — anywhere else '[' is an
+// ordinary byte, like the array literal of ClickHouse's hasAny. The '?'
+// scanners skip the identifier whole, so a '?' in [a?b] is never a bind.
+func (d *SQLDialect) bracketIdentEnd(sql string, i int) (int, bool) {
+	if d.QuoteRune != '[' || sql[i] != '[' {
+		return 0, false
+	}
+	for j := i + 1; j < len(sql); j++ {
+		if sql[j] == ']' {
+			if j+1 < len(sql) && sql[j+1] == ']' {
+				j++
+				continue
+			}
+			return j, true
+		}
+	}
+	return len(sql) - 1, true
+}
+
 // quoteIdent quotes an identifier with the dialect's quote rune, escaping
 // embedded quote runes by doubling them. A dotted name is quoted per segment
 // (users.first_name -> `users`.`first_name`) so a qualified reference set via
@@ -87,15 +245,18 @@ var SQLiteDialect = &SQLDialect{
 // naming a nonexistent dotted column. Every segment still has embedded quote
 // runes doubled, so the injection hardening is unchanged.
 func (d *SQLDialect) quoteIdent(ident string) string {
-	q := string(d.QuoteRune)
+	q, c := string(d.QuoteRune), d.closeQuote()
+	if d.EscapeIdentBackslash {
+		ident = strings.ReplaceAll(ident, "\\", "\\\\")
+	}
 	if strings.Contains(ident, ".") {
 		segs := strings.Split(ident, ".")
 		for i, s := range segs {
-			segs[i] = q + strings.ReplaceAll(s, q, q+q) + q
+			segs[i] = q + strings.ReplaceAll(s, c, c+c) + c
 		}
 		return strings.Join(segs, ".")
 	}
-	return q + strings.ReplaceAll(ident, q, q+q) + q
+	return q + strings.ReplaceAll(ident, c, c+c) + c
 }
 
 // validateIdent rejects identifiers that quoting cannot turn into executable
@@ -138,9 +299,9 @@ func (d *SQLDialect) escapeString(s string) string {
 	return strings.ReplaceAll(s, "'", "''")
 }
 
-// numberPlaceholders rewrites ?-style binds to $1..$N, skipping quoted
-// regions (string literals and quoted identifiers) so a literal '?' inside
-// them is never renumbered. On dialects where '\' escapes inside a string
+// numberPlaceholders rewrites ?-style binds to $1..$N (or the dialect's
+// Placeholder), skipping quoted regions (string literals and quoted
+// identifiers) so a literal '?' inside them is never renumbered. On dialects where '\' escapes inside a string
 // literal (EscapeBackslash), a backslash consumes the next byte: a CustomExpr
 // fragment containing \' otherwise flipped the scanner out of the literal and
 // every following placeholder was mis-numbered.
@@ -160,6 +321,13 @@ func numberPlaceholders(d *SQLDialect, sql string) string {
 			b.WriteByte(sql[i])
 			continue
 		}
+		if !inSingle && !inDouble && !inBacktick {
+			if end, ok := d.bracketIdentEnd(sql, i); ok {
+				b.WriteString(sql[i : end+1])
+				i = end
+				continue
+			}
+		}
 		if ch == '\'' && !inDouble && !inBacktick {
 			inSingle = !inSingle
 		} else if ch == '"' && !inSingle && !inBacktick {
@@ -168,6 +336,10 @@ func numberPlaceholders(d *SQLDialect, sql string) string {
 			inBacktick = !inBacktick
 		} else if ch == '?' && !inSingle && !inDouble && !inBacktick {
 			n++
+			if d.Placeholder != nil {
+				b.WriteString(d.Placeholder(n))
+				continue
+			}
 			b.WriteByte('$')
 			b.WriteString(itoa(n))
 			continue
@@ -190,7 +362,8 @@ func itoa(n int) string {
 	return string(digits)
 }
 
-// rawDialectOf resolves the dialect from the INSTANCE's adapter. It is for the
+// rawRendererOf resolves the dialect, and the relation configuration, from
+// the INSTANCE's adapter. It is for the
 // receiver-less package-level Build* helpers only — the RawAdapter methods
 // thread their own receiver's dialect instead, because an instance built with
 // nil (or with a different adapter, or via SetAdapterObject after the fact)
@@ -200,14 +373,14 @@ func itoa(n int) string {
 // Both the value and the pointer form are accepted: *RawAdapter satisfies
 // figo.Adapter through the value receivers, so asserting only the value type
 // silently fell back to MySQL for a caller who wrote Build(&RawAdapter{...}).
-func rawDialectOf(f figo.Figo) *SQLDialect {
+func rawRendererOf(f figo.Figo) sqlRenderer {
 	switch ra := f.GetAdapterObject().(type) {
 	case RawAdapter:
-		return ra.dialect()
+		return ra.renderer()
 	case *RawAdapter:
 		if ra != nil {
-			return ra.dialect()
+			return ra.renderer()
 		}
 	}
-	return MySQLDialect
+	return sqlRenderer{SQLDialect: MySQLDialect}
 }
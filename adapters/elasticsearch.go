@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // esMaxResultWindow is Elasticsearch's default index.max_result_window. figo's
@@ -17,8 +19,231 @@ import (
 // Result sets beyond it need search_after/scroll.
 const esMaxResultWindow = 10000
 
-// ElasticsearchAdapter provides query building for Elasticsearch
-type ElasticsearchAdapter struct{}
+// ElasticsearchAdapter provides query building for Elasticsearch. Relations
+// describes the relations quantified predicates may name (orders<any>[...]);
+// see ESRelation. A predicate naming a relation missing from it fails the
+// build.
+//
+// NestedPaths lists the fields mapped as type nested ("items", and
+// "items.variants" for a nested-in-nested field — full paths from the document
+// root). Each nested object is indexed as a hidden document of its own, so a
+// plain term query on items.sku matches nothing, and flattening two
+// conditions onto the parent would pair the sku of one item with the price of
+// another. With the paths declared:
+//
+//   - a predicate on a field under a nested path renders inside a nested
+//     query — it reads "some item satisfies it", and not negates that to "no
+//     item satisfies it";
+//   - predicates under the same path that are operands of the same AND are
+//     grouped into ONE nested query, so items.sku="A" and items.qty>2 needs a
+//     single item with both;
+//   - a sort key under a nested path carries its nested context;
+//   - load=[items:...] naming a nested path renders as a nested query with
+//     inner_hits, returning the matching objects beside each hit without
+//     narrowing the hits themselves, like a preload on the other adapters.
+//     The condition's fields are the nested object's own (sku, not items.sku).
+//
+// A load= preload is resolved through Relations first, then NestedPaths: a
+// ChildType relation renders as has_child with inner_hits, a nested one as
+// nested with inner_hits. Elasticsearch returns 3 inner hits per hit unless
+// told otherwise; InnerHitsSize (when > 0) sets that for every preload, up to
+// the index's max_inner_result_window (100 by default).
+//
+// Scoring switches to relevance ranking. By default every predicate renders in
+// one bool.must and the hits' scores mean nothing in particular. With Scoring
+// set, the full-text conjuncts of the query — a FullTextSearchExpr, or the
+// text of a q= directive, ANDed at the top level — render in bool.must, where
+// they score, and every other predicate renders in bool.filter, where it
+// narrows without scoring (and is cacheable). Full-text predicates under an
+// or/not stay structural. sort=_score:desc orders by the score.
+//
+// SearchFields lists the fields a field-less full-text search (q=) targets,
+// each with an optional boost in Elasticsearch's own syntax ("title^3",
+// "body"); without it multi_match searches the index's default fields. A
+// field-scoped full-text search on a listed field carries the field's boost.
+type ElasticsearchAdapter struct {
+	Relations     map[string]ESRelation
+	NestedPaths   []string
+	InnerHitsSize int
+	Scoring       bool
+	SearchFields  []string
+}
+
+// ESRelation tells the Elasticsearch adapter how a relation named in a
+// quantified predicate or a load= preload is indexed — the counterpart of
+// MongoJoin. Set exactly one of the two:
+//
+//   - Path: the related rows are a nested field of the parent document
+//     ("orders", or "orders.items" for a nested-in-nested field — always the
+//     FULL path from the document root). The predicate renders as a nested
+//     query, and the condition's fields are addressed under the path
+//     (status becomes orders.status).
+//   - ChildType: the related rows are child documents of a join field. The
+//     predicate renders as has_child, and the condition's fields are the
+//     child document's own. The join field's parent/child model is what
+//     makes a load= of it possible at all: has_child with inner_hits.
+type ESRelation struct {
+	Path      string
+	ChildType string
+}
+
+// esAdapterOf returns the ElasticsearchAdapter configured on the figo
+// instance, or the zero value when it was built with a different or nil
+// adapter. Both the value and the pointer form are accepted, as for
+// rawRendererOf.
+func esAdapterOf(f figo.Figo) ElasticsearchAdapter {
+	if f != nil {
+		switch t := f.GetAdapterObject().(type) {
+		case ElasticsearchAdapter:
+			return t
+		case *ElasticsearchAdapter:
+			if t != nil {
+				return *t
+			}
+		}
+	}
+	return ElasticsearchAdapter{}
+}
+
+// esRenderer carries per-build rendering context: the relation configuration
+// quantified predicates and preloads resolve against, the declared nested
+// paths, the preloads' inner_hits size, the scoring options, and the nested
+// path the expression being rendered already sits inside ("" at the
+// document root).
+type esRenderer struct {
+	relations     map[string]ESRelation
+	nestedPaths   []string
+	innerHitsSize int
+	scoring       bool
+	searchFields  []string
+	scope         string
+}
+
+// render builds the rendering context for this adapter's configuration.
+func (e ElasticsearchAdapter) render() esRenderer {
+	rc := esRenderer{relations: e.Relations, innerHitsSize: e.InnerHitsSize, scoring: e.Scoring, searchFields: e.SearchFields}
+	for _, p := range e.NestedPaths {
+		if p != "" {
+			rc.nestedPaths = append(rc.nestedPaths, p)
+		}
+	}
+	return rc
+}
+
+// within returns the context for rendering inside the nested query at path.
+func (rc esRenderer) within(path string) esRenderer {
+	rc.scope = path
+	return rc
+}
+
+// nestedPathOf returns the nested path a field needs a nested query for: the
+// OUTERMOST declared path the field lies under that is deeper than the current
+// scope. Deeper paths are reached one level at a time, because Elasticsearch
+// resolves a nested-in-nested query relative to its enclosing nested query.
+// "" means the field needs no (further) nested context.
+func (rc esRenderer) nestedPathOf(field string) string {
+	best := ""
+	for _, p := range rc.nestedPaths {
+		if !strings.HasPrefix(field, p+".") {
+			continue
+		}
+		if rc.scope != "" && !strings.HasPrefix(p, rc.scope+".") {
+			continue
+		}
+		if best == "" || len(p) < len(best) {
+			best = p
+		}
+	}
+	return best
+}
+
+// leafNestedPath is nestedPathOf for a leaf expression's field. Logical nodes
+// and relation predicates return "": they are never wrapped as a whole, their
+// leaves are.
+func (rc esRenderer) leafNestedPath(e figo.Expr) string {
+	if len(rc.nestedPaths) == 0 {
+		return ""
+	}
+	switch e.(type) {
+	case figo.AndExpr, figo.OrExpr, figo.NotExpr, figo.RelationExpr:
+		return ""
+	}
+	field := ""
+	figo.Walk(e, func(n figo.Expr) {
+		if f, ok := figo.NodeField(n); ok {
+			field = f
+		}
+	})
+	if field == "" {
+		return ""
+	}
+	return rc.nestedPathOf(field)
+}
+
+// esAndItem is one operand of a rendered AND: a single expression (path ""),
+// or every operand under the same nested path, rendered as one nested query.
+type esAndItem struct {
+	path string
+	ops  []figo.Expr
+}
+
+// groupNested partitions AND operands so predicates under the same nested
+// path land in one nested query — the same nested object has to satisfy all
+// of them. A group sits where its first operand was; AND is commutative, so
+// moving the others up to it changes nothing.
+func (rc esRenderer) groupNested(ops []figo.Expr) []esAndItem {
+	items := make([]esAndItem, 0, len(ops))
+	at := map[string]int{}
+	for _, op := range ops {
+		if op == nil {
+			continue
+		}
+		path := rc.leafNestedPath(op)
+		if path == "" {
+			items = append(items, esAndItem{ops: []figo.Expr{op}})
+			continue
+		}
+		if i, ok := at[path]; ok {
+			items[i].ops = append(items[i].ops, op)
+			continue
+		}
+		at[path] = len(items)
+		items = append(items, esAndItem{path: path, ops: []figo.Expr{op}})
+	}
+	return items
+}
+
+// renderAndItem renders one AND operand as esRenderExpr does.
+func (rc esRenderer) renderAndItem(item esAndItem, wantNeg bool) (pos, neg map[string]interface{}, unknown bool, err error) {
+	if item.path == "" {
+		return esRenderExpr(rc, item.ops[0], wantNeg)
+	}
+	if pos, err = rc.renderNested(item.path, item.ops); err != nil {
+		return nil, nil, false, err
+	}
+	if wantNeg {
+		neg = esMustNot(pos)
+	}
+	return pos, neg, false, nil
+}
+
+// renderNested wraps the conjunction of ops in a nested query at path. The
+// nested query is EXISTS over the nested objects and so is two-valued: its
+// negation is the plain exclusion, whatever the conditions inside it were.
+func (rc esRenderer) renderNested(path string, ops []figo.Expr) (map[string]interface{}, error) {
+	var inner figo.Expr = figo.AndExpr{Operands: ops}
+	if len(ops) == 1 {
+		inner = ops[0]
+	}
+	q, _, _, err := esRenderExpr(rc.within(path), inner, false)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"nested": map[string]interface{}{"path": path, "query": q}}, nil
+}
+
+// Store implements figo.StoreAdapter
+func (ElasticsearchAdapter) Store() string { return figo.StoreElasticsearch }
 
 // GetSqlString returns the JSON representation of the Elasticsearch query.
 //
@@ -37,7 +262,7 @@ func (e ElasticsearchAdapter) GetSqlString(f figo.Figo, ctx any, conditionType .
 		// that matches nothing rather than one that matches everything.
 		return esMatchNoneJSON(), false
 	}
-	query, err := BuildElasticsearchQuery(f)
+	query, err := buildElasticsearchQuery(f, e)
 	if err != nil {
 		query = matchNoneQuery()
 	}
@@ -60,7 +285,7 @@ func (e ElasticsearchAdapter) GetQuery(f figo.Figo, ctx any, conditionType ...st
 	if f == nil {
 		return ElasticsearchQueryWrapper{Query: matchNoneQuery()}, false
 	}
-	query, err := BuildElasticsearchQuery(f)
+	query, err := buildElasticsearchQuery(f, e)
 	if err != nil {
 		query = matchNoneQuery()
 	}
@@ -97,7 +322,10 @@ func (e ElasticsearchQueryWrapper) GetArgs() []any {
 	return nil
 }
 
-// ElasticsearchQuery represents the structure of an Elasticsearch query
+// ElasticsearchQuery represents the structure of an Elasticsearch query. The
+// fields past Source are search options the adapter never sets itself; the
+// builder's setters (SetHighlight, SetSearchAfter, SetTrackTotalHits,
+// SetTimeout, SetMinScore, SetCollapse) fill them in.
 type ElasticsearchQuery struct {
 	Query  map[string]interface{}   `json:"query"`
 	Sort   []map[string]interface{} `json:"sort,omitempty"`
@@ -105,6 +333,13 @@ type ElasticsearchQuery struct {
 	Size   int                      `json:"size,omitempty"`
 	Source []string                 `json:"_source,omitempty"`
 
+	Highlight      map[string]interface{} `json:"highlight,omitempty"`
+	SearchAfter    []interface{}          `json:"search_after,omitempty"`
+	TrackTotalHits interface{}            `json:"track_total_hits,omitempty"` // bool or int
+	Timeout        string                 `json:"timeout,omitempty"`          // an ES time unit: "500ms", "2s"
+	MinScore       *float64               `json:"min_score,omitempty"`
+	Collapse       map[string]interface{} `json:"collapse,omitempty"`
+
 	// sizeSet records that Size was chosen deliberately, so that a zero Size
 	// renders as "size":0 (a count-only search) instead of being dropped by
 	// omitempty — Elasticsearch then applies its default of 10 hits, which is
@@ -112,17 +347,31 @@ type ElasticsearchQuery struct {
 	sizeSet bool
 }
 
-// MarshalJSON renders the query. It exists solely so an explicitly requested
-// "size":0 survives; the field order matches the struct tags.
+// MarshalJSON renders the query. It exists so an explicitly requested
+// "size":0 survives, and it pins the key order golden files compare against:
+// the top-level keys follow the struct fields, and the map-valued options
+// (query, highlight, collapse) are ordered by encoding/json's sorted map keys.
+// A new field must be added here as well as to the struct, or it is silently
+// dropped from the request body.
 func (q ElasticsearchQuery) MarshalJSON() ([]byte, error) {
 	type esQueryBody struct {
-		Query  map[string]interface{}   `json:"query"`
-		Sort   []map[string]interface{} `json:"sort,omitempty"`
-		From   int                      `json:"from,omitempty"`
-		Size   *int                     `json:"size,omitempty"`
-		Source []string                 `json:"_source,omitempty"`
+		Query          map[string]interface{}   `json:"query"`
+		Sort           []map[string]interface{} `json:"sort,omitempty"`
+		From           int                      `json:"from,omitempty"`
+		Size           *int                     `json:"size,omitempty"`
+		Source         []string                 `json:"_source,omitempty"`
+		Highlight      map[string]interface{}   `json:"highlight,omitempty"`
+		SearchAfter    []interface{}            `json:"search_after,omitempty"`
+		TrackTotalHits interface{}              `json:"track_total_hits,omitempty"`
+		Timeout        string                   `json:"timeout,omitempty"`
+		MinScore       *float64                 `json:"min_score,omitempty"`
+		Collapse       map[string]interface{}   `json:"collapse,omitempty"`
+	}
+	body := esQueryBody{
+		Query: q.Query, Sort: q.Sort, From: q.From, Source: q.Source,
+		Highlight: q.Highlight, SearchAfter: q.SearchAfter, TrackTotalHits: q.TrackTotalHits,
+		Timeout: q.Timeout, MinScore: q.MinScore, Collapse: q.Collapse,
 	}
-	body := esQueryBody{Query: q.Query, Sort: q.Sort, From: q.From, Source: q.Source}
 	if q.Size != 0 || q.sizeSet {
 		size := q.Size
 		body.Size = &size
@@ -130,21 +379,25 @@ func (q ElasticsearchQuery) MarshalJSON() ([]byte, error) {
 	return json.Marshal(body)
 }
 
-// BuildElasticsearchQuery converts the built figo expressions into an Elasticsearch query
+// BuildElasticsearchQuery converts the built figo expressions into an
+// Elasticsearch query, with the configuration of the instance's
+// ElasticsearchAdapter (the zero value when it was built with another one).
 func BuildElasticsearchQuery(f figo.Figo) (ElasticsearchQuery, error) {
-	// ES has no join/preload analogue, so load= cannot be rendered. Every
-	// other adapter honors preloads (GORM Preload, Mongo $lookup, raw JOIN);
-	// failing the build keeps the file's fail-closed convention instead of
-	// silently discarding them.
-	if len(f.GetPreloads()) > 0 {
+	return buildElasticsearchQuery(f, esAdapterOf(f))
+}
+
+// buildElasticsearchQuery is BuildElasticsearchQuery with the adapter
+// supplied by the caller; the adapter methods pass their own receiver.
+func buildElasticsearchQuery(f figo.Figo, a ElasticsearchAdapter) (ElasticsearchQuery, error) {
+	rc := a.render()
+	q, err := buildElasticsearchQueryFromExprs(rc, f.GetClauses())
+	if err != nil {
 		// The error value is the fail-closed query, never the zero
 		// ElasticsearchQuery: that marshals to {"query":null}, which ES either
 		// rejects or (as a bare body) treats as match_all.
-		return matchNoneQuery(), fmt.Errorf("figo: the Elasticsearch adapter does not support load= preloads")
+		return matchNoneQuery(), err
 	}
-
-	q, err := buildElasticsearchQueryFromExprs(f.GetClauses())
-	if err != nil {
+	if q, err = esRenderPreloads(rc, q, f.GetPreloads()); err != nil {
 		return matchNoneQuery(), err
 	}
 	query := ElasticsearchQuery{
@@ -192,6 +445,14 @@ func BuildElasticsearchQuery(f figo.Figo) (ElasticsearchQuery, error) {
 					"order": "desc",
 				}
 			}
+			if nested := rc.sortNested(c.Name); nested != nil {
+				// A nested field sorts by a value of one of its objects; without
+				// the nested context ES sees no value at all and every hit ties.
+				sortField[c.Name] = map[string]interface{}{
+					"order":  sortField[c.Name].(map[string]string)["order"],
+					"nested": nested,
+				}
+			}
 			query.Sort = append(query.Sort, sortField)
 		}
 	}
@@ -209,8 +470,114 @@ func BuildElasticsearchQuery(f figo.Figo) (ElasticsearchQuery, error) {
 	return query, nil
 }
 
+// sortNested returns the nested sort context for a sort field under one or
+// more declared nested paths — {"path": outer, "nested": {"path": inner}} for
+// a nested-in-nested field — or nil for an ordinary field.
+func (rc esRenderer) sortNested(field string) map[string]interface{} {
+	path := rc.nestedPathOf(field)
+	if path == "" {
+		return nil
+	}
+	nested := map[string]interface{}{"path": path}
+	if inner := rc.within(path).sortNested(field); inner != nil {
+		nested["nested"] = inner
+	}
+	return nested
+}
+
+// esRenderPreloads adds load= preloads to the rendered query. Elasticsearch
+// has no join, but two index layouts keep the related rows beside the hit, and
+// each has a query that returns the matching ones as inner_hits:
+//
+//   - a relation configured in Relations with ChildType (a join field)
+//     renders as has_child; its condition's fields are the child's own;
+//   - a relation configured with Path, or a path listed in NestedPaths,
+//     renders as nested; the condition's fields are the nested object's own
+//     and are addressed under the path.
+//
+// Those queries go in should beside the query in must: with a must present
+// should is optional, so the preloads select related rows without narrowing
+// the hits — load= never filters parents on any adapter. Nor do they score:
+// nested gets score_mode none, which has_child already defaults to, so a
+// preload leaves _score and a Scoring sort as they were.
+//
+// A preload naming anything else still fails the build; silently discarding
+// it would hand back hits without the relation the caller asked for.
+func esRenderPreloads(rc esRenderer, q map[string]interface{}, preloads map[string][]figo.Expr) (map[string]interface{}, error) {
+	if len(preloads) == 0 {
+		return q, nil
+	}
+	names := make([]string, 0, len(preloads))
+	for name := range preloads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var should []map[string]interface{}
+	for _, name := range names {
+		rel, ok := rc.relations[name]
+		if ok && (rel.Path == "") == (rel.ChildType == "") {
+			return nil, fmt.Errorf("figo: ESRelation for relation %q must set exactly one of Path (nested) and ChildType (has_child)", name)
+		}
+		if !ok {
+			for _, p := range rc.nestedPaths {
+				if p == name {
+					rel, ok = ESRelation{Path: p}, true
+				}
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("figo: the Elasticsearch adapter cannot render the load= preload %q: configure it in ElasticsearchAdapter.Relations (has_child or nested inner_hits) or list it in ElasticsearchAdapter.NestedPaths", name)
+		}
+
+		conds, irc := preloads[name], rc.within("")
+		if rel.Path != "" {
+			conds, irc = esPrefixOperands(conds, rel.Path), rc.within(rel.Path)
+		}
+		inner := esMatchAllClause()
+		if len(conds) > 0 {
+			var cond figo.Expr = figo.AndExpr{Operands: conds}
+			if len(conds) == 1 {
+				cond = conds[0]
+			}
+			var err error
+			if inner, _, _, err = esRenderExpr(irc, cond, false); err != nil {
+				return nil, err
+			}
+		}
+
+		// inner_hits are keyed by the nested path or child type unless named;
+		// name them after the relation when that differs, so the caller finds
+		// each preload under the name it wrote in load=.
+		innerHits, defaultName := map[string]interface{}{}, rel.Path+rel.ChildType
+		if name != defaultName {
+			innerHits["name"] = name
+		}
+		if rc.innerHitsSize > 0 {
+			innerHits["size"] = rc.innerHitsSize
+		}
+		if rel.Path != "" {
+			should = append(should, map[string]interface{}{"nested": map[string]interface{}{
+				"path": rel.Path, "query": inner, "inner_hits": innerHits, "score_mode": "none",
+			}})
+		} else {
+			should = append(should, map[string]interface{}{"has_child": map[string]interface{}{
+				"type": rel.ChildType, "query": inner, "inner_hits": innerHits,
+			}})
+		}
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must":   []map[string]interface{}{q},
+			"should": should,
+		},
+	}, nil
+}
+
 // buildElasticsearchQueryFromExprs converts expressions to Elasticsearch query structure
-func buildElasticsearchQueryFromExprs(exprs []figo.Expr) (map[string]interface{}, error) {
+func buildElasticsearchQueryFromExprs(rc esRenderer, exprs []figo.Expr) (map[string]interface{}, error) {
+	if rc.scoring {
+		return esScoringQuery(rc, exprs)
+	}
 	if len(exprs) == 0 {
 		return map[string]interface{}{
 			"match_all": map[string]interface{}{},
@@ -218,16 +585,30 @@ func buildElasticsearchQueryFromExprs(exprs []figo.Expr) (map[string]interface{}
 	}
 
 	if len(exprs) == 1 {
-		q, _, _, err := esRenderExpr(exprs[0], false)
+		q, _, _, err := esRenderExpr(rc, exprs[0], false)
 		return q, err
 	}
 
+	if len(rc.nestedPaths) > 0 {
+		// The top level is an implicit AND, and nested predicates in separate
+		// clauses group exactly as they do inside a written one.
+		must := []map[string]interface{}{}
+		for _, item := range rc.groupNested(exprs) {
+			query, _, _, err := rc.renderAndItem(item, false)
+			if err != nil {
+				return nil, err
+			}
+			must = append(must, query)
+		}
+		return esAllOf(must), nil
+	}
+
 	// Multiple expressions - combine with bool query. wantNeg is false and the
 	// UNKNOWN taint is discarded: the top level is an implicit AND with no
 	// negation above it, so nothing here can invert the sentinel (see esRenderExpr).
 	must := []map[string]interface{}{}
 	for _, expr := range exprs {
-		query, _, _, err := esRenderExpr(expr, false)
+		query, _, _, err := esRenderExpr(rc, expr, false)
 		if err != nil {
 			return nil, err
 		}
@@ -317,16 +698,18 @@ func esAnyOf(clauses []map[string]interface{}) map[string]interface{} {
 // De Morgan holds in Kleene three-valued logic, so the structural cases just
 // push the negation down; only the leaves need the FALSE/UNKNOWN distinction,
 // which esNegateLeaf owns.
-func esRenderExpr(expr figo.Expr, wantNeg bool) (pos, neg map[string]interface{}, unknown bool, err error) {
+func esRenderExpr(rc esRenderer, expr figo.Expr, wantNeg bool) (pos, neg map[string]interface{}, unknown bool, err error) {
 	switch x := expr.(type) {
 	case figo.AndExpr:
+		items := rc.groupNested(x.Operands)
+		if len(items) == 1 && items[0].path != "" {
+			// Every operand went into one nested query; it is the AND.
+			return rc.renderAndItem(items[0], wantNeg)
+		}
 		must := []map[string]interface{}{}
 		var negs []map[string]interface{}
-		for _, op := range x.Operands {
-			if op == nil {
-				continue
-			}
-			p, n, u, err := esRenderExpr(op, wantNeg)
+		for _, item := range items {
+			p, n, u, err := rc.renderAndItem(item, wantNeg)
 			if err != nil {
 				return nil, nil, false, err
 			}
@@ -352,7 +735,7 @@ func esRenderExpr(expr figo.Expr, wantNeg bool) (pos, neg map[string]interface{}
 			if op == nil {
 				continue
 			}
-			p, n, u, err := esRenderExpr(op, wantNeg)
+			p, n, u, err := esRenderExpr(rc, op, wantNeg)
 			if err != nil {
 				return nil, nil, false, err
 			}
@@ -403,7 +786,7 @@ func esRenderExpr(expr figo.Expr, wantNeg bool) (pos, neg map[string]interface{}
 			if op == nil {
 				continue
 			}
-			p, n, u, err := esRenderExpr(op, true)
+			p, n, u, err := esRenderExpr(rc, op, true)
 			if err != nil {
 				return nil, nil, false, err
 			}
@@ -442,8 +825,34 @@ func esRenderExpr(expr figo.Expr, wantNeg bool) (pos, neg map[string]interface{}
 		}
 		return pos, neg, unknown, nil
 
+	case figo.RelationExpr:
+		pos, err = esRenderRelation(rc, x)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		// EXISTS is two-valued: a related row either satisfies the condition
+		// or it does not, so the plain exclusion is the exact negation.
+		if wantNeg {
+			neg = esMustNot(pos)
+		}
+		return pos, neg, false, nil
+
 	default:
-		pos, unknown, err = esRenderLeaf(expr)
+		if path := rc.leafNestedPath(expr); path != "" {
+			pos, err = rc.renderNested(path, []figo.Expr{expr})
+			if err != nil {
+				return nil, nil, false, err
+			}
+			if wantNeg {
+				neg = esMustNot(pos)
+			}
+			return pos, neg, false, nil
+		}
+		if x, ok := expr.(figo.FullTextSearchExpr); ok {
+			pos, err = rc.fullText(x)
+		} else {
+			pos, unknown, err = esRenderLeaf(expr)
+		}
 		if err != nil {
 			return nil, nil, false, err
 		}
@@ -454,6 +863,240 @@ func esRenderExpr(expr figo.Expr, wantNeg bool) (pos, neg map[string]interface{}
 	}
 }
 
+// esRenderRelation renders a quantified relation predicate as a nested or
+// has_child query (see ESRelation):
+//
+//	any   {"nested": {"path": P, "query": <cond>}}
+//	none  must_not of the above
+//	all   must_not {"nested": {"path": P, "query": must_not <cond>}}
+//
+// <all> is "no related row fails the condition", and must_not of the
+// condition's positive rendering counts a row whose condition is UNKNOWN (a
+// null field, the match_none sentinel) as failing — the same reading the SQL
+// adapters give it. A missing condition is match_all.
+func esRenderRelation(rc esRenderer, x figo.RelationExpr) (map[string]interface{}, error) {
+	switch x.Quantifier {
+	case figo.QuantifierAny, figo.QuantifierAll, figo.QuantifierNone:
+	default:
+		return nil, fmt.Errorf("figo: relation %q has unknown quantifier %q (want any, all or none)", x.Relation, x.Quantifier)
+	}
+	rel, ok := rc.relations[x.Relation]
+	if !ok {
+		return nil, fmt.Errorf("figo: no ESRelation configured for relation %q on the Elasticsearch adapter (ElasticsearchAdapter.Relations is keyed by the relation name exactly as it appears in the DSL)", x.Relation)
+	}
+	if (rel.Path == "") == (rel.ChildType == "") {
+		return nil, fmt.Errorf("figo: ESRelation for relation %q must set exactly one of Path (nested) and ChildType (has_child)", x.Relation)
+	}
+	if x.Cond == nil && x.Quantifier == figo.QuantifierAll {
+		return esMatchAllClause(), nil
+	}
+
+	cond := esMatchAllClause()
+	if x.Cond != nil {
+		inner := x.Cond
+		// The condition renders inside the relation's own query: inside the
+		// nested query when it is one (so a NestedPaths entry for the same
+		// path does not wrap it twice), and at the child document's root for
+		// has_child.
+		irc := rc.within("")
+		if rel.Path != "" {
+			inner = esPrefixFields(inner, rel.Path)
+			irc = rc.within(rel.Path)
+		}
+		var err error
+		if cond, _, _, err = esRenderExpr(irc, inner, false); err != nil {
+			return nil, err
+		}
+	}
+	if x.Quantifier == figo.QuantifierAll {
+		cond = esMustNot(cond)
+	}
+
+	var q map[string]interface{}
+	if rel.Path != "" {
+		q = map[string]interface{}{"nested": map[string]interface{}{"path": rel.Path, "query": cond}}
+	} else {
+		q = map[string]interface{}{"has_child": map[string]interface{}{"type": rel.ChildType, "query": cond}}
+	}
+	if x.Quantifier == figo.QuantifierAny {
+		return q, nil
+	}
+	return esMustNot(q), nil
+}
+
+// fullText renders a full-text search: match on a field, or multi_match
+// across SearchFields (the index's default fields when none are configured)
+// for a field-less search. The language maps to an analyzer, the closest ES
+// analogue of Mongo's $text $language.
+func (rc esRenderer) fullText(x figo.FullTextSearchExpr) (map[string]interface{}, error) {
+	boosts, err := rc.searchBoosts()
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{"query": x.Query}
+	if x.Language != "" {
+		body["analyzer"] = x.Language
+	}
+	if x.Field == "" {
+		if len(rc.searchFields) > 0 {
+			body["fields"] = rc.searchFields
+		}
+		return map[string]interface{}{"multi_match": body}, nil
+	}
+	if b, ok := boosts[x.Field]; ok {
+		body["boost"] = b
+	}
+	return map[string]interface{}{
+		"match": map[string]interface{}{x.Field: body},
+	}, nil
+}
+
+// searchBoosts parses SearchFields into field -> boost (only boosted fields
+// are present). A malformed entry fails the build: Elasticsearch would reject
+// the whole search for it.
+func (rc esRenderer) searchBoosts() (map[string]float64, error) {
+	var boosts map[string]float64
+	for _, entry := range rc.searchFields {
+		name, boost, hasBoost := strings.Cut(entry, "^")
+		if name == "" {
+			return nil, fmt.Errorf("figo: Elasticsearch SearchFields entry %q has no field name", entry)
+		}
+		if !hasBoost {
+			continue
+		}
+		b, err := strconv.ParseFloat(boost, 64)
+		if err != nil || b <= 0 || math.IsInf(b, 0) {
+			return nil, fmt.Errorf("figo: Elasticsearch SearchFields entry %q has an invalid boost (want a positive number after '^')", entry)
+		}
+		if boosts == nil {
+			boosts = map[string]float64{}
+		}
+		boosts[name] = b
+	}
+	return boosts, nil
+}
+
+// esScoringQuery renders the top-level clauses for Scoring mode: full-text
+// conjuncts in must, everything else in filter. Only conjuncts are split —
+// a full-text search under or/not cannot score independently of its siblings,
+// so it stays part of the structural filter.
+func esScoringQuery(rc esRenderer, exprs []figo.Expr) (map[string]interface{}, error) {
+	var scored, structural []figo.Expr
+	var split func([]figo.Expr)
+	split = func(ops []figo.Expr) {
+		for _, op := range ops {
+			switch x := op.(type) {
+			case nil:
+			case figo.AndExpr:
+				split(x.Operands)
+			case figo.FullTextSearchExpr:
+				scored = append(scored, x)
+			default:
+				structural = append(structural, x)
+			}
+		}
+	}
+	split(exprs)
+
+	body := map[string]interface{}{}
+	if len(scored) > 0 {
+		must := make([]map[string]interface{}, 0, len(scored))
+		for _, e := range scored {
+			q, _, _, err := esRenderExpr(rc, e, false)
+			if err != nil {
+				return nil, err
+			}
+			must = append(must, q)
+		}
+		body["must"] = must
+	}
+	if len(structural) > 0 {
+		rc.scoring = false
+		q, err := buildElasticsearchQueryFromExprs(rc, structural)
+		if err != nil {
+			return nil, err
+		}
+		filter := []map[string]interface{}{q}
+		// A bare conjunction's operands are the filter's own clauses.
+		if b, ok := q["bool"].(map[string]interface{}); ok && len(q) == 1 && len(b) == 1 {
+			if m, ok := b["must"].([]map[string]interface{}); ok {
+				filter = m
+			}
+		}
+		body["filter"] = filter
+	}
+	if len(body) == 0 {
+		return esMatchAllClause(), nil
+	}
+	return map[string]interface{}{"bool": body}, nil
+}
+
+// esMustNot excludes the documents clause matches.
+func esMustNot(clause map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"bool": map[string]interface{}{"must_not": []map[string]interface{}{clause}},
+	}
+}
+
+// esPrefixFields addresses a nested relation's condition under its path:
+// inside a nested query Elasticsearch wants the full field path
+// (orders.status), not the bare name the DSL wrote. A relation predicate
+// nested in the condition is left alone — its own ESRelation.Path is already
+// the full path, and it prefixes its condition when it is rendered.
+func esPrefixFields(e figo.Expr, path string) figo.Expr {
+	switch x := e.(type) {
+	case figo.AndExpr:
+		return figo.AndExpr{Operands: esPrefixOperands(x.Operands, path)}
+	case figo.OrExpr:
+		return figo.OrExpr{Operands: esPrefixOperands(x.Operands, path)}
+	case figo.NotExpr:
+		return figo.NotExpr{Operands: esPrefixOperands(x.Operands, path)}
+	case figo.RelationExpr:
+		return x
+	}
+	return figo.Walk(e, func(n figo.Expr) {
+		if field, ok := figo.NodeField(n); ok && field != "" {
+			figo.SetNodeField(n, path+"."+field)
+		}
+	})
+}
+
+func esPrefixOperands(ops []figo.Expr, path string) []figo.Expr {
+	out := make([]figo.Expr, len(ops))
+	for i, op := range ops {
+		if op != nil {
+			out[i] = esPrefixFields(op, path)
+		}
+	}
+	return out
+}
+
+// esRange renders a range query on field. A figo.DateMath bound — a
+// date-math literal such as now-7d — is rendered as Elasticsearch date math,
+// with its time_zone, so the cluster resolves it rather than the instant the
+// Build saw. The exception is a rounded bound under lte or gt: Elasticsearch
+// rounds those UP, to the end of the unit, where every other store rounds
+// down (created_at<=now/d is midnight), so they render the resolved instant.
+func esRange(field string, bounds map[string]interface{}) map[string]interface{} {
+	zone := ""
+	for op, v := range bounds {
+		d, ok := v.(figo.DateMath)
+		switch {
+		case !ok:
+		case (op == "lte" || op == "gt") && strings.Contains(d.Expr, "/"):
+			bounds[op] = d.Time
+		default:
+			bounds[op], zone = d.Expr, d.TimeZone()
+		}
+	}
+	if zone != "" {
+		bounds["time_zone"] = zone
+	}
+	return map[string]interface{}{
+		"range": map[string]interface{}{field: bounds},
+	}
+}
+
 // esNegateLeaf renders NOT(leaf) under SQL's three-valued logic, given the
 // leaf's already-computed positive clause and taint.
 //
@@ -515,17 +1158,9 @@ func esNegateLeaf(expr figo.Expr, pos map[string]interface{}, unknown bool) map[
 		case x.Low == nil && x.High == nil:
 			return esMatchNoneClause()
 		case x.Low == nil:
-			return map[string]interface{}{
-				"range": map[string]interface{}{
-					x.Field: map[string]interface{}{"gt": x.High},
-				},
-			}
+			return esRange(x.Field, map[string]interface{}{"gt": x.High})
 		case x.High == nil:
-			return map[string]interface{}{
-				"range": map[string]interface{}{
-					x.Field: map[string]interface{}{"lt": x.Low},
-				},
-			}
+			return esRange(x.Field, map[string]interface{}{"lt": x.Low})
 		}
 	case figo.ArrayContainsExpr:
 		if len(x.Values) == 0 {
@@ -659,11 +1294,7 @@ func esRenderLeaf(expr figo.Expr) (map[string]interface{}, bool, error) {
 		if x.Value == nil {
 			return esMatchNoneClause(), true, nil
 		}
-		return map[string]interface{}{
-			"range": map[string]interface{}{
-				x.Field: map[string]interface{}{"gte": x.Value},
-			},
-		}, false, nil
+		return esRange(x.Field, map[string]interface{}{"gte": x.Value}), false, nil
 	case figo.GtExpr:
 		// A nil operand on the four range operators used to render
 		// {"range":{"a":{"gt":null}}}; ES documents a null bound as UNBOUNDED,
@@ -675,29 +1306,17 @@ func esRenderLeaf(expr figo.Expr) (map[string]interface{}, bool, error) {
 		if x.Value == nil {
 			return esMatchNoneClause(), true, nil
 		}
-		return map[string]interface{}{
-			"range": map[string]interface{}{
-				x.Field: map[string]interface{}{"gt": x.Value},
-			},
-		}, false, nil
+		return esRange(x.Field, map[string]interface{}{"gt": x.Value}), false, nil
 	case figo.LtExpr:
 		if x.Value == nil {
 			return esMatchNoneClause(), true, nil
 		}
-		return map[string]interface{}{
-			"range": map[string]interface{}{
-				x.Field: map[string]interface{}{"lt": x.Value},
-			},
-		}, false, nil
+		return esRange(x.Field, map[string]interface{}{"lt": x.Value}), false, nil
 	case figo.LteExpr:
 		if x.Value == nil {
 			return esMatchNoneClause(), true, nil
 		}
-		return map[string]interface{}{
-			"range": map[string]interface{}{
-				x.Field: map[string]interface{}{"lte": x.Value},
-			},
-		}, false, nil
+		return esRange(x.Field, map[string]interface{}{"lte": x.Value}), false, nil
 	case figo.NeqExpr:
 		if x.Value == nil {
 			// Canonical across adapters: != nil is the IS NOT NULL predicate.
@@ -796,11 +1415,7 @@ func esRenderLeaf(expr figo.Expr) (map[string]interface{}, bool, error) {
 			// UNKNOWN and matches none. Fail closed, like the range operators.
 			return esMatchNoneClause(), true, nil
 		}
-		return map[string]interface{}{
-			"range": map[string]interface{}{
-				x.Field: map[string]interface{}{"gte": x.Low, "lte": x.High},
-			},
-		}, false, nil
+		return esRange(x.Field, map[string]interface{}{"gte": x.Low, "lte": x.High}), false, nil
 	case figo.JsonPathExpr:
 		if x.Value == nil {
 			switch x.Op {
@@ -852,27 +1467,6 @@ func esRenderLeaf(expr figo.Expr) (map[string]interface{}, bool, error) {
 		return map[string]interface{}{
 			"terms": map[string]interface{}{x.Field: vals},
 		}, esHasNilValue(x.Values), nil
-	case figo.FullTextSearchExpr:
-		if x.Field == "" {
-			// No target field: search across all fields via multi_match. The
-			// language maps to an analyzer exactly like the field-scoped match.
-			mm := map[string]interface{}{"query": x.Query}
-			if x.Language != "" {
-				mm["analyzer"] = x.Language
-			}
-			return map[string]interface{}{
-				"multi_match": mm,
-			}, false, nil
-		}
-		body := map[string]interface{}{"query": x.Query}
-		if x.Language != "" {
-			// The closest ES analogue of Mongo's $text $language is a
-			// language analyzer on the match query.
-			body["analyzer"] = x.Language
-		}
-		return map[string]interface{}{
-			"match": map[string]interface{}{x.Field: body},
-		}, false, nil
 	case figo.GeoDistanceExpr:
 		unit, err := esGeoUnit(x.Unit)
 		if err != nil {
@@ -1070,16 +1664,75 @@ type ElasticsearchQueryBuilder struct {
 	// the original bad values — the exact stickiness A7-3 removed from FromFigo,
 	// reintroduced one method below it.
 	pageErr error
+
+	// optErrs holds the deferred errors of the search-option setters, keyed by
+	// option, for the same reason pageErr has its own field: a valid call to
+	// one setter clears that setter's error and no other.
+	optErrs map[string]error
+
+	// The highlight fields and tags are kept apart and composed into
+	// query.Highlight on every change, so SetHighlight and SetHighlightTags can
+	// be called in either order.
+	highlightFields []string
+	highlightPre    string
+	highlightPost   string
 }
 
 // deferredErr reports the first deferred error, if any. FromFigo's error wins:
-// it means the builder holds no usable query at all, whereas a pagination error
-// leaves the query intact.
+// it means the builder holds no usable query at all, whereas a pagination or
+// option error leaves the query intact. search_after is checked last and at
+// build time, because its constraints involve the sort and the offset, which
+// may be set after it.
 func (b *ElasticsearchQueryBuilder) deferredErr() error {
 	if b.err != nil {
 		return b.err
 	}
-	return b.pageErr
+	if b.pageErr != nil {
+		return b.pageErr
+	}
+	if len(b.optErrs) > 0 {
+		keys := make([]string, 0, len(b.optErrs))
+		for k := range b.optErrs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return b.optErrs[keys[0]]
+	}
+	return b.searchAfterErr()
+}
+
+// setOptErr records (err != nil) or clears (err == nil) the deferred error of
+// one search option.
+func (b *ElasticsearchQueryBuilder) setOptErr(option string, err error) {
+	if err == nil {
+		delete(b.optErrs, option)
+		return
+	}
+	if b.optErrs == nil {
+		b.optErrs = map[string]error{}
+	}
+	b.optErrs[option] = err
+}
+
+// searchAfterErr reports a search_after the server would reject with HTTP 400
+// for the whole search: it resumes after the sort values of the last hit, so it
+// needs a sort with exactly one value per key, and it replaces from, which
+// must then be 0.
+func (b *ElasticsearchQueryBuilder) searchAfterErr() error {
+	q := b.query
+	if len(q.SearchAfter) == 0 {
+		return nil
+	}
+	if len(q.Sort) == 0 {
+		return fmt.Errorf("figo: Elasticsearch search_after needs a sort")
+	}
+	if len(q.SearchAfter) != len(q.Sort) {
+		return fmt.Errorf("figo: Elasticsearch search_after has %d value(s) but the sort has %d key(s)", len(q.SearchAfter), len(q.Sort))
+	}
+	if q.From > 0 {
+		return fmt.Errorf("figo: Elasticsearch search_after cannot be combined with from=%d; leave from at 0", q.From)
+	}
+	return nil
 }
 
 // NewElasticsearchQueryBuilder creates a new Elasticsearch query builder
@@ -1115,9 +1768,11 @@ func matchNoneQuery() ElasticsearchQuery {
 // reused builder to match_none forever while it held a perfectly good query,
 // and the error it reported named an expression type no longer present.
 func (b *ElasticsearchQueryBuilder) FromFigo(f figo.Figo) *ElasticsearchQueryBuilder {
-	// FromFigo replaces b.query wholesale, including From/Size, so a pagination
-	// error deferred against the DISCARDED pagination must go with it.
+	// FromFigo replaces b.query wholesale, including From/Size and the search
+	// options, so errors deferred against the DISCARDED values must go with them.
 	b.pageErr = nil
+	b.optErrs = nil
+	b.highlightFields, b.highlightPre, b.highlightPost = nil, "", ""
 	q, err := BuildElasticsearchQuery(f)
 	if err != nil {
 		b.err = err
@@ -1129,7 +1784,8 @@ func (b *ElasticsearchQueryBuilder) FromFigo(f figo.Figo) *ElasticsearchQueryBui
 	return b
 }
 
-// Err returns the error deferred by FromFigo or SetPagination, if any.
+// Err returns the error deferred by FromFigo, SetPagination or a search-option
+// setter, if any.
 func (b *ElasticsearchQueryBuilder) Err() error { return b.deferredErr() }
 
 // AddSort adds a sort field to the query. An empty field name is skipped:
@@ -1187,6 +1843,134 @@ func (b *ElasticsearchQueryBuilder) SetSource(fields ...string) *ElasticsearchQu
 	return b
 }
 
+// SetHighlight requests highlighted fragments for the given fields, each with
+// the server's default settings. Empty names are skipped like AddSort's; no
+// fields at all removes the highlight.
+func (b *ElasticsearchQueryBuilder) SetHighlight(fields ...string) *ElasticsearchQueryBuilder {
+	b.highlightFields = b.highlightFields[:0:0]
+	for _, field := range fields {
+		if field != "" {
+			b.highlightFields = append(b.highlightFields, field)
+		}
+	}
+	b.composeHighlight()
+	return b
+}
+
+// SetHighlightTags replaces the <em></em> tags wrapped around highlighted
+// terms. The tags only take effect alongside SetHighlight fields: a highlight
+// without fields highlights nothing, so none is rendered.
+func (b *ElasticsearchQueryBuilder) SetHighlightTags(pre, post string) *ElasticsearchQueryBuilder {
+	b.highlightPre, b.highlightPost = pre, post
+	b.composeHighlight()
+	return b
+}
+
+func (b *ElasticsearchQueryBuilder) composeHighlight() {
+	if len(b.highlightFields) == 0 {
+		b.query.Highlight = nil
+		return
+	}
+	fields := make(map[string]interface{}, len(b.highlightFields))
+	for _, field := range b.highlightFields {
+		fields[field] = map[string]interface{}{}
+	}
+	highlight := map[string]interface{}{"fields": fields}
+	if b.highlightPre != "" || b.highlightPost != "" {
+		highlight["pre_tags"] = []string{b.highlightPre}
+		highlight["post_tags"] = []string{b.highlightPost}
+	}
+	b.query.Highlight = highlight
+}
+
+// SetSearchAfter resumes the search after the hit with these sort values — the
+// "sort" array of the last hit of the previous page — which, unlike from/size,
+// pages past the max_result_window. It needs a sort with one key per value and
+// from left at 0; a mismatch is reported when the query is built. No values
+// removes the option. The variadic is copied, as in SetSource.
+func (b *ElasticsearchQueryBuilder) SetSearchAfter(values ...interface{}) *ElasticsearchQueryBuilder {
+	b.query.SearchAfter = append([]interface{}(nil), values...)
+	if len(b.query.SearchAfter) == 0 {
+		b.query.SearchAfter = nil
+	}
+	return b
+}
+
+// SetTrackTotalHits switches exact hit counting on or off. Elasticsearch
+// counts accurately only up to 10,000 hits by default and reports the rest as
+// a lower bound.
+func (b *ElasticsearchQueryBuilder) SetTrackTotalHits(track bool) *ElasticsearchQueryBuilder {
+	b.setOptErr("track_total_hits", nil)
+	b.query.TrackTotalHits = track
+	return b
+}
+
+// SetTrackTotalHitsUpTo counts hits accurately up to limit. A negative limit
+// is rejected with a deferred error, as the server would reject the search.
+func (b *ElasticsearchQueryBuilder) SetTrackTotalHitsUpTo(limit int) *ElasticsearchQueryBuilder {
+	if limit < 0 {
+		b.setOptErr("track_total_hits", fmt.Errorf("figo: negative Elasticsearch track_total_hits (%d) is rejected by the server", limit))
+		return b
+	}
+	b.setOptErr("track_total_hits", nil)
+	b.query.TrackTotalHits = limit
+	return b
+}
+
+// SetTimeout bounds the time each shard spends on the search; hits collected
+// before it expires are returned with "timed_out":true. Zero removes the
+// option and a negative duration is rejected with a deferred error.
+func (b *ElasticsearchQueryBuilder) SetTimeout(d time.Duration) *ElasticsearchQueryBuilder {
+	if d < 0 {
+		b.setOptErr("timeout", fmt.Errorf("figo: negative Elasticsearch timeout (%s)", d))
+		return b
+	}
+	b.setOptErr("timeout", nil)
+	b.query.Timeout = esTimeUnit(d)
+	return b
+}
+
+// esTimeUnit renders a duration in the coarsest Elasticsearch time unit that
+// represents it exactly; ES does not accept Go's compound "1m30s" form.
+func esTimeUnit(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	for _, u := range []struct {
+		unit time.Duration
+		name string
+	}{{time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"}, {time.Millisecond, "ms"}, {time.Microsecond, "micros"}} {
+		if d%u.unit == 0 {
+			return strconv.FormatInt(int64(d/u.unit), 10) + u.name
+		}
+	}
+	return strconv.FormatInt(int64(d), 10) + "nanos"
+}
+
+// SetMinScore drops hits scoring below score. NaN and infinities are rejected
+// with a deferred error: encoding/json cannot marshal them, so the request
+// body could not be produced at all.
+func (b *ElasticsearchQueryBuilder) SetMinScore(score float64) *ElasticsearchQueryBuilder {
+	if math.IsNaN(score) || math.IsInf(score, 0) {
+		b.setOptErr("min_score", fmt.Errorf("figo: Elasticsearch min_score must be a finite number, got %v", score))
+		return b
+	}
+	b.setOptErr("min_score", nil)
+	b.query.MinScore = &score
+	return b
+}
+
+// SetCollapse keeps only the top hit of each distinct value of field (a
+// keyword or numeric field with doc_values). An empty field removes the option.
+func (b *ElasticsearchQueryBuilder) SetCollapse(field string) *ElasticsearchQueryBuilder {
+	if field == "" {
+		b.query.Collapse = nil
+		return b
+	}
+	b.query.Collapse = map[string]interface{}{"field": field}
+	return b
+}
+
 // Build returns the final Elasticsearch query. When FromFigo deferred an
 // error it returns the fail-closed match_none query — never a match-all with
 // the filters stripped; use Err or BuildE to observe the error.
@@ -0,0 +1,169 @@
+package adapters
+
+import (
+	. "github.com/bi0dread/figo/v4"
+
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Nested paths are dotted, and the default snake_case naming joins the
+// segments with '_'; these tests keep the names as written.
+func esNestedJSON(t *testing.T, a ElasticsearchAdapter, dsl string) string {
+	t.Helper()
+	f := New()
+	f.SetNamingFunc(NoChangeNaming)
+	require.NoError(t, f.AddFiltersFromString(dsl))
+	f.Build(a)
+	q, err := BuildElasticsearchQuery(f)
+	require.NoError(t, err)
+	b, err := json.Marshal(q)
+	require.NoError(t, err)
+	return string(b)
+}
+
+var esNestedItems = ElasticsearchAdapter{NestedPaths: []string{"items", "items.variants"}}
+
+// Predicates on the same nested path inside one AND must be satisfied by the
+// SAME nested object: one nested query, not two (which would pair the sku of
+// one item with the quantity of another).
+func TestESNestedGroupsSamePathUnderAnd(t *testing.T) {
+	got := esNestedJSON(t, esNestedItems, `items.sku="A" and status="open" and items.qty>2`)
+	assert.JSONEq(t, `{"query":{"bool":{"must":[
+		{"nested":{"path":"items","query":{"bool":{"must":[
+			{"term":{"items.sku":"A"}},
+			{"range":{"items.qty":{"gt":2}}}]}}}},
+		{"term":{"status":"open"}}]}},"size":10000}`, got)
+
+	// Separate top-level clauses are an implicit AND and group the same way.
+	f := New()
+	f.SetNamingFunc(NoChangeNaming)
+	f.AddFilter(EqExpr{Field: "items.sku", Value: "A"})
+	f.AddFilter(GtExpr{Field: "items.qty", Value: 2})
+	f.Build(esNestedItems)
+	q, err := BuildElasticsearchQuery(f)
+	require.NoError(t, err)
+	b, _ := json.Marshal(q.Query)
+	assert.JSONEq(t, `{"nested":{"path":"items","query":{"bool":{"must":[
+		{"term":{"items.sku":"A"}},{"range":{"items.qty":{"gt":2}}}]}}}}`, string(b))
+}
+
+// Outside an AND each predicate is its own "some item" test; not negates it
+// to "no item", the plain exclusion of the nested query.
+func TestESNestedOrAndNot(t *testing.T) {
+	assert.JSONEq(t, `{"query":{"bool":{"should":[
+		{"nested":{"path":"items","query":{"term":{"items.sku":"A"}}}},
+		{"nested":{"path":"items","query":{"term":{"items.sku":"B"}}}}],
+		"minimum_should_match":1}},"size":10000}`,
+		esNestedJSON(t, esNestedItems, `items.sku="A" or items.sku="B"`))
+
+	assert.JSONEq(t, `{"query":{"bool":{"must_not":[
+		{"nested":{"path":"items","query":{"bool":{"must":[
+			{"term":{"items.sku":"A"}},{"range":{"items.qty":{"gt":2}}}]}}}}]}},"size":10000}`,
+		esNestedJSON(t, esNestedItems, `not (items.sku="A" and items.qty>2)`))
+}
+
+// A nested-in-nested field is reached one level at a time.
+func TestESNestedInNested(t *testing.T) {
+	assert.JSONEq(t, `{"query":{"nested":{"path":"items","query":{"bool":{"must":[
+		{"term":{"items.sku":"A"}},
+		{"nested":{"path":"items.variants","query":{"term":{"items.variants.color":"red"}}}}]}}}},"size":10000}`,
+		esNestedJSON(t, esNestedItems, `items.sku="A" and items.variants.color="red"`))
+}
+
+// Without NestedPaths, and for fields outside them, rendering is unchanged.
+func TestESNestedPathsLeaveOtherFieldsAlone(t *testing.T) {
+	for _, a := range []ElasticsearchAdapter{{}, esNestedItems} {
+		assert.JSONEq(t, `{"query":{"bool":{"must":[{"term":{"itemsCount":3}},{"term":{"a.b":1}}]}},"size":10000}`,
+			esNestedJSON(t, a, `itemsCount=3 and a.b=1`))
+	}
+	assert.JSONEq(t, `{"query":{"bool":{"must":[{"term":{"items.sku":"A"}},{"term":{"items.qty":1}}]}},"size":10000}`,
+		esNestedJSON(t, ElasticsearchAdapter{}, `items.sku="A" and items.qty=1`))
+}
+
+// A relation predicate over a declared nested path is already a nested query;
+// its condition must not be wrapped a second time.
+func TestESNestedRelationNotWrappedTwice(t *testing.T) {
+	a := ElasticsearchAdapter{NestedPaths: []string{"items"}, Relations: map[string]ESRelation{"items": {Path: "items"}}}
+	assert.JSONEq(t, `{"query":{"nested":{"path":"items","query":{"term":{"items.sku":"A"}}}},"size":10000}`,
+		esNestedJSON(t, a, `items<any>[sku="A"]`))
+}
+
+func TestESNestedSort(t *testing.T) {
+	f := New()
+	f.SetNamingFunc(NoChangeNaming)
+	require.NoError(t, f.AddFiltersFromString(`sort=items.variants.price:desc,name:asc`))
+	f.Build(esNestedItems)
+	q, err := BuildElasticsearchQuery(f)
+	require.NoError(t, err)
+	b, _ := json.Marshal(q.Sort)
+	assert.JSONEq(t, `[
+		{"items.variants.price":{"order":"desc","nested":{"path":"items","nested":{"path":"items.variants"}}}},
+		{"name":{"order":"asc"}}]`, string(b))
+}
+
+// load= of a nested path returns the matching objects as inner_hits without
+// narrowing the hits: the preload sits in should beside the query in must.
+func TestESNestedPreloadRendersInnerHits(t *testing.T) {
+	assert.JSONEq(t, `{"query":{"bool":{
+		"must":[{"term":{"status":"open"}}],
+		"should":[{"nested":{"path":"items","score_mode":"none","inner_hits":{},"query":{"bool":{"must":[
+			{"term":{"items.sku":"A"}},
+			{"nested":{"path":"items.variants","query":{"term":{"items.variants.color":"red"}}}}]}}}}]}},"size":10000}`,
+		esNestedJSON(t, esNestedItems, `status="open" load=[items:sku="A" and variants.color="red"]`))
+
+	// An unfiltered preload returns every object.
+	assert.JSONEq(t, `{"query":{"bool":{
+		"must":[{"match_all":{}}],
+		"should":[{"nested":{"path":"items","score_mode":"none","inner_hits":{},"query":{"match_all":{}}}}]}},"size":10000}`,
+		esNestedJSON(t, esNestedItems, `load=[items:]`))
+}
+
+// A preload of anything that is not a declared nested path still fails closed.
+func TestESNestedPreloadOfUndeclaredPathFails(t *testing.T) {
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(`a=1 load=[orders:b=2]`))
+	f.Build(esNestedItems)
+	q, err := BuildElasticsearchQuery(f)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `preload "orders"`)
+	assert.Contains(t, q.Query, "match_none")
+}
+
+// load= of a join-field child relation renders as has_child with inner_hits,
+// named after the relation as written in the DSL; the condition's fields are
+// the child's own.
+func TestESPreloadAsHasChild(t *testing.T) {
+	a := ElasticsearchAdapter{
+		Relations:     map[string]ESRelation{"Comments": {ChildType: "comment"}, "Tags": {Path: "tags"}},
+		InnerHitsSize: 50,
+	}
+	assert.JSONEq(t, `{"query":{"bool":{
+		"must":[{"term":{"status":"open"}}],
+		"should":[
+			{"has_child":{"type":"comment","inner_hits":{"name":"Comments","size":50},
+				"query":{"bool":{"must":[{"term":{"approved":true}},{"range":{"votes":{"gte":3}}}]}}}},
+			{"nested":{"path":"tags","score_mode":"none","inner_hits":{"name":"Tags","size":50},"query":{"match_all":{}}}}]}},"size":10000}`,
+		esNestedJSON(t, a, `status="open" load=[Comments:approved=true and votes>=3 | Tags:]`))
+
+	// The default inner_hits name needs no override.
+	b := ElasticsearchAdapter{Relations: map[string]ESRelation{"comment": {ChildType: "comment"}}}
+	assert.JSONEq(t, `{"query":{"bool":{
+		"must":[{"match_all":{}}],
+		"should":[{"has_child":{"type":"comment","inner_hits":{},"query":{"term":{"approved":true}}}}]}},"size":10000}`,
+		esNestedJSON(t, b, `load=[comment:approved=true]`))
+}
+
+// A preload still fails closed when its relation is misconfigured.
+func TestESPreloadRelationNeedsExactlyOneTarget(t *testing.T) {
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(`load=[Comments:a=1]`))
+	f.Build(ElasticsearchAdapter{Relations: map[string]ESRelation{"Comments": {}}})
+	q, err := BuildElasticsearchQuery(f)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exactly one of Path")
+	assert.Contains(t, q.Query, "match_none")
+}
@@ -0,0 +1,135 @@
+package adapters
+
+import (
+	. "github.com/bi0dread/figo/v4"
+
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func esOptionsBuilder(t *testing.T, dsl string) *ElasticsearchQueryBuilder {
+	t.Helper()
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(dsl))
+	f.Build(ElasticsearchAdapter{})
+	return NewElasticsearchQueryBuilder().FromFigo(f)
+}
+
+// Golden files compare the body byte for byte, so the key order is part of the
+// contract: not JSONEq here. encoding/json escapes the tags' angle brackets,
+// which Elasticsearch reads back unchanged.
+func TestESQueryOptionsKeyOrder(t *testing.T) {
+	got, err := esOptionsBuilder(t, `status="open" sort=created:desc,id:asc page=skip:0,take:20`).
+		SetCollapse("user_id").
+		SetMinScore(0.5).
+		SetTimeout(1500*time.Millisecond).
+		SetTrackTotalHits(true).
+		SetSearchAfter(int64(1700000000), "b7").
+		SetHighlightTags("<b>", "</b>").
+		SetHighlight("title", "", "body").
+		SetSource("id").
+		ToJSONCompact()
+	require.NoError(t, err)
+	assert.Equal(t, `{"query":{"term":{"status":"open"}},`+
+		`"sort":[{"created":{"order":"desc"}},{"id":{"order":"asc"}}],"size":20,"_source":["id"],`+
+		`"highlight":{"fields":{"body":{},"title":{}},"post_tags":["\u003c/b\u003e"],"pre_tags":["\u003cb\u003e"]},`+
+		`"search_after":[1700000000,"b7"],"track_total_hits":true,"timeout":"1500ms",`+
+		`"min_score":0.5,"collapse":{"field":"user_id"}}`, got)
+}
+
+// Zero values of the options that have a meaning of their own still render.
+func TestESQueryOptionsExplicitZeroValues(t *testing.T) {
+	q, err := esOptionsBuilder(t, `a=1`).SetTrackTotalHits(false).SetMinScore(0).BuildE()
+	require.NoError(t, err)
+	assert.Equal(t, false, q.TrackTotalHits)
+	got, err := esOptionsBuilder(t, `a=1`).SetTrackTotalHits(false).SetMinScore(0).ToJSONCompact()
+	require.NoError(t, err)
+	assert.Contains(t, got, `"track_total_hits":false,"min_score":0`)
+
+	got, err = esOptionsBuilder(t, `a=1`).SetTrackTotalHitsUpTo(50000).SetTimeout(2 * time.Minute).ToJSONCompact()
+	require.NoError(t, err)
+	assert.Contains(t, got, `"track_total_hits":50000,"timeout":"2m"`)
+}
+
+func TestESQueryOptionsRemoval(t *testing.T) {
+	got, err := esOptionsBuilder(t, `a=1 sort=id:asc`).
+		SetHighlight("title").SetHighlight().
+		SetSearchAfter(1).SetSearchAfter().
+		SetCollapse("user_id").SetCollapse("").
+		SetTimeout(time.Second).SetTimeout(0).
+		ToJSONCompact()
+	require.NoError(t, err)
+	assert.Equal(t, `{"query":{"term":{"a":1}},"sort":[{"id":{"order":"asc"}}],"size":10000}`, got)
+
+	// Tags alone highlight nothing.
+	q := esOptionsBuilder(t, `a=1`).SetHighlightTags("<b>", "</b>").Build()
+	assert.Nil(t, q.Highlight)
+}
+
+func TestESTimeUnit(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                       "",
+		2 * time.Hour:           "2h",
+		90 * time.Second:        "90s",
+		250 * time.Millisecond:  "250ms",
+		1500 * time.Microsecond: "1500micros",
+		1001:                    "1001nanos",
+	} {
+		assert.Equal(t, want, esTimeUnit(d), d.String())
+	}
+}
+
+// search_after is rejected when the server would reject it, and the builder
+// fails closed; fixing the sort or the offset afterwards clears the error.
+func TestESSearchAfterValidation(t *testing.T) {
+	b := esOptionsBuilder(t, `a=1`).SetSearchAfter(10)
+	q, err := b.BuildE()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "needs a sort")
+	assert.Contains(t, q.Query, "match_none")
+
+	b.AddSort("id", true).AddSort("name", true)
+	require.Error(t, b.Err())
+	assert.Contains(t, b.Err().Error(), "1 value(s) but the sort has 2")
+
+	b.SetSearchAfter(10, "x").SetPagination(20, 10)
+	require.Error(t, b.Err())
+	assert.Contains(t, b.Err().Error(), "from=20")
+
+	b.SetPagination(0, 10)
+	q, err = b.BuildE()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{10, "x"}, q.SearchAfter)
+}
+
+// A bad option poisons only itself: a valid call to the same setter clears it,
+// a valid call to another does not, and FromFigo clears them all.
+func TestESQueryOptionErrorsAreIndependent(t *testing.T) {
+	b := esOptionsBuilder(t, `a=1`).SetTimeout(-time.Second).SetMinScore(math.NaN())
+	require.Error(t, b.Err())
+
+	b.SetTimeout(time.Second)
+	require.Error(t, b.Err())
+	assert.Contains(t, b.Err().Error(), "min_score")
+
+	b.SetMinScore(1)
+	require.NoError(t, b.Err())
+
+	b.SetTrackTotalHitsUpTo(-1)
+	require.Error(t, b.Err())
+	b.SetTrackTotalHits(true)
+	require.NoError(t, b.Err())
+
+	b.SetMinScore(math.Inf(1)).SetSearchAfter(1)
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(`a=2`))
+	f.Build(ElasticsearchAdapter{})
+	q, err := b.FromFigo(f).BuildE()
+	require.NoError(t, err)
+	assert.Nil(t, q.SearchAfter)
+	assert.Nil(t, q.MinScore)
+}
@@ -0,0 +1,138 @@
+package adapters
+
+import (
+	. "github.com/bi0dread/figo/v4"
+
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func esScoringJSON(t *testing.T, a ElasticsearchAdapter, dsl string) (string, error) {
+	t.Helper()
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(dsl))
+	f.Build(a)
+	q, err := BuildElasticsearchQuery(f)
+	b, mErr := json.Marshal(q)
+	require.NoError(t, mErr)
+	return string(b), err
+}
+
+var esScoring = ElasticsearchAdapter{Scoring: true, SearchFields: []string{"title^3", "body"}}
+
+// Full-text conjuncts score in must; the structural predicates narrow in
+// filter.
+func TestESScoringSplitsMustAndFilter(t *testing.T) {
+	got, err := esScoringJSON(t, esScoring, `q="red shoes" status="open" and price<100 sort=_score:desc`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"query":{"bool":{
+		"must":[{"multi_match":{"query":"red shoes","fields":["title^3","body"]}}],
+		"filter":[{"term":{"status":"open"}},{"range":{"price":{"lt":100}}}]}},
+		"sort":[{"_score":{"order":"desc"}}],"size":10000}`, got)
+}
+
+// A field-scoped search on a listed field carries its boost; several
+// full-text conjuncts all score.
+func TestESScoringFieldBoost(t *testing.T) {
+	f := New()
+	f.AddFilter(FullTextSearchExpr{Field: "title", Query: "shoes"})
+	f.AddFilter(FullTextSearchExpr{Field: "summary", Query: "red", Language: "english"})
+	f.Build(esScoring)
+	q, err := BuildElasticsearchQuery(f)
+	require.NoError(t, err)
+	b, _ := json.Marshal(q.Query)
+	assert.JSONEq(t, `{"bool":{"must":[
+		{"match":{"title":{"query":"shoes","boost":3}}},
+		{"match":{"summary":{"query":"red","analyzer":"english"}}}]}}`, string(b))
+}
+
+// A full-text search under or/not cannot score independently of its
+// siblings; it stays in the structural filter.
+func TestESScoringKeepsDisjunctionStructural(t *testing.T) {
+	got, err := esScoringJSON(t, esScoring, `(status="open" or status="new") and not archived=true`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"query":{"bool":{"filter":[
+		{"bool":{"should":[{"term":{"status":"open"}},{"term":{"status":"new"}}],"minimum_should_match":1}},
+		{"bool":{"must_not":[{"term":{"archived":true}}]}}]}},"size":10000}`, got)
+
+	got, err = esScoringJSON(t, esScoring, ``)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"query":{"match_all":{}},"size":10000}`, got)
+}
+
+// Without Scoring the q= search renders where it always would, in must with
+// everything else; SearchFields still picks its fields.
+func TestESSearchDirectiveWithoutScoring(t *testing.T) {
+	got, err := esScoringJSON(t, ElasticsearchAdapter{}, `q=shoes status="open"`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"query":{"bool":{"must":[{"term":{"status":"open"}},{"multi_match":{"query":"shoes"}}]}},"size":10000}`, got)
+
+	got, err = esScoringJSON(t, ElasticsearchAdapter{SearchFields: []string{"title"}}, `q=shoes`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"query":{"multi_match":{"query":"shoes","fields":["title"]}},"size":10000}`, got)
+}
+
+// A malformed boost would make Elasticsearch reject the whole search.
+func TestESSearchFieldsRejectsBadBoost(t *testing.T) {
+	for _, entry := range []string{"title^", "title^x", "title^-1", "^2"} {
+		got, err := esScoringJSON(t, ElasticsearchAdapter{Scoring: true, SearchFields: []string{entry}}, `q=shoes`)
+		require.Error(t, err, entry)
+		assert.Contains(t, got, "match_none", entry)
+	}
+}
+
+// q= and _score belong to Elasticsearch. On SQL q= still filters a column
+// named q, and a relevance sort is refused rather than rendered as a column.
+func TestSearchAndScoreSortOnSQL(t *testing.T) {
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(`q="shoes"`))
+	require.NoError(t, f.BuildE(RawAdapter{}))
+	where, args, err := BuildRawWhere(f)
+	require.NoError(t, err)
+	assert.Equal(t, "`q` = ?", where)
+	assert.Equal(t, []any{"shoes"}, args)
+
+	f = New()
+	require.NoError(t, f.AddFiltersFromString(`a=1 sort=_score:desc`))
+	err = f.BuildE(RawAdapter{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only Elasticsearch has a relevance score")
+	assert.Nil(t, f.GetSort())
+
+	// Set programmatically, it fails the render.
+	f = New()
+	f.SetSort(&OrderBy{Columns: []OrderByColumn{{Name: ScoreSortField, Desc: true}}})
+	f.Build(RawAdapter{})
+	_, _, err = BuildRawSelect(f, "items")
+	assert.ErrorContains(t, err, "cannot sort by _score")
+
+	db := newGormRegDB(t)
+	applied := ApplyGorm(f, db.Model(&gormRegModel{}))
+	assert.ErrorContains(t, applied.Error, "cannot sort by _score")
+}
+
+// A preload's should clause scores nothing, so load= leaves the _score of
+// every hit, and a _score sort, as they were.
+func TestESScoringPreloadDoesNotScore(t *testing.T) {
+	a := esScoring
+	a.Relations = map[string]ESRelation{"Comments": {ChildType: "comment"}, "Tags": {Path: "tags"}}
+	without, err := esScoringJSON(t, a, `q="red shoes" status="open" sort=_score:desc`)
+	require.NoError(t, err)
+	with, err := esScoringJSON(t, a, `q="red shoes" status="open" sort=_score:desc load=[Comments:approved=true | Tags:name="x"]`)
+	require.NoError(t, err)
+
+	var w, p map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(without), &w))
+	require.NoError(t, json.Unmarshal([]byte(with), &p))
+	assert.Equal(t, w["sort"], p["sort"])
+	b := p["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	assert.Equal(t, []interface{}{w["query"]}, b["must"])
+	should := b["should"].([]interface{})
+	require.Len(t, should, 2)
+	hasChild := should[0].(map[string]interface{})["has_child"].(map[string]interface{})
+	assert.NotContains(t, hasChild, "score_mode") // has_child defaults to none
+	assert.Equal(t, "none", should[1].(map[string]interface{})["nested"].(map[string]interface{})["score_mode"])
+}
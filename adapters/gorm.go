@@ -26,6 +26,21 @@ import (
 // snake_case default but rendered nonexistent columns (t_t_age) for any
 // non-idempotent naming strategy.
 func toGormClauseWithFigo(e figo.Expr, f figo.Figo) (clause.Expression, error) {
+	return toGormClause(e, f, gormRelScope{relations: gormRelationsOf(f)})
+}
+
+// gormRelScope is the correlation context for quantified relation
+// predicates: the configured relations, and the alias of the enclosing
+// relation subquery (empty at the top level, where the parent is the
+// statement's own table).
+type gormRelScope struct {
+	relations map[string]SQLRelation
+	outer     string
+	depth     int
+}
+
+// toGormClause is toGormClauseWithFigo inside a relation scope.
+func toGormClause(e figo.Expr, f figo.Figo, rs gormRelScope) (clause.Expression, error) {
 	getFieldName := func(field string) string { return field }
 
 	// convertOperands maps a logical node's operand list, propagating the
@@ -36,7 +51,7 @@ func toGormClauseWithFigo(e figo.Expr, f figo.Figo) (clause.Expression, error) {
 			if op == nil {
 				continue
 			}
-			part, err := toGormClauseWithFigo(op, f)
+			part, err := toGormClause(op, f, rs)
 			if err != nil {
 				return nil, err
 			}
@@ -189,6 +204,8 @@ func toGormClauseWithFigo(e figo.Expr, f figo.Figo) (clause.Expression, error) {
 			return nil, fmt.Errorf("gorm adapter: CustomExpr handler for field %q: %w", x.Field, err)
 		}
 		return clause.Expr{SQL: frag, Vars: gormProtectByteSlices(args)}, nil
+	case figo.RelationExpr:
+		return gormRelationClause(x, f, rs)
 	case figo.OrderBy:
 		// A sort spec is not a boolean predicate, so it renders as nothing in
 		// an expression position — exactly what the raw adapter does with one.
@@ -205,6 +222,71 @@ func toGormClauseWithFigo(e figo.Expr, f figo.Figo) (clause.Expression, error) {
 	}
 }
 
+// gormRelationClause renders a quantified relation predicate as a correlated
+// subquery, with the same shape and semantics as the raw adapter's
+// relationToSQL (see sqlQuantify). The parent side of the join is qualified
+// with the statement's table (clause.CurrentTable) or the enclosing
+// relation's alias: a bare LocalKey would resolve to the subquery's own
+// column and compare the child with itself.
+func gormRelationClause(x figo.RelationExpr, f figo.Figo, rs gormRelScope) (clause.Expression, error) {
+	rel, err := lookupSQLRelation("gorm adapter", rs.relations, x)
+	if err != nil {
+		return nil, err
+	}
+	local := rel.localKey()
+	for _, id := range [...][2]string{{"relation table", rel.Table}, {"relation foreign key", rel.ForeignKey}, {"relation local key", local}} {
+		if err := gormIdentScreen(id[0], id[1]); err != nil {
+			return nil, err
+		}
+	}
+	parent := clause.Column{Table: clause.CurrentTable, Name: local}
+	if strings.Contains(local, ".") {
+		parent = clause.Column{Name: local}
+	} else if rs.outer != "" {
+		parent = clause.Column{Table: rs.outer, Name: local}
+	}
+
+	alias := sqlRelationAlias(rs.depth + 1)
+	vars := []any{
+		clause.Table{Name: rel.Table},
+		clause.Table{Name: alias},
+		clause.Column{Table: alias, Name: rel.ForeignKey},
+		parent,
+	}
+	cond := ""
+	if x.Cond != nil {
+		inner, err := toGormClause(x.Cond, f, gormRelScope{relations: rs.relations, outer: alias, depth: rs.depth + 1})
+		if err != nil {
+			return nil, err
+		}
+		if inner != nil {
+			cond = "?"
+			vars = append(vars, inner)
+		}
+	}
+	if x.Quantifier == figo.QuantifierAll && cond == "" {
+		// Vacuously true; sqlQuantify renders no subquery to bind vars into.
+		return clause.Expr{SQL: "1=1"}, nil
+	}
+	return clause.Expr{SQL: sqlQuantify(x.Quantifier, "SELECT 1 FROM ? AS ? WHERE ? = ?", cond), Vars: vars}, nil
+}
+
+// gormRelationsOf reads the relation configuration from the instance's
+// adapter. ApplyGorm is package-level and receives no adapter, so this is the
+// only place it can come from; the pointer form is accepted for the same
+// reason rawRendererOf accepts it.
+func gormRelationsOf(f figo.Figo) map[string]SQLRelation {
+	switch ga := f.GetAdapterObject().(type) {
+	case GormAdapter:
+		return ga.Relations
+	case *GormAdapter:
+		if ga != nil {
+			return ga.Relations
+		}
+	}
+	return nil
+}
+
 // gormAppliedSetting marks a *gorm.DB that already went through ApplyGorm so
 // the adapter never double-applies. A caller-scoped DB (tenant filters etc.)
 // does not carry the marker, so figo's filters are applied on top of it.
@@ -493,6 +575,13 @@ func ApplyGorm(f figo.Figo, trx *gorm.DB) *gorm.DB {
 	// converter (see the figo.OrderBy case there).
 	sort := f.GetSort()
 	if sort != nil {
+		for _, c := range sort.Columns {
+			if c.Name == figo.ScoreSortField {
+				// There is no column to sort by: fail closed, as above.
+				_ = trx.AddError(fmt.Errorf("figo: cannot sort by %s: only Elasticsearch has a relevance score", figo.ScoreSortField))
+				return trx
+			}
+		}
 		if ce := gormOrderByClause(*sort); ce != nil {
 			trx = trx.Clauses(ce)
 		}
@@ -605,8 +694,16 @@ func AdapterGormGetSql(f figo.Figo, ctx any, conditionType ...string) (string, b
 	return getGormSqlString(applied, conditionType...), true
 }
 
-// GormAdapter is an Adapter object you can pass to Build
-type GormAdapter struct{}
+// GormAdapter is an Adapter object you can pass to Build. Relations
+// describes the relations quantified predicates may name (orders<any>[...]);
+// see SQLRelation. A predicate naming a relation missing from it fails the
+// render.
+type GormAdapter struct {
+	Relations map[string]SQLRelation
+}
+
+// Store implements figo.StoreAdapter
+func (GormAdapter) Store() string { return figo.StoreSQL }
 
 func (GormAdapter) GetSqlString(f figo.Figo, ctx any, conditionType ...string) (string, bool) {
 	if f == nil {
@@ -340,11 +340,19 @@ func mongoAggregatePipeline(f figo.Figo, joins map[string]MongoJoin, a MongoAdap
 		aliases[j.As] = true
 	}
 
-	// root filter
-	rootMatch, err := buildMongoFilterFromExprs(f.GetClauses(), a.render())
+	// root filter; relation predicates in it collect the $lookup stages they
+	// test into rels.
+	rels := &mongoRelations{joins: joins}
+	rrc := a.render()
+	rrc.rel = rels
+	rootMatch, err := buildMongoFilterFromExprs(f.GetClauses(), rrc)
 	if err != nil {
 		return nil, err
 	}
+	if len(rels.stages) > 0 && anyContainsFullText(f.GetClauses()) {
+		return nil, fmt.Errorf("figo: a relation predicate cannot be combined with full-text search ($text) on the MongoDB adapter — its $lookup must run before the $match, and $text must be the first pipeline stage")
+	}
+	pipeline = append(pipeline, rels.stages...)
 
 	// A root clause that addresses a lookup alias ("Orders.total>5") can only be
 	// evaluated once the $lookup has created that field; matched first it saw a
@@ -359,6 +367,9 @@ func mongoAggregatePipeline(f figo.Figo, joins map[string]MongoJoin, a MongoAdap
 	if len(rootMatch) > 0 && !rootAfterLookups {
 		pipeline = append(pipeline, bson.D{{Key: "$match", Value: rootMatch}})
 	}
+	if !rootAfterLookups {
+		pipeline = append(pipeline, rels.cleanup()...)
+	}
 
 	for i, relation := range relations {
 		j := resolved[i]
@@ -385,13 +396,18 @@ func mongoAggregatePipeline(f figo.Figo, joins map[string]MongoJoin, a MongoAdap
 				"$eq": []any{"$" + j.ForeignField, "$$" + mongoLookupLocalVar},
 			}}}},
 		}
-		childMatch, err := buildMongoFilterFromExprs(preloadExprs[relation], a.renderPreload(relation))
+		prels := &mongoRelations{joins: joins}
+		prc := a.renderPreload(relation)
+		prc.rel = prels
+		childMatch, err := buildMongoFilterFromExprs(preloadExprs[relation], prc)
 		if err != nil {
 			return nil, err
 		}
+		sub = append(sub, prels.stages...)
 		if len(childMatch) > 0 {
 			sub = append(sub, bson.D{{Key: "$match", Value: childMatch}})
 		}
+		sub = append(sub, prels.cleanup()...)
 		pipeline = append(pipeline, bson.D{{Key: "$lookup", Value: bson.D{
 			{Key: "from", Value: j.From},
 			{Key: "let", Value: bson.D{{Key: mongoLookupLocalVar, Value: "$" + j.LocalField}}},
@@ -425,6 +441,7 @@ func mongoAggregatePipeline(f figo.Figo, joins map[string]MongoJoin, a MongoAdap
 
 	if rootAfterLookups {
 		pipeline = append(pipeline, bson.D{{Key: "$match", Value: rootMatch}})
+		pipeline = append(pipeline, rels.cleanup()...)
 	}
 
 	// sort= and page= must survive the aggregation path with the same
@@ -509,12 +526,111 @@ func exprsReferenceAlias(exprs []figo.Expr, aliases map[string]bool) bool {
 
 // mongoRender carries per-build rendering context: the preload relation whose
 // $lookup sub-pipeline is being rendered (empty at the root), whether the node
-// is being rendered underneath a NOT, and the set of fields whose hex-string
-// values convert to ObjectIDs.
+// is being rendered underneath a NOT, the set of fields whose hex-string
+// values convert to ObjectIDs, and where relation predicates put the $lookup
+// stages they depend on (nil on the Find path, which has no pipeline).
 type mongoRender struct {
 	preload   string
 	negated   bool
 	oidFields map[string]bool
+	rel       *mongoRelations
+}
+
+// mongoRelations collects the $lookup stages the relation predicates of ONE
+// match document depend on. A quantified predicate cannot be a plain filter:
+// the related documents live in another collection, so each predicate runs a
+// correlated $lookup into a private field (__figo_rel<n>) ahead of the
+// $match, and the match tests whether that field's array is empty. cleanup
+// removes the private fields again once the match has run.
+type mongoRelations struct {
+	joins   map[string]MongoJoin
+	stages  []bson.D
+	aliases []string
+}
+
+// mongoRelationAliasPrefix names the private $lookup output fields.
+const mongoRelationAliasPrefix = "__figo_rel"
+
+// cleanup returns the stage dropping the private lookup fields, or nothing
+// when no relation predicate was rendered.
+func (r *mongoRelations) cleanup() []bson.D {
+	if len(r.aliases) == 0 {
+		return nil
+	}
+	drop := make(bson.D, 0, len(r.aliases))
+	for _, alias := range r.aliases {
+		drop = append(drop, bson.E{Key: alias, Value: 0})
+	}
+	return []bson.D{{{Key: "$project", Value: drop}}}
+}
+
+// relation renders a quantified relation predicate: the $lookup goes into
+// rc.rel, the returned filter tests its output. The lookup's sub-pipeline
+// keeps only the related documents that decide the predicate — those matching
+// the condition for <any>/<none>, those FAILING it for <all> — and stops at the
+// first one, so the parent test is a plain emptiness check:
+//
+//	any   {"__figo_rel1.0": {"$exists": true}}
+//	none  {"__figo_rel1.0": {"$exists": false}}
+//	all   {"__figo_rel1.0": {"$exists": false}}  (no counterexample)
+//
+// A relation predicate nested in the condition renders inside the
+// sub-pipeline the same way, correlated with the related document.
+func (rc mongoRender) relation(x figo.RelationExpr) (bson.M, error) {
+	if rc.rel == nil {
+		return nil, fmt.Errorf("figo: the MongoDB Find path cannot evaluate the %q relation predicate (it needs a $lookup); render the aggregate path instead (GetQuery(joins, \"AGG\") or BuildMongoAggregatePipeline)", x.Relation)
+	}
+	switch x.Quantifier {
+	case figo.QuantifierAny, figo.QuantifierAll, figo.QuantifierNone:
+	default:
+		return nil, fmt.Errorf("figo: relation %q has unknown quantifier %q (want any, all or none)", x.Relation, x.Quantifier)
+	}
+	j, err := resolveMongoJoin(x.Relation, rc.rel.joins)
+	if err != nil {
+		return nil, err
+	}
+	if x.Cond == nil && x.Quantifier == figo.QuantifierAll {
+		// Every related document satisfies no condition: vacuously true.
+		return bson.M{}, nil
+	}
+
+	sub := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{"$expr": bson.M{
+			"$eq": []any{"$" + j.ForeignField, "$$" + mongoLookupLocalVar},
+		}}}},
+	}
+	if x.Cond != nil {
+		// The condition addresses the related collection's own fields, like a
+		// preload's sub-pipeline; $text is refused there for the same reason.
+		crc := mongoRender{preload: x.Relation, oidFields: rc.oidFields, rel: &mongoRelations{joins: rc.rel.joins}}
+		cond, err := mongoExpr(x.Cond, crc)
+		if err != nil {
+			return nil, err
+		}
+		if x.Quantifier == figo.QuantifierAll {
+			// {} (match-all) becomes {$nor:[{}]}: no document fails it.
+			cond = bson.M{"$nor": []bson.M{cond}}
+		}
+		sub = append(sub, crc.rel.stages...)
+		if len(cond) > 0 {
+			sub = append(sub, bson.D{{Key: "$match", Value: cond}})
+		}
+	}
+	// One witness decides the predicate, and only its _id is carried back.
+	sub = append(sub,
+		bson.D{{Key: "$limit", Value: int64(1)}},
+		bson.D{{Key: "$project", Value: bson.D{{Key: "_id", Value: 1}}}},
+	)
+
+	alias := fmt.Sprintf("%s%d", mongoRelationAliasPrefix, len(rc.rel.aliases)+1)
+	rc.rel.aliases = append(rc.rel.aliases, alias)
+	rc.rel.stages = append(rc.rel.stages, bson.D{{Key: "$lookup", Value: bson.D{
+		{Key: "from", Value: j.From},
+		{Key: "let", Value: bson.D{{Key: mongoLookupLocalVar, Value: "$" + j.LocalField}}},
+		{Key: "pipeline", Value: sub},
+		{Key: "as", Value: alias},
+	}}})
+	return bson.M{alias + ".0": bson.M{"$exists": x.Quantifier == figo.QuantifierAny}}, nil
 }
 
 // under returns the context for the operands of a NotExpr. Tracking negation
@@ -1000,6 +1116,8 @@ func mongoExpr(e figo.Expr, rc mongoRender) (bson.M, error) {
 			return bson.M{}, nil
 		}
 		return bson.M{"$nor": parts}, nil
+	case figo.RelationExpr:
+		return rc.relation(x)
 	case figo.OrderBy:
 		return bson.M{}, nil
 	default:
@@ -1196,6 +1314,9 @@ func (a MongoAdapter) renderPreload(relation string) mongoRender {
 	return mongoRender{preload: relation, oidFields: a.objectIDFieldSet()}
 }
 
+// Store implements figo.StoreAdapter
+func (MongoAdapter) Store() string { return figo.StoreMongo }
+
 func (MongoAdapter) GetSqlString(f figo.Figo, ctx any, conditionType ...string) (string, bool) {
 	// Mongo adapter doesn't produce SQL strings; return false
 	if f == nil {
@@ -0,0 +1,156 @@
+package adapters
+
+import (
+	figo "github.com/bi0dread/figo/v4"
+
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoCollection is the part of *mongo.Collection FindMongo and
+// AggregateMongo run commands through.
+type MongoCollection interface {
+	Find(ctx context.Context, filter any, opts ...*options.FindOptions) (*mongo.Cursor, error)
+	Aggregate(ctx context.Context, pipeline any, opts ...*options.AggregateOptions) (*mongo.Cursor, error)
+	CountDocuments(ctx context.Context, filter any, opts ...*options.CountOptions) (int64, error)
+}
+
+// MongoPage is one page of decoded documents.
+type MongoPage[T any] struct {
+	// Items is the page, in the instance's sort order.
+	Items []T
+	// Total counts every matching document, before paging.
+	Total int64
+	// Page is the instance's page (Take 0: unlimited).
+	Page figo.Page
+}
+
+// FindMongo runs the built instance against coll and decodes the page into
+// []T. It is AggregateMongo without joins: an instance with a load= preload or
+// a relation predicate needs a $lookup, which needs the joins, and fails.
+func FindMongo[T any](ctx context.Context, coll MongoCollection, f figo.Figo) (MongoPage[T], error) {
+	return AggregateMongo[T](ctx, coll, f, nil)
+}
+
+// AggregateMongo runs the built instance against coll with the command it
+// needs, so callers stop choosing between GetQuery's Find payload and its
+// "AGG" pipeline: a plain Find when the instance has no load= preload and no
+// relation predicate, otherwise BuildMongoAggregatePipeline's pipeline with
+// joins. Documents decode into T with the driver's bson rules (the lookup
+// arrays land in the field whose bson name is MongoJoin.As).
+//
+// Total costs a second command — CountDocuments with the Find filter, or the
+// pipeline without its paging stages plus $count — and is skipped when the
+// page itself shows the total: no paging at all, or a short page that is not
+// past the end.
+func AggregateMongo[T any](ctx context.Context, coll MongoCollection, f figo.Figo, joins map[string]MongoJoin) (MongoPage[T], error) {
+	page := MongoPage[T]{Items: []T{}, Page: f.GetPage()}
+	if !mongoNeedsAggregate(f) {
+		filter, opts, err := AdapterMongoGetFind(f)
+		if err != nil {
+			return MongoPage[T]{}, err
+		}
+		cur, err := coll.Find(ctx, filter, opts)
+		if err != nil {
+			return MongoPage[T]{}, fmt.Errorf("figo: mongo find: %w", err)
+		}
+		if err := cur.All(ctx, &page.Items); err != nil {
+			return MongoPage[T]{}, fmt.Errorf("figo: mongo find: %w", err)
+		}
+		if total, ok := mongoTotalFromPage(page.Page, len(page.Items)); ok {
+			page.Total = total
+			return page, nil
+		}
+		if page.Total, err = coll.CountDocuments(ctx, filter); err != nil {
+			return MongoPage[T]{}, fmt.Errorf("figo: mongo count: %w", err)
+		}
+		return page, nil
+	}
+
+	pipeline, opts, err := AdapterMongoGetAggregate(f, joins)
+	if err != nil {
+		return MongoPage[T]{}, err
+	}
+	cur, err := coll.Aggregate(ctx, pipeline, opts)
+	if err != nil {
+		return MongoPage[T]{}, fmt.Errorf("figo: mongo aggregate: %w", err)
+	}
+	if err := cur.All(ctx, &page.Items); err != nil {
+		return MongoPage[T]{}, fmt.Errorf("figo: mongo aggregate: %w", err)
+	}
+	if total, ok := mongoTotalFromPage(page.Page, len(page.Items)); ok {
+		page.Total = total
+		return page, nil
+	}
+	cur, err = coll.Aggregate(ctx, mongoCountPipeline(pipeline), options.Aggregate())
+	if err != nil {
+		return MongoPage[T]{}, fmt.Errorf("figo: mongo count: %w", err)
+	}
+	var counts []struct {
+		N int64 `bson:"n"`
+	}
+	if err := cur.All(ctx, &counts); err != nil {
+		return MongoPage[T]{}, fmt.Errorf("figo: mongo count: %w", err)
+	}
+	if len(counts) > 0 {
+		page.Total = counts[0].N
+	}
+	return page, nil
+}
+
+// mongoNeedsAggregate reports whether the instance needs the aggregate path: a
+// preload is a $lookup, and so is a relation predicate anywhere in the filter.
+func mongoNeedsAggregate(f figo.Figo) bool {
+	if len(f.GetPreloads()) > 0 {
+		return true
+	}
+	found := false
+	for _, e := range f.GetClauses() {
+		figo.Walk(e, func(n figo.Expr) {
+			if _, ok := n.(*figo.RelationExpr); ok {
+				found = true
+			}
+		})
+	}
+	return found
+}
+
+// mongoTotalFromPage returns the total when n documents fetched for p already
+// determine it.
+func mongoTotalFromPage(p figo.Page, n int) (int64, bool) {
+	skip := int64(max(p.Skip, 0))
+	switch {
+	case p.Take <= 0 && (skip == 0 || n > 0):
+		// Unlimited: everything from the offset on was returned.
+		return skip + int64(n), true
+	case p.Take > 0 && n < p.Take && (skip == 0 || n > 0):
+		// A short page is the last one.
+		return skip + int64(n), true
+	}
+	return 0, false
+}
+
+// mongoCountPipeline turns a built pipeline into one counting its matches.
+// The paging stages are the only top-level $skip/$limit the builder emits; the
+// trailing $sort and $project cannot change the count, so they go too.
+func mongoCountPipeline(pipeline mongo.Pipeline) mongo.Pipeline {
+	out := make(mongo.Pipeline, 0, len(pipeline)+1)
+	for _, stage := range pipeline {
+		if len(stage) == 1 && (stage[0].Key == "$skip" || stage[0].Key == "$limit") {
+			continue
+		}
+		out = append(out, stage)
+	}
+	for len(out) > 0 {
+		last := out[len(out)-1]
+		if len(last) != 1 || last[0].Key != "$sort" && last[0].Key != "$project" {
+			break
+		}
+		out = out[:len(out)-1]
+	}
+	return append(out, bson.D{{Key: "$count", Value: "n"}})
+}
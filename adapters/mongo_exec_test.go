@@ -0,0 +1,129 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	figo "github.com/bi0dread/figo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fakeCollection records the commands it receives and answers each with the
+// next canned batch of documents.
+type fakeCollection struct {
+	batches [][]any
+	count   int64
+	calls   []string
+	filters []any
+	err     error
+}
+
+func (c *fakeCollection) next() (*mongo.Cursor, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	var docs []any
+	if len(c.batches) > 0 {
+		docs, c.batches = c.batches[0], c.batches[1:]
+	}
+	return mongo.NewCursorFromDocuments(docs, nil, nil)
+}
+
+func (c *fakeCollection) Find(ctx context.Context, filter any, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	c.calls = append(c.calls, "find")
+	c.filters = append(c.filters, filter)
+	return c.next()
+}
+
+func (c *fakeCollection) Aggregate(ctx context.Context, pipeline any, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+	c.calls = append(c.calls, "aggregate")
+	c.filters = append(c.filters, pipeline)
+	return c.next()
+}
+
+func (c *fakeCollection) CountDocuments(ctx context.Context, filter any, opts ...*options.CountOptions) (int64, error) {
+	c.calls = append(c.calls, "count")
+	c.filters = append(c.filters, filter)
+	return c.count, nil
+}
+
+type mongoExecUser struct {
+	Name   string           `bson:"name"`
+	Age    int              `bson:"age"`
+	Orders []map[string]any `bson:"Orders"`
+}
+
+func TestFindMongoDecodesAndCounts(t *testing.T) {
+	coll := &fakeCollection{
+		batches: [][]any{{bson.M{"name": "ann", "age": 30}, bson.M{"name": "bo", "age": 41}}},
+		count:   7,
+	}
+	f := figoFor(t, MongoAdapter{}, `age>20 sort=age:asc page=skip:0,take:2`)
+	page, err := FindMongo[mongoExecUser](context.Background(), coll, f)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"find", "count"}, coll.calls)
+	assert.Equal(t, bson.M{"age": bson.M{"$gt": int64(20)}}, coll.filters[0])
+	assert.Equal(t, coll.filters[0], coll.filters[1], "the count uses the Find filter")
+	assert.Equal(t, []mongoExecUser{{Name: "ann", Age: 30}, {Name: "bo", Age: 41}}, page.Items)
+	assert.Equal(t, int64(7), page.Total)
+	assert.Equal(t, figo.Page{Skip: 0, Take: 2}, page.Page)
+
+	// A short page is the last one: no count command.
+	coll = &fakeCollection{batches: [][]any{{bson.M{"name": "cy"}}}}
+	page, err = FindMongo[mongoExecUser](context.Background(), coll, figoFor(t, MongoAdapter{}, `page=skip:4,take:2`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"find"}, coll.calls)
+	assert.Equal(t, int64(5), page.Total)
+
+	// An empty result is an empty slice, not nil.
+	coll = &fakeCollection{}
+	page, err = FindMongo[mongoExecUser](context.Background(), coll, figoFor(t, MongoAdapter{}, `age>99`))
+	require.NoError(t, err)
+	assert.Equal(t, []mongoExecUser{}, page.Items)
+	assert.Equal(t, int64(0), page.Total)
+}
+
+func TestAggregateMongoRunsThePipelineForPreloads(t *testing.T) {
+	joins := map[string]MongoJoin{"Orders": {From: "orders", LocalField: "_id", ForeignField: "user_id"}}
+	coll := &fakeCollection{batches: [][]any{
+		{bson.M{"name": "ann", "Orders": bson.A{bson.M{"total": 120}}}},
+		{bson.M{"n": int64(9)}},
+	}}
+	f := figoFor(t, MongoAdapter{}, `age>20 sort=name:asc page=skip:1,take:1 load=[Orders:total>100]`)
+	page, err := AggregateMongo[mongoExecUser](context.Background(), coll, f, joins)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aggregate", "aggregate"}, coll.calls)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "ann", page.Items[0].Name)
+	assert.Equal(t, int64(9), page.Total)
+
+	want, err := BuildMongoAggregatePipeline(f, joins)
+	require.NoError(t, err)
+	assert.Equal(t, want, coll.filters[0])
+
+	// The count pipeline keeps every filtering stage and drops the paging.
+	count := coll.filters[1].(mongo.Pipeline)
+	assert.Equal(t, bson.D{{Key: "$count", Value: "n"}}, count[len(count)-1])
+	for _, stage := range count {
+		assert.NotContains(t, []string{"$skip", "$limit", "$sort"}, stage[0].Key)
+	}
+	assert.Equal(t, want[:len(want)-3], count[:len(count)-1])
+}
+
+func TestFindMongoFailsClosed(t *testing.T) {
+	// A relation predicate needs the joins.
+	coll := &fakeCollection{}
+	_, err := FindMongo[mongoExecUser](context.Background(), coll, figoFor(t, MongoAdapter{}, `orders<any>[total>1]`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no MongoJoin configured")
+	assert.Empty(t, coll.calls, "nothing runs when the render fails")
+
+	boom := errors.New("boom")
+	_, err = FindMongo[mongoExecUser](context.Background(), &fakeCollection{err: boom}, figoFor(t, MongoAdapter{}, `age=1`))
+	assert.ErrorIs(t, err, boom)
+}
@@ -0,0 +1,60 @@
+package adapters
+
+// Exclusion projections for the MongoDB evaluator (mongo_semantics_eval_test.go).
+// A pipeline with a relation predicate ends by dropping its private $lookup
+// fields with {$project: {__figo_rel1: 0, ...}}, and the evaluator must run
+// that stage to check what the relation tests read.
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// exclusionProject applies entries as an exclusion projection, or returns
+// (nil, nil) when they are not one.
+func (e *mongoEval) exclusionProject(docs []bson.M, entries []bson.E) ([]bson.M, error) {
+	zeros := 0
+	for _, en := range entries {
+		if n, ok := numeric(en.Value); ok && n == 0 {
+			if strings.Contains(en.Key, ".") {
+				return nil, e.unsupported("$project exclusion of a dotted path", en.Key)
+			}
+			zeros++
+		}
+	}
+	if zeros == 0 {
+		return nil, nil
+	}
+	if zeros != len(entries) {
+		return nil, fmt.Errorf("Invalid $project :: caused by :: Cannot do exclusion on field in inclusion projection (error 31254)")
+	}
+	out := make([]bson.M, 0, len(docs))
+	for _, d := range docs {
+		nd := cloneDoc(d)
+		for _, en := range entries {
+			delete(nd, en.Key)
+		}
+		out = append(out, nd)
+	}
+	return out, nil
+}
+
+// https://www.mongodb.com/docs/manual/reference/operator/aggregation/project/:
+// "If you specify the exclusion of a field other than _id, you cannot employ
+// any other $project specification forms."
+func TestMongoEvaluator_ExclusionProject(t *testing.T) {
+	e := &mongoEval{}
+	docs := []bson.M{{"_id": 1, "a": 1, "b": 2}}
+	out, err := e.stageProject(docs, bson.D{{Key: "b", Value: 0}})
+	require.NoError(t, err)
+	assert.Equal(t, []bson.M{{"_id": 1, "a": 1}}, out)
+	assert.Equal(t, bson.M{"_id": 1, "a": 1, "b": 2}, docs[0], "the input is not modified")
+
+	_, err = e.stageProject(docs, bson.D{{Key: "a", Value: 1}, {Key: "b", Value: 0}})
+	assert.ErrorContains(t, err, "error 31254")
+}
@@ -914,12 +914,17 @@ func (e *mongoEval) stageSkipLimit(docs []bson.M, spec any, skip bool) ([]bson.M
 // stageProject models an INCLUSION projection, including MongoDB's path
 // collision rule: a specification that names both a path and a prefix of that
 // path is rejected outright ("Invalid $project :: caused by :: Path collision",
-// errors 31249/31250, legacy 40176) and the WHOLE aggregate fails.
+// errors 31249/31250, legacy 40176) and the WHOLE aggregate fails. An
+// exclusion projection is handed to exclusionProject
+// (mongo_relation_eval_test.go).
 func (e *mongoEval) stageProject(docs []bson.M, spec any) ([]bson.M, error) {
 	entries, ok := docEntries(spec)
 	if !ok {
 		return nil, e.unsupported("$project spec", spec)
 	}
+	if excl, err := e.exclusionProject(docs, entries); excl != nil || err != nil {
+		return excl, err
+	}
 	paths := make([]string, 0, len(entries))
 	for _, en := range entries {
 		if n, ok := numeric(en.Value); !ok || n == 0 {
@@ -39,15 +39,15 @@ type RawPreload struct {
 // any ORM dependency. An expression the raw adapter cannot render returns an
 // error (and a nil map) instead of silently dropping the condition.
 func BuildRawPreloads(f figo.Figo) (map[string]RawPreload, error) {
-	d := rawDialectOf(f)
+	d := rawRendererOf(f)
 	result := make(map[string]RawPreload)
 	for rel, exprs := range f.GetPreloads() {
 		where, args, err := buildWhereFromExprs(d, exprs)
 		if err != nil {
 			return nil, err
 		}
-		if d.NumberedPlaceholders {
-			where = numberPlaceholders(d, where)
+		if d.numbered() {
+			where = numberPlaceholders(d.SQLDialect, where)
 		}
 		result[rel] = RawPreload{Where: where, Args: args}
 	}
@@ -58,13 +58,13 @@ func BuildRawPreloads(f figo.Figo) (map[string]RawPreload, error) {
 // and its args. An expression the raw adapter cannot render returns an error
 // instead of silently dropping the condition (which would widen the result).
 func BuildRawWhere(f figo.Figo) (string, []any, error) {
-	d := rawDialectOf(f)
+	d := rawRendererOf(f)
 	where, args, err := buildWhereFromExprs(d, clausesForRender(f))
 	if err != nil {
 		return "", nil, err
 	}
-	if d.NumberedPlaceholders {
-		where = numberPlaceholders(d, where)
+	if d.numbered() {
+		where = numberPlaceholders(d.SQLDialect, where)
 	}
 	return where, args, nil
 }
@@ -74,13 +74,13 @@ func BuildRawWhere(f figo.Figo) (string, []any, error) {
 // adapter dialect (MySQL backticks and '?' by default). An expression the raw
 // adapter cannot render returns an error instead of silently dropping it.
 func BuildRawSelect(f figo.Figo, table string, columns ...string) (string, []any, error) {
-	d := rawDialectOf(f)
+	d := rawRendererOf(f)
 	sql, args, err := buildFullSelect(d, f, table, columns...)
 	if err != nil {
 		return "", nil, err
 	}
-	if d.NumberedPlaceholders {
-		sql = numberPlaceholders(d, sql)
+	if d.numbered() {
+		sql = numberPlaceholders(d.SQLDialect, sql)
 	}
 	return sql, args, nil
 }
@@ -105,7 +105,7 @@ func clausesForRender(f figo.Figo) []figo.Expr {
 	return f.GetClauses()
 }
 
-func buildWhereFromExprs(d *SQLDialect, exprs []figo.Expr) (string, []any, error) {
+func buildWhereFromExprs(d sqlRenderer, exprs []figo.Expr) (string, []any, error) {
 	if len(exprs) == 0 {
 		return "", nil, nil
 	}
@@ -155,7 +155,13 @@ func docExprSupport(e figo.Expr) string {
 	}
 }
 
-func exprToSQL(d *SQLDialect, e figo.Expr) (string, []any, error) {
+// errDialectUnsupported is errUnsupportedExpr for the advanced types only some
+// dialects render (see the SQLDialect capability fields).
+func errDialectUnsupported(d *SQLDialect, e figo.Expr) error {
+	return fmt.Errorf("raw adapter: unsupported expression type %T on the %s dialect (rendered by the MongoDB/Elasticsearch adapters and the ClickHouse dialect)", e, d.Name)
+}
+
+func exprToSQL(d sqlRenderer, e figo.Expr) (string, []any, error) {
 	// Reject a field name that quoting cannot make executable (NUL/control
 	// bytes, empty dot segments) before it reaches quoteIdent — see
 	// validateIdent. CustomExpr is exempt: its field is handed to the handler
@@ -194,10 +200,41 @@ func exprToSQL(d *SQLDialect, e figo.Expr) (string, []any, error) {
 	case figo.LikeExpr:
 		return fmt.Sprintf("%s LIKE ?", d.quoteIdent(x.Field)), []any{x.Value}, nil
 	case figo.RegexExpr:
-		// The operator comes from the dialect: REGEXP (MySQL/SQLite), ~ (Postgres).
+		// The operator comes from the dialect: REGEXP (MySQL/SQLite), ~ (Postgres),
+		// or a function call such as ClickHouse's match(col, pattern).
+		if d.RegexFunction != "" {
+			return fmt.Sprintf("%s(%s, ?)", d.RegexFunction, d.quoteIdent(x.Field)), []any{x.Value}, nil
+		}
+		if d.RegexOperator == "" {
+			return "", nil, fmt.Errorf("raw adapter: the %s dialect has no regex operator; cannot render a regex match on %q", d.Name, x.Field)
+		}
 		return fmt.Sprintf("%s %s ?", d.quoteIdent(x.Field), d.RegexOperator), []any{x.Value}, nil
 	case figo.ILikeExpr:
+		if d.ILikeOperator != "" {
+			return fmt.Sprintf("%s %s ?", d.quoteIdent(x.Field), d.ILikeOperator), []any{x.Value}, nil
+		}
 		return fmt.Sprintf("LOWER(%s) LIKE LOWER(?)", d.quoteIdent(x.Field)), []any{x.Value}, nil
+	case figo.ArrayContainsExpr:
+		if d.ArrayContainsFunction == "" {
+			return "", nil, errDialectUnsupported(d.SQLDialect, e)
+		}
+		return arrayContainsToSQL(d, x)
+	case figo.ArrayOverlapsExpr:
+		if d.ArrayOverlapsFunction == "" {
+			return "", nil, errDialectUnsupported(d.SQLDialect, e)
+		}
+		if len(x.Values) == 0 {
+			// No element can be shared with an empty set.
+			return "1=0", nil, nil
+		}
+		placeholders := strings.Repeat("?, ", len(x.Values))
+		placeholders = placeholders[:len(placeholders)-2]
+		return fmt.Sprintf("%s(%s, [%s])", d.ArrayOverlapsFunction, d.quoteIdent(x.Field), placeholders), append([]any{}, x.Values...), nil
+	case figo.JsonPathExpr:
+		if !d.JSONExtractFunctions {
+			return "", nil, errDialectUnsupported(d.SQLDialect, e)
+		}
+		return jsonPathToSQL(d, x)
 	case figo.IsNullExpr:
 		return fmt.Sprintf("%s IS NULL", d.quoteIdent(x.Field)), nil, nil
 	case figo.NotNullExpr:
@@ -276,7 +313,7 @@ func exprToSQL(d *SQLDialect, e figo.Expr) (string, []any, error) {
 			// discarded by the callers' p != "" test).
 			return "", nil, nil
 		}
-		frag, args = expandSliceArgs(d, frag, args)
+		frag, args = expandSliceArgs(d.SQLDialect, frag, args)
 		// Parenthesize the fragment. It is spliced into an " AND " join, and
 		// SQL's AND binds tighter than OR, so a compound fragment silently
 		// re-associated: scope AND (a OR b) rendered as a OR (b AND scope),
@@ -284,6 +321,8 @@ func exprToSQL(d *SQLDialect, e figo.Expr) (string, []any, error) {
 		// case here is self-delimiting; the GORM adapter, documented as sharing
 		// this handler contract, already nests the fragment correctly.
 		return "(" + frag + ")", args, nil
+	case figo.RelationExpr:
+		return relationToSQL(d, x)
 	case figo.OrderBy:
 		// Rendered by buildOrderBy, which reads the clause list as well as
 		// GetSort — not dropped (see buildOrderBy).
@@ -293,6 +332,182 @@ func exprToSQL(d *SQLDialect, e figo.Expr) (string, []any, error) {
 	}
 }
 
+// relationToSQL renders a quantified relation predicate as a correlated
+// subquery (see sqlQuantify). The parent side of the join is qualified with
+// the table (or enclosing alias) being filtered: left bare, a LocalKey such as
+// "id" resolves to the SUBQUERY's own id column first — SQL scoping looks
+// innermost-out — and the predicate silently compares the child with itself.
+// A WHERE-only render has no table to qualify with, so it needs a LocalKey
+// that is already qualified ("users.id").
+//
+// The condition renders with this subquery's alias as the correlation target,
+// so a nested orders<any>[items<any>[...]] joins items to the order rather
+// than to the outer row.
+func relationToSQL(d sqlRenderer, x figo.RelationExpr) (string, []any, error) {
+	rel, err := lookupSQLRelation("raw adapter", d.relations, x)
+	if err != nil {
+		return "", nil, err
+	}
+	local := rel.localKey()
+	for _, id := range [...][2]string{{"relation table", rel.Table}, {"relation foreign key", rel.ForeignKey}, {"relation local key", local}} {
+		if err := validateIdent(id[0], id[1]); err != nil {
+			return "", nil, err
+		}
+	}
+	if !strings.Contains(local, ".") {
+		if d.outer == "" {
+			return "", nil, fmt.Errorf("raw adapter: relation %q cannot be correlated with its parent row: no table is being rendered; qualify SQLRelation.LocalKey with the parent table (e.g. %q) or render a full SELECT", x.Relation, "users."+local)
+		}
+		local = d.outer + "." + local
+	}
+
+	alias := sqlRelationAlias(d.depth + 1)
+	inner := d
+	inner.outer = alias
+	inner.depth++
+	var cond string
+	var args []any
+	if x.Cond != nil {
+		if cond, args, err = exprToSQL(inner, x.Cond); err != nil {
+			return "", nil, err
+		}
+	}
+	as := " AS "
+	if d.OmitAliasAS {
+		as = " "
+	}
+	sub := fmt.Sprintf("SELECT 1 FROM %s%s%s WHERE %s = %s",
+		d.quoteIdent(rel.Table), as, d.quoteIdent(alias), d.quoteIdent(alias+"."+rel.ForeignKey), d.quoteIdent(local))
+	return sqlQuantify(x.Quantifier, sub, cond), args, nil
+}
+
+// arrayContainsToSQL renders contains-ALL as one membership test per required
+// element: has(col, ?) AND has(col, ?). Requiring no element is vacuously
+// true, the same identity the empty NOT IN renders.
+func arrayContainsToSQL(d sqlRenderer, x figo.ArrayContainsExpr) (string, []any, error) {
+	if len(x.Values) == 0 {
+		return "1=1", nil, nil
+	}
+	col := d.quoteIdent(x.Field)
+	parts := make([]string, len(x.Values))
+	for i := range x.Values {
+		parts[i] = fmt.Sprintf("%s(%s, ?)", d.ArrayContainsFunction, col)
+	}
+	if len(parts) == 1 {
+		return parts[0], append([]any{}, x.Values...), nil
+	}
+	return "(" + strings.Join(parts, " AND ") + ")", append([]any{}, x.Values...), nil
+}
+
+// jsonPathToSQL renders a JSON path predicate with ClickHouse's JSONExtract
+// family. The path ($.user.tags[0]) becomes the function's key arguments, bound
+// like any other value: object keys as strings, array subscripts as 1-based
+// indexes. The extraction function follows the compared value's Go type,
+// since ClickHouse extracts into a fixed type rather than comparing JSON
+// values:
+//
+//	$.user.name = "x"      JSONExtractString(`data`, ?, ?) = ?
+//	$.tags contains "x"    has(JSONExtract(`data`, ?, 'Array(String)'), ?)
+//	$.user exists          JSONHas(`data`, ?)
+//
+// A NULL comparison value has no typed extraction (and = NULL is never true),
+// so it fails the render; "exists" is the way to test for a key.
+func jsonPathToSQL(d sqlRenderer, x figo.JsonPathExpr) (string, []any, error) {
+	keys, err := jsonPathKeys(x.Path)
+	if err != nil {
+		return "", nil, fmt.Errorf("raw adapter: JSON path %q on %q: %w", x.Path, x.Field, err)
+	}
+	col := d.quoteIdent(x.Field)
+	keyPH := strings.Repeat(", ?", len(keys))
+	if x.Op == "exists" {
+		return fmt.Sprintf("JSONHas(%s%s)", col, keyPH), keys, nil
+	}
+	if x.Value == nil {
+		return "", nil, fmt.Errorf("raw adapter: JSON path %q on %q compares with NULL; use the exists op", x.Path, x.Field)
+	}
+	suffix, chType, err := clickHouseJSONType(x.Value)
+	if err != nil {
+		return "", nil, fmt.Errorf("raw adapter: JSON path %q on %q: %w", x.Path, x.Field, err)
+	}
+	args := append(keys, x.Value)
+	switch x.Op {
+	case "", "=", "==":
+		return fmt.Sprintf("JSONExtract%s(%s%s) = ?", suffix, col, keyPH), args, nil
+	case "!=", ">", ">=", "<", "<=":
+		return fmt.Sprintf("JSONExtract%s(%s%s) %s ?", suffix, col, keyPH, x.Op), args, nil
+	case "contains":
+		return fmt.Sprintf("has(JSONExtract(%s%s, 'Array(%s)'), ?)", col, keyPH, chType), args, nil
+	default:
+		return "", nil, fmt.Errorf("raw adapter: unsupported JSON path op %q on %q", x.Op, x.Field)
+	}
+}
+
+// jsonPathKeys splits a JSON path ($.a.b[2], .a.b or a.b) into JSONExtract
+// key arguments.
+func jsonPathKeys(path string) ([]any, error) {
+	p := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if p == "" {
+		return nil, fmt.Errorf("the path names no key")
+	}
+	var keys []any
+	for _, seg := range strings.Split(p, ".") {
+		name := seg
+		var subs []string
+		if i := strings.IndexByte(seg, '['); i >= 0 {
+			name = seg[:i]
+			rest := seg[i:]
+			for rest != "" {
+				end := strings.IndexByte(rest, ']')
+				if rest[0] != '[' || end < 0 {
+					return nil, fmt.Errorf("malformed subscript in %q", seg)
+				}
+				subs = append(subs, rest[1:end])
+				rest = rest[end+1:]
+			}
+		}
+		if name == "" && len(subs) == 0 {
+			return nil, fmt.Errorf("empty key")
+		}
+		if name != "" {
+			keys = append(keys, name)
+		}
+		for _, s := range subs {
+			n := 0
+			if s == "" || len(s) > 9 {
+				return nil, fmt.Errorf("array subscript %q is not an index", s)
+			}
+			for _, c := range s {
+				if c < '0' || c > '9' {
+					return nil, fmt.Errorf("array subscript %q is not an index", s)
+				}
+				n = n*10 + int(c-'0')
+			}
+			// JSONExtract counts array elements from 1.
+			keys = append(keys, int64(n+1))
+		}
+	}
+	return keys, nil
+}
+
+// clickHouseJSONType picks the JSONExtract function suffix and the matching
+// ClickHouse type name for a comparison value.
+func clickHouseJSONType(v any) (suffix, chType string, err error) {
+	switch v.(type) {
+	case string:
+		return "String", "String", nil
+	case bool:
+		return "Bool", "Bool", nil
+	case int, int8, int16, int32, int64:
+		return "Int", "Int64", nil
+	case uint, uint8, uint16, uint32, uint64:
+		return "UInt", "UInt64", nil
+	case float32, float64:
+		return "Float", "Float64", nil
+	default:
+		return "", "", fmt.Errorf("no JSONExtract function for a %T value", v)
+	}
+}
+
 // hasNonNilOperand reports whether the operand list has at least one real
 // entry — NOT() with no operands is the vacuous-true identity, but NOT over
 // operands that merely RENDER empty must fail closed instead.
@@ -311,7 +526,7 @@ func hasNonNilOperand(ops []figo.Expr) bool {
 // collapse (alternating and/or, or a not-chain) cost O(output x depth): a
 // 152 KB filter burned 1.5 s and 5.7 GB of allocation to produce 216 KB of SQL,
 // while the other three adapters stayed linear on the same AST.
-func joinGroup(d *SQLDialect, op string, operands []figo.Expr) (string, []any, error) {
+func joinGroup(d sqlRenderer, op string, operands []figo.Expr) (string, []any, error) {
 	var w sqlWriter
 	wrote, err := w.writeGroup(d, op, operands)
 	if err != nil {
@@ -334,7 +549,7 @@ type sqlWriter struct {
 
 // writeGroup joins the operands with op, parenthesizing only when more than one
 // of them renders — byte-for-byte the output the string joiner produced.
-func (w *sqlWriter) writeGroup(d *SQLDialect, op string, operands []figo.Expr) (bool, error) {
+func (w *sqlWriter) writeGroup(d sqlRenderer, op string, operands []figo.Expr) (bool, error) {
 	nonNil := 0
 	for _, e := range operands {
 		if e != nil {
@@ -392,7 +607,7 @@ func (w *sqlWriter) writeGroup(d *SQLDialect, op string, operands []figo.Expr) (
 
 // writeExpr renders one expression. The logical nodes recurse in place; every
 // other type is a leaf whose rendering is short, so it goes through exprToSQL.
-func (w *sqlWriter) writeExpr(d *SQLDialect, e figo.Expr) (bool, error) {
+func (w *sqlWriter) writeExpr(d sqlRenderer, e figo.Expr) (bool, error) {
 	switch x := e.(type) {
 	case figo.AndExpr:
 		return w.writeGroup(d, "AND", x.Operands)
@@ -455,6 +670,9 @@ func buildOrderBy(d *SQLDialect, f figo.Figo, clauses []figo.Expr) (string, erro
 			if c.Name == "" {
 				continue
 			}
+			if c.Name == figo.ScoreSortField {
+				return fmt.Errorf("raw adapter: cannot sort by %s: only Elasticsearch has a relevance score", figo.ScoreSortField)
+			}
 			if err := validateIdent("sort key", c.Name); err != nil {
 				return err
 			}
@@ -484,13 +702,18 @@ func buildOrderBy(d *SQLDialect, f figo.Figo, clauses []figo.Expr) (string, erro
 	return "ORDER BY " + strings.Join(cols, ", "), nil
 }
 
-func buildLimitOffset(d *SQLDialect, f figo.Figo) string {
+// buildLimitOffset renders the paging clause; ordered reports whether the
+// statement carries an ORDER BY (see SQLDialect.Paginate).
+func buildLimitOffset(d *SQLDialect, f figo.Figo, ordered bool) string {
 	p := f.GetPage()
 	// Embed numbers directly for broad driver compatibility
 	// If Take is 0, skip LIMIT clause
 	if p.Take <= 0 && p.Skip <= 0 {
 		return ""
 	}
+	if d.Paginate != nil {
+		return d.Paginate(max(p.Take, 0), max(p.Skip, 0), ordered)
+	}
 	if p.Take > 0 && p.Skip > 0 {
 		return fmt.Sprintf("LIMIT %d OFFSET %d", p.Take, p.Skip)
 	}
@@ -515,16 +738,16 @@ type RawContext struct {
 // neither a table name string nor a RawContext, and on any expression the raw
 // adapter cannot render (nothing is silently dropped).
 func AdapterRawGetSql(f figo.Figo, ctx any, conditionType ...string) (string, []any, error) {
-	return rawGetSQL(rawDialectOf(f), f, ctx, conditionType...)
+	return rawGetSQL(rawRendererOf(f), f, ctx, conditionType...)
 }
 
 // rawGetSQL is AdapterRawGetSql with the dialect supplied by the caller. The
 // RawAdapter methods pass their own receiver's dialect: an instance built with
 // nil, with another adapter, or with a *RawAdapter holds no value RawAdapter
-// for rawDialectOf to read, and taking quoting from the instance while taking
+// for rawRendererOf to read, and taking quoting from the instance while taking
 // placeholder numbering from the receiver produced MySQL backticks with $n
 // binds — valid on no engine.
-func rawGetSQL(d *SQLDialect, f figo.Figo, ctx any, conditionType ...string) (string, []any, error) {
+func rawGetSQL(d sqlRenderer, f figo.Figo, ctx any, conditionType ...string) (string, []any, error) {
 	switch v := ctx.(type) {
 	case string:
 		return buildByConditions(d, f, v, conditionType...)
@@ -540,8 +763,13 @@ func rawGetSQL(d *SQLDialect, f figo.Figo, ctx any, conditionType ...string) (st
 // PostgresDialect / SQLiteDialect (or a custom *SQLDialect) to change the
 // rendering. Select the dialect BEFORE rendering, e.g. Build(RawAdapter{
 // Dialect: figo.PostgresDialect}).
+//
+// Relations describes the relations quantified predicates may name
+// (orders<any>[...]); see SQLRelation. A predicate naming a relation missing
+// from it fails the render.
 type RawAdapter struct {
-	Dialect *SQLDialect
+	Dialect   *SQLDialect
+	Relations map[string]SQLRelation
 }
 
 // dialect returns the configured dialect, defaulting to MySQL.
@@ -552,6 +780,31 @@ func (a RawAdapter) dialect() *SQLDialect {
 	return a.Dialect
 }
 
+// renderer returns the state a render of this adapter starts from.
+func (a RawAdapter) renderer() sqlRenderer {
+	return sqlRenderer{SQLDialect: a.dialect(), relations: a.Relations}
+}
+
+// sqlRenderer is the state of one raw render: the dialect, which is shared
+// configuration and never written, and what quantified relation predicates
+// need (see relationToSQL).
+type sqlRenderer struct {
+	*SQLDialect
+	relations map[string]SQLRelation // RawAdapter.Relations
+	outer     string                 // table or alias the next relation correlates with
+	depth     int                    // enclosing relation predicates
+}
+
+// correlate returns d with its relation predicates correlated with table,
+// the statement's FROM table.
+func (d sqlRenderer) correlate(table string) sqlRenderer {
+	d.outer = table
+	return d
+}
+
+// Store implements figo.StoreAdapter
+func (RawAdapter) Store() string { return figo.StoreSQL }
+
 // GetSqlString renders the requested segments with literals interpolated.
 //
 // ok=false MEANS THE RENDER FAILED AND THE STRING IS NOT A STATEMENT. The
@@ -568,7 +821,7 @@ func (a RawAdapter) GetSqlString(f figo.Figo, ctx any, conditionType ...string)
 	}
 	// A build error fails the render (ok=false) — fail closed rather than
 	// returning SQL that silently omits a predicate.
-	sql, args, err := rawGetSQL(a.dialect(), f, ctx, conditionType...)
+	sql, args, err := rawGetSQL(a.renderer(), f, ctx, conditionType...)
 	if err != nil {
 		return "", false
 	}
@@ -583,17 +836,17 @@ func (a RawAdapter) GetQuery(f figo.Figo, ctx any, conditionType ...string) (fig
 	if f == nil {
 		return nil, false
 	}
-	sql, args, err := rawGetSQL(a.dialect(), f, ctx, conditionType...)
+	sql, args, err := rawGetSQL(a.renderer(), f, ctx, conditionType...)
 	if err != nil {
 		return nil, false
 	}
-	if a.dialect().NumberedPlaceholders {
+	if a.dialect().numbered() {
 		sql = numberPlaceholders(a.dialect(), sql)
 	}
 	return figo.SQLQuery{SQL: sql, Args: args}, true
 }
 
-func buildByConditions(d *SQLDialect, f figo.Figo, table string, conditionType ...string) (string, []any, error) {
+func buildByConditions(d sqlRenderer, f figo.Figo, table string, conditionType ...string) (string, []any, error) {
 	// No conditionType: the whole SELECT. Answered BEFORE the per-segment
 	// builders below, which buildFullSelect renders itself — computing them
 	// here first and then discarding them did every join/where/order render
@@ -637,7 +890,7 @@ func buildByConditions(d *SQLDialect, f figo.Figo, table string, conditionType .
 	cols := "*"
 	if needCols {
 		var err error
-		if cols, err = columnsOnly(f, d); err != nil {
+		if cols, err = columnsOnly(f, d.SQLDialect); err != nil {
 			return "", nil, err
 		}
 	}
@@ -658,20 +911,20 @@ func buildByConditions(d *SQLDialect, f figo.Figo, table string, conditionType .
 	)
 	if needWhere {
 		var err error
-		if where, whereArgs, err = buildWhereFromExprs(d, clauses); err != nil {
+		if where, whereArgs, err = buildWhereFromExprs(d.correlate(table), clauses); err != nil {
 			return "", nil, err
 		}
 	}
 	var orderBy string
 	if needOrder {
 		var err error
-		if orderBy, err = buildOrderBy(d, f, clauses); err != nil {
+		if orderBy, err = buildOrderBy(d.SQLDialect, f, clauses); err != nil {
 			return "", nil, err
 		}
 	}
 	var limitOffset string
 	if needLimit {
-		limitOffset = buildLimitOffset(d, f)
+		limitOffset = buildLimitOffset(d.SQLDialect, f, needOrder && orderBy != "")
 	}
 
 	// Build only requested parts, in the order provided
@@ -683,6 +936,12 @@ func buildByConditions(d *SQLDialect, f figo.Figo, table string, conditionType .
 	offsetAdded := false
 	for _, ct := range conditionType {
 		norm := normalizeConditionType(ct)
+		if (norm == "LIMIT" || norm == "OFFSET") && d.Paginate != nil && limitOffset != "" {
+			// A rendered paging clause (OFFSET ... FETCH ...) is one unit, and
+			// the split below only knows LIMIT/OFFSET: emitting nothing for
+			// half of it would drop the page silently.
+			return "", nil, fmt.Errorf("raw adapter: the %s dialect renders paging as one clause; request PAGE instead of %s", d.Name, norm)
+		}
 		switch norm {
 		case "SELECT":
 			parts = append(parts, fmt.Sprintf("SELECT %s", cols))
@@ -760,8 +1019,8 @@ func buildByConditions(d *SQLDialect, f figo.Figo, table string, conditionType .
 // the join key — it now leaves the main statement alone, and the preload
 // filters stay available as rendered fragments via BuildRawPreloads for callers
 // running their own join/second query.
-func buildFullSelect(d *SQLDialect, f figo.Figo, table string, columns ...string) (string, []any, error) {
-	cols, err := columnsOnly(f, d)
+func buildFullSelect(d sqlRenderer, f figo.Figo, table string, columns ...string) (string, []any, error) {
+	cols, err := columnsOnly(f, d.SQLDialect)
 	if err != nil {
 		return "", nil, err
 	}
@@ -778,17 +1037,18 @@ func buildFullSelect(d *SQLDialect, f figo.Figo, table string, columns ...string
 	if err := validateIdent("table", table); err != nil {
 		return "", nil, err
 	}
+	d = d.correlate(table)
 
 	clauses := clausesForRender(f)
 	where, whereArgs, err := buildWhereFromExprs(d, clauses)
 	if err != nil {
 		return "", nil, err
 	}
-	orderBy, err := buildOrderBy(d, f, clauses)
+	orderBy, err := buildOrderBy(d.SQLDialect, f, clauses)
 	if err != nil {
 		return "", nil, err
 	}
-	limitOffset := buildLimitOffset(d, f)
+	limitOffset := buildLimitOffset(d.SQLDialect, f, orderBy != "")
 
 	query := fmt.Sprintf("SELECT %s FROM %s", cols, d.quoteIdent(table))
 	if where != "" {
@@ -904,6 +1164,12 @@ func expandSliceArgs(d *SQLDialect, frag string, args []any) (string, []any) {
 				prev = ch
 			}
 			continue
+		case ch == '[' && !inSingle && !inDouble && !inBacktick && d.QuoteRune == '[':
+			end, _ := d.bracketIdentEnd(frag, i)
+			b.WriteString(frag[i : end+1])
+			i = end
+			prev = ']'
+			continue
 		case ch == '\'' && !inDouble && !inBacktick:
 			inSingle = !inSingle
 		case ch == '"' && !inSingle && !inBacktick:
@@ -997,6 +1263,13 @@ func expandPlaceholders(d *SQLDialect, sql string, args []any) string {
 			b.WriteByte(sql[i])
 			continue
 		}
+		if !inSingle && !inDouble && !inBacktick {
+			if end, ok := d.bracketIdentEnd(sql, i); ok {
+				b.WriteString(sql[i : end+1])
+				i = end
+				continue
+			}
+		}
 		if ch == '\'' && !inDouble && !inBacktick {
 			inSingle = !inSingle
 			b.WriteByte(ch)
@@ -1039,7 +1312,12 @@ func toSQLLiteral(d *SQLDialect, v any) string {
 	case float64:
 		return floatLiteral(x)
 	case bool:
-		if x {
+		switch {
+		case d.NoBooleanLiterals && x:
+			return "1"
+		case d.NoBooleanLiterals:
+			return "0"
+		case x:
 			return "TRUE"
 		}
 		return "FALSE"
@@ -301,7 +301,7 @@ func TestH8_R4_ExpandSliceArgsHonoursBackslashEscape(t *testing.T) {
 			f := figo.New()
 			f.AddFilter(figo.CustomExpr{Field: "id", Operator: "custom", Handler: handler(c.frag)})
 			f.Build(RawAdapter{Dialect: c.dialect})
-			gotSQL, gotArgs, err := buildWhereFromExprs(c.dialect, f.GetClauses())
+			gotSQL, gotArgs, err := buildWhereFromExprs(sqlRenderer{SQLDialect: c.dialect}, f.GetClauses())
 			if err != nil {
 				t.Fatalf("render: %v", err)
 			}
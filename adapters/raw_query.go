@@ -0,0 +1,232 @@
+package adapters
+
+import (
+	figo "github.com/bi0dread/figo/v4"
+
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RawQuerier runs a statement with bind args. *sql.DB, *sql.Tx and *sql.Conn
+// satisfy it, as do sqlx's DB and Tx.
+type RawQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// QueryRaw runs the built instance's SELECT against table and scans every row
+// into a T, the scan loop each caller of BuildRawSelect otherwise writes. The
+// statement is BuildRawSelect's: the instance's raw dialect, filter, sort and
+// page. T must be a struct. Each exported field reads the column named by its
+// `db` tag, else its gorm `column:` tag, else the instance's NamingFunc applied
+// to the Go name — the name the raw adapter renders for that field in a
+// filter, so `UserName string` reads user_name under the default snake_case
+// naming. A field tagged `db:"-"` or `gorm:"-"` is skipped; embedded structs
+// are flattened.
+//
+// Without select fields the statement selects exactly T's columns. With them,
+// every column the statement returns must have a field in T (matched exactly,
+// then ignoring case, for engines that fold unquoted names) — a result
+// column with nowhere to go is an error, never silently dropped.
+//
+// ctx bounds the whole call: it is passed to the driver, and a cancellation
+// mid-scan surfaces as ctx's error.
+func QueryRaw[T any](ctx context.Context, db RawQuerier, f figo.Figo, table string) ([]T, error) {
+	fields, err := rawScanFields(reflect.TypeFor[T](), f.GetNamingFunc())
+	if err != nil {
+		return nil, err
+	}
+	stmt, args, err := BuildRawSelect(f, table, fields.columns...)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("raw adapter: %s: %w", stmt, err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	index := make([][]int, len(cols))
+	for i, c := range cols {
+		if index[i] = fields.lookup(c); index[i] == nil {
+			return nil, fmt.Errorf("raw adapter: result column %q has no field in %s", c, reflect.TypeFor[T]())
+		}
+	}
+
+	out := []T{}
+	dest := make([]any, len(cols))
+	for rows.Next() {
+		var item T
+		rv := reflect.ValueOf(&item).Elem()
+		for i, idx := range index {
+			dest[i] = rawFieldByIndex(rv, idx).Addr().Interface()
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("raw adapter: scanning %s: %w", reflect.TypeFor[T](), err)
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// A driver that notices the cancellation only between rows ends the loop
+	// without an error; the caller must not mistake that for the last row.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// QueryRawWithTotal is QueryRaw plus the number of rows matching the filter
+// before paging, counted by a second statement (SELECT COUNT(*) with the same
+// WHERE). The two statements are not run in one snapshot; pass a *sql.Tx with
+// the isolation level you need when the count must agree with the page.
+func QueryRawWithTotal[T any](ctx context.Context, db RawQuerier, f figo.Figo, table string) ([]T, int64, error) {
+	items, err := QueryRaw[T](ctx, db, f, table)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := countRaw(ctx, db, f, table)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// countRaw counts the rows matching the instance's filter in table.
+func countRaw(ctx context.Context, db RawQuerier, f figo.Figo, table string) (int64, error) {
+	if err := validateIdent("table", table); err != nil {
+		return 0, err
+	}
+	d := rawRendererOf(f)
+	// Correlated like buildFullSelect, so a relation predicate joins to table.
+	where, args, err := buildWhereFromExprs(d.correlate(table), clausesForRender(f))
+	if err != nil {
+		return 0, err
+	}
+	stmt := "SELECT COUNT(*) FROM " + d.quoteIdent(table)
+	if where != "" {
+		stmt += " WHERE " + where
+	}
+	if d.numbered() {
+		stmt = numberPlaceholders(d.SQLDialect, stmt)
+	}
+	rows, err := db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, fmt.Errorf("raw adapter: %s: %w", stmt, err)
+	}
+	defer rows.Close()
+	var total int64
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, fmt.Errorf("raw adapter: %s returned no row", stmt)
+	}
+	if err := rows.Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, rows.Err()
+}
+
+// rawScanTarget maps result columns onto the fields of a struct type.
+type rawScanTarget struct {
+	columns []string         // one per field, in declaration order
+	index   map[string][]int // column -> field index
+	folded  map[string][]int // lower-cased column -> field index
+}
+
+func (t rawScanTarget) lookup(column string) []int {
+	if idx, ok := t.index[column]; ok {
+		return idx
+	}
+	return t.folded[strings.ToLower(column)]
+}
+
+// rawScanFields resolves the column of every scannable field of t. A column
+// claimed by a shallower field wins, as Go promotes fields.
+func rawScanFields(t reflect.Type, naming figo.NamingFunc) (rawScanTarget, error) {
+	if t.Kind() != reflect.Struct {
+		return rawScanTarget{}, fmt.Errorf("raw adapter: QueryRaw scans into a struct, not %s", t)
+	}
+	target := rawScanTarget{index: map[string][]int{}, folded: map[string][]int{}}
+	depth := map[string]int{}
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || sf.Anonymous && indirectStruct(sf.Type) {
+			continue
+		}
+		col, ok := rawColumnOf(sf, naming)
+		if !ok {
+			continue
+		}
+		if d, taken := depth[col]; taken && d <= len(sf.Index) {
+			continue
+		}
+		if _, taken := depth[col]; !taken {
+			target.columns = append(target.columns, col)
+		}
+		depth[col] = len(sf.Index)
+		target.index[col] = sf.Index
+	}
+	if len(target.columns) == 0 {
+		return rawScanTarget{}, fmt.Errorf("raw adapter: %s has no exported field to scan into", t)
+	}
+	// Two columns differing only in case leave the folded name ambiguous, so
+	// it matches neither.
+	for _, col := range target.columns {
+		lower := strings.ToLower(col)
+		if _, clash := target.folded[lower]; clash {
+			target.folded[lower] = nil
+			continue
+		}
+		target.folded[lower] = target.index[col]
+	}
+	return target, nil
+}
+
+// rawColumnOf names the column a field reads, or reports that it reads none.
+func rawColumnOf(sf reflect.StructField, naming figo.NamingFunc) (string, bool) {
+	if name, _, _ := strings.Cut(sf.Tag.Get("db"), ","); name == "-" {
+		return "", false
+	} else if name != "" {
+		return name, true
+	}
+	for _, part := range strings.Split(sf.Tag.Get("gorm"), ";") {
+		part = strings.TrimSpace(part)
+		if part == "-" {
+			return "", false
+		}
+		if col, ok := strings.CutPrefix(part, "column:"); ok && col != "" {
+			return col, true
+		}
+	}
+	return naming(sf.Name), true
+}
+
+func indirectStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// rawFieldByIndex is reflect.Value.FieldByIndex allocating nil embedded
+// pointers on the way, so a promoted field can be scanned into.
+func rawFieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
@@ -0,0 +1,97 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	figo "github.com/bi0dread/figo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rawQueryItem struct {
+	ID    int
+	A     sql.NullInt64
+	Score *int64 `db:"b"`
+	Name  sql.NullString
+	Note  string `db:"-"`
+}
+
+// figoFor parses dsl into a new instance built for adapter.
+func figoFor(t *testing.T, adapter figo.Adapter, dsl string) figo.Figo {
+	t.Helper()
+	f := figo.New()
+	require.NoError(t, f.AddFiltersFromString(dsl), dsl)
+	f.Build(adapter)
+	return f
+}
+
+var sqliteRaw = RawAdapter{Dialect: SQLiteDialect}
+
+func TestQueryRawScansAndCounts(t *testing.T) {
+	d := oracleDB(t)
+	ctx := context.Background()
+
+	f := figoFor(t, sqliteRaw, `b>=10 sort=b:desc page=skip:1,take:2`)
+	items, total, err := QueryRawWithTotal[rawQueryItem](ctx, d, f, "items")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total, "the count ignores the page")
+	require.Len(t, items, 2)
+	assert.Equal(t, 3, items[0].ID)
+	assert.False(t, items[0].A.Valid)
+	assert.Equal(t, int64(30), *items[0].Score)
+	assert.Equal(t, "carol", items[0].Name.String)
+	assert.Equal(t, 2, items[1].ID)
+
+	// The same ids as the hand-written scan loop.
+	all, err := QueryRaw[rawQueryItem](ctx, d, figoFor(t, sqliteRaw, `a<nin>[1] or name<null>`), "items")
+	require.NoError(t, err)
+	ids := []int{}
+	for _, it := range all {
+		ids = append(ids, it.ID)
+	}
+	assert.ElementsMatch(t, sqlIDs(t, d, `a<nin>[1] or name<null>`), ids)
+
+	none, total, err := QueryRawWithTotal[rawQueryItem](ctx, d, figoFor(t, sqliteRaw, `a=99`), "items")
+	require.NoError(t, err)
+	assert.Equal(t, []rawQueryItem{}, none)
+	assert.Equal(t, int64(0), total)
+}
+
+// Select fields narrow the statement; each returned column still needs a field.
+func TestQueryRawSelectFields(t *testing.T) {
+	d := oracleDB(t)
+	type idName struct {
+		ID   int    `gorm:"column:id"`
+		Name string `db:"NAME"`
+	}
+	f := figoFor(t, sqliteRaw, `id<in>[1,2] sort=id:asc`)
+	f.AddSelectFields("id", "name")
+	got, err := QueryRaw[idName](context.Background(), d, f, "items")
+	require.NoError(t, err)
+	assert.Equal(t, []idName{{1, "alice"}, {2, "bob"}}, got)
+
+	f.AddSelectFields("a")
+	_, err = QueryRaw[idName](context.Background(), d, f, "items")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `result column "a" has no field`)
+}
+
+func TestQueryRawFailsClosed(t *testing.T) {
+	d := oracleDB(t)
+
+	_, err := QueryRaw[int](context.Background(), d, figoFor(t, sqliteRaw, `id=1`), "items")
+	assert.Error(t, err, "T must be a struct")
+
+	f := figo.New()
+	f.AddFilter(figo.GeoDistanceExpr{Field: "loc", Latitude: 1, Longitude: 2, Distance: 3})
+	f.Build(RawAdapter{Dialect: SQLiteDialect})
+	_, _, err = QueryRawWithTotal[rawQueryItem](context.Background(), d, f, "items")
+	assert.Error(t, err, "an unrenderable filter never runs")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = QueryRaw[rawQueryItem](ctx, d, figoFor(t, sqliteRaw, `id>0`), "items")
+	assert.ErrorIs(t, err, context.Canceled)
+}
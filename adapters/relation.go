@@ -0,0 +1,101 @@
+package adapters
+
+import (
+	figo "github.com/bi0dread/figo/v4"
+
+	"fmt"
+	"sort"
+)
+
+// SQLRelation tells the SQL adapters how a relation named in a quantified
+// predicate (orders<any>[status="paid"]) joins its parent table. The adapters
+// have no schema metadata, so the join is spelled out once per relation on
+// RawAdapter.Relations / GormAdapter.Relations, keyed by the relation name
+// exactly as it appears in the DSL:
+//
+//	adapters.RawAdapter{Relations: map[string]adapters.SQLRelation{
+//	    "orders": {Table: "orders", ForeignKey: "user_id"},
+//	}}
+//
+// renders orders<any>[status="paid"] against the users table as
+//
+//	EXISTS (SELECT 1 FROM orders AS figo_rel1
+//	        WHERE figo_rel1.user_id = users.id AND (status = ?))
+type SQLRelation struct {
+	Table      string // the related table
+	ForeignKey string // column of Table that references the parent row
+	LocalKey   string // parent column ForeignKey references; "id" when empty
+}
+
+// localKey returns the parent-side join column, defaulting to "id".
+func (r SQLRelation) localKey() string {
+	if r.LocalKey == "" {
+		return "id"
+	}
+	return r.LocalKey
+}
+
+// sqlRelationAlias names the correlated subquery's table at nesting level n
+// (1 for a top-level predicate). Every level gets its own alias so a nested
+// relation correlates with its immediate parent, never with the outer query.
+func sqlRelationAlias(n int) string {
+	return fmt.Sprintf("figo_rel%d", n)
+}
+
+// lookupSQLRelation resolves the configuration for a quantified predicate.
+// An unconfigured relation fails the render: guessing a join key would
+// correlate the wrong rows, and dropping the predicate would widen the result.
+// adapter prefixes the error ("raw adapter", "gorm adapter").
+func lookupSQLRelation(adapter string, relations map[string]SQLRelation, x figo.RelationExpr) (SQLRelation, error) {
+	switch x.Quantifier {
+	case figo.QuantifierAny, figo.QuantifierAll, figo.QuantifierNone:
+	default:
+		return SQLRelation{}, fmt.Errorf("%s: relation %q has unknown quantifier %q (want any, all or none)", adapter, x.Relation, x.Quantifier)
+	}
+	rel, ok := relations[x.Relation]
+	if !ok {
+		configured := make([]string, 0, len(relations))
+		for k := range relations {
+			configured = append(configured, k)
+		}
+		sort.Strings(configured)
+		return SQLRelation{}, fmt.Errorf("%s: no SQLRelation configured for relation %q (Relations is keyed by the relation name exactly as it appears in the DSL; configured keys: %v)", adapter, x.Relation, configured)
+	}
+	if rel.Table == "" || rel.ForeignKey == "" {
+		return SQLRelation{}, fmt.Errorf("%s: SQLRelation for relation %q needs Table and ForeignKey (got Table=%q ForeignKey=%q)", adapter, x.Relation, rel.Table, rel.ForeignKey)
+	}
+	return rel, nil
+}
+
+// sqlQuantify wraps a correlated subquery — "SELECT 1 FROM t AS a WHERE
+// <join>", with cond the rendered condition on the related row ("" when there
+// is none) — in the predicate its quantifier asks for:
+//
+//	any   EXISTS (<sub> AND (<cond>))
+//	none  NOT EXISTS (<sub> AND (<cond>))
+//	all   NOT EXISTS (<sub> AND CASE WHEN (<cond>) THEN 0 ELSE 1 END = 1)
+//
+// <all> is "no related row fails the condition". The CASE form matters: the
+// obvious NOT (<cond>) is UNKNOWN, not true, for a row whose condition is
+// UNKNOWN (a NULL column), so that row would not count as a counterexample and
+// a user with an order of unknown status would pass "every order is paid". An
+// <all> without a condition is vacuously true.
+func sqlQuantify(q figo.Quantifier, sub, cond string) string {
+	switch q {
+	case figo.QuantifierAll:
+		if cond == "" {
+			return "1=1"
+		}
+		return "NOT EXISTS (" + sub + " AND CASE WHEN (" + cond + ") THEN 0 ELSE 1 END = 1)"
+	case figo.QuantifierNone:
+		if cond == "" {
+			return "NOT EXISTS (" + sub + ")"
+		}
+		return "NOT EXISTS (" + sub + " AND (" + cond + "))"
+	default:
+		if cond == "" {
+			return "EXISTS (" + sub + ")"
+		}
+		return "EXISTS (" + sub + " AND (" + cond + "))"
+	}
+}
@@ -0,0 +1,317 @@
+package adapters
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	figo "github.com/bi0dread/figo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Quantified relation predicates (orders<any>[...]) are checked by EXECUTING
+// them: the raw and GORM renderings run against SQLite and the Mongo pipeline
+// runs through the MongoDB-semantics evaluator, and all three must return the
+// same parents. A shape comparison could not catch the two ways this goes
+// wrong — correlating with the wrong row, and <all> passing a child whose
+// condition is UNKNOWN.
+
+type relUser struct {
+	ID   int
+	Name string
+}
+
+var relOrders = []struct {
+	id, userID, total int
+	status            any // string or nil
+}{
+	{10, 1, 100, "paid"},
+	{11, 1, 50, "open"},
+	{12, 2, 20, "paid"},
+	{13, 3, 70, nil},
+	{14, 3, 30, "paid"},
+}
+
+var relOrderItems = []struct {
+	id, orderID int
+	sku         string
+}{
+	{100, 10, "A"},
+	{101, 11, "B"},
+	{102, 12, "B"},
+	{103, 14, "A"},
+}
+
+var relSQLRelations = map[string]SQLRelation{
+	"orders": {Table: "orders", ForeignKey: "user_id"},
+	"items":  {Table: "order_items", ForeignKey: "order_id"},
+}
+
+var relMongoJoins = map[string]MongoJoin{
+	"orders": {From: "orders", LocalField: "id", ForeignField: "user_id"},
+	"items":  {From: "order_items", LocalField: "id", ForeignField: "order_id"},
+}
+
+func relDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	d, err := db.DB()
+	require.NoError(t, err)
+	d.SetMaxOpenConns(1) // one connection: every query sees the same in-memory database
+	t.Cleanup(func() { _ = d.Close() })
+	require.NoError(t, db.Exec(`CREATE TABLE users (id INTEGER, name TEXT)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO users VALUES (1,'ann'),(2,'bob'),(3,'cat'),(4,'dan')`).Error)
+	require.NoError(t, db.Exec(`CREATE TABLE orders (id INTEGER, user_id INTEGER, total INTEGER, status TEXT)`).Error)
+	for _, o := range relOrders {
+		require.NoError(t, db.Exec(`INSERT INTO orders VALUES (?,?,?,?)`, o.id, o.userID, o.total, o.status).Error)
+	}
+	require.NoError(t, db.Exec(`CREATE TABLE order_items (id INTEGER, order_id INTEGER, sku TEXT)`).Error)
+	for _, it := range relOrderItems {
+		require.NoError(t, db.Exec(`INSERT INTO order_items VALUES (?,?,?)`, it.id, it.orderID, it.sku).Error)
+	}
+	return db
+}
+
+func relMongoCollections() (users []bson.M, collections map[string][]bson.M) {
+	users = []bson.M{{"id": 1, "name": "ann"}, {"id": 2, "name": "bob"}, {"id": 3, "name": "cat"}, {"id": 4, "name": "dan"}}
+	orders := make([]bson.M, 0, len(relOrders))
+	for _, o := range relOrders {
+		orders = append(orders, bson.M{"id": o.id, "user_id": o.userID, "total": o.total, "status": o.status})
+	}
+	items := make([]bson.M, 0, len(relOrderItems))
+	for _, it := range relOrderItems {
+		items = append(items, bson.M{"id": it.id, "order_id": it.orderID, "sku": it.sku})
+	}
+	return users, map[string][]bson.M{"orders": orders, "order_items": items}
+}
+
+func relRawIDs(t *testing.T, db *gorm.DB, dsl string) []int {
+	t.Helper()
+	a := RawAdapter{Dialect: SQLiteDialect, Relations: relSQLRelations}
+	f := figo.New()
+	require.NoError(t, f.AddFiltersFromString(dsl))
+	require.NoError(t, f.BuildE(a))
+	q, ok := a.GetQuery(f, "users")
+	require.True(t, ok, "raw render failed for %q", dsl)
+	s := q.(figo.SQLQuery)
+	var rows []relUser
+	require.NoError(t, db.Raw(s.SQL, s.Args...).Scan(&rows).Error, "statement did not execute: %s", s.SQL)
+	return relUserIDs(rows)
+}
+
+func relGormIDs(t *testing.T, db *gorm.DB, dsl string) []int {
+	t.Helper()
+	f := figo.New()
+	require.NoError(t, f.AddFiltersFromString(dsl))
+	require.NoError(t, f.BuildE(GormAdapter{Relations: relSQLRelations}))
+	var rows []relUser
+	require.NoError(t, ApplyGorm(f, db.Table("users")).Find(&rows).Error)
+	return relUserIDs(rows)
+}
+
+func relMongoIDs(t *testing.T, dsl string) []int {
+	t.Helper()
+	f := figo.New()
+	require.NoError(t, f.AddFiltersFromString(dsl))
+	require.NoError(t, f.BuildE(MongoAdapter{}))
+	pipe, err := BuildMongoAggregatePipeline(f, relMongoJoins)
+	require.NoError(t, err)
+	users, collections := relMongoCollections()
+	docs, err := (&mongoEval{collections: collections}).runPipeline(pipe, users)
+	require.NoError(t, err)
+	ids := []int{}
+	for _, d := range docs {
+		for k := range d {
+			assert.NotContains(t, k, mongoRelationAliasPrefix, "the private lookup field must not reach the caller")
+		}
+		ids = append(ids, d["id"].(int))
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func relUserIDs(rows []relUser) []int {
+	ids := []int{}
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func TestRelationPredicates_AdaptersAgree(t *testing.T) {
+	db := relDB(t)
+	cases := []struct {
+		dsl  string
+		want []int
+	}{
+		{`orders<any>[status="paid"]`, []int{1, 2, 3}},
+		{`orders<none>[status="paid"]`, []int{4}},
+		// cat's order 13 has no status: "every order is paid" is not true of
+		// it, and dan has no orders, so the claim holds vacuously.
+		{`orders<all>[status="paid"]`, []int{2, 4}},
+		{`orders<any>[]`, []int{1, 2, 3}},
+		{`orders<none>[]`, []int{4}},
+		{`orders<all>[]`, []int{1, 2, 3, 4}},
+		{`not orders<any>[status="open"]`, []int{2, 3, 4}},
+		{`orders<any>[status="paid" and total>50]`, []int{1}},
+		{`orders<any>[(status="open" or total<25) and total>10]`, []int{1, 2}},
+		{`orders<all>[total>=30] or name="bob"`, []int{1, 2, 3, 4}},
+		{`orders<any>[status="paid"] and name!="ann"`, []int{2, 3}},
+		// Nested: the inner relation correlates with the ORDER, not the user.
+		{`orders<any>[items<any>[sku="B"]]`, []int{1, 2}},
+		{`orders<any>[items<none>[]]`, []int{3}},
+		{`orders<all>[items<any>[sku="A"]]`, []int{4}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.dsl, func(t *testing.T) {
+			assert.Equal(t, tc.want, relRawIDs(t, db, tc.dsl), "raw")
+			assert.Equal(t, tc.want, relGormIDs(t, db, tc.dsl), "gorm")
+			assert.Equal(t, tc.want, relMongoIDs(t, tc.dsl), "mongo")
+		})
+	}
+}
+
+func TestRelationPredicates_RawRendering(t *testing.T) {
+	f := figo.New()
+	require.NoError(t, f.AddFiltersFromString(`orders<all>[status="paid"]`))
+	a := RawAdapter{Dialect: PostgresDialect, Relations: relSQLRelations}
+	f.Build(a)
+	q, ok := a.GetQuery(f, "users")
+	require.True(t, ok)
+	assert.Equal(t, `SELECT * FROM "users" WHERE NOT EXISTS (SELECT 1 FROM "orders" AS "figo_rel1" WHERE "figo_rel1"."user_id" = "users"."id" AND CASE WHEN ("status" = $1) THEN 0 ELSE 1 END = 1)`,
+		q.(figo.SQLQuery).SQL)
+	assert.Equal(t, []any{"paid"}, q.(figo.SQLQuery).Args)
+}
+
+// The WHERE-only helpers render no table, so an unqualified LocalKey has
+// nothing to correlate with; left bare it would bind to the subquery's own id.
+func TestRelationPredicates_RawWhereNeedsQualifiedLocalKey(t *testing.T) {
+	f := figo.New()
+	require.NoError(t, f.AddFiltersFromString(`orders<any>[status="paid"]`))
+	f.Build(RawAdapter{Relations: relSQLRelations})
+	_, _, err := BuildRawWhere(f)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "LocalKey")
+
+	f.Build(RawAdapter{Relations: map[string]SQLRelation{
+		"orders": {Table: "orders", ForeignKey: "user_id", LocalKey: "users.id"},
+	}})
+	where, args, err := BuildRawWhere(f)
+	require.NoError(t, err)
+	assert.Equal(t, "EXISTS (SELECT 1 FROM `orders` AS `figo_rel1` WHERE `figo_rel1`.`user_id` = `users`.`id` AND (`status` = ?))", where)
+	assert.Equal(t, []any{"paid"}, args)
+}
+
+// An unconfigured relation fails the render on every adapter instead of
+// guessing a join key or dropping the predicate (which would widen the result).
+func TestRelationPredicates_UnconfiguredRelationFailsClosed(t *testing.T) {
+	build := func(a figo.Adapter) figo.Figo {
+		f := figo.New()
+		require.NoError(t, f.AddFiltersFromString(`orders<none>[status="refunded"]`))
+		f.Build(a)
+		return f
+	}
+
+	_, ok := RawAdapter{}.GetQuery(build(RawAdapter{}), "users")
+	assert.False(t, ok, "raw")
+
+	db := relDB(t)
+	var rows []relUser
+	err := ApplyGorm(build(GormAdapter{}), db.Table("users")).Find(&rows).Error
+	require.Error(t, err, "gorm")
+	assert.Contains(t, err.Error(), `relation "orders"`)
+
+	_, err = BuildMongoAggregatePipeline(build(MongoAdapter{}), nil)
+	assert.Error(t, err, "mongo")
+
+	q, err := BuildElasticsearchQuery(build(ElasticsearchAdapter{}))
+	assert.Error(t, err, "elasticsearch")
+	assert.Contains(t, q.Query, "match_none")
+}
+
+// The Find path has no $lookup: a relation predicate must fail there rather
+// than be dropped.
+func TestRelationPredicates_MongoFindPathRefuses(t *testing.T) {
+	f := figo.New()
+	require.NoError(t, f.AddFiltersFromString(`a=1 and orders<any>[status="paid"]`))
+	f.Build(MongoAdapter{})
+	_, err := BuildMongoFilter(f)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "aggregate")
+}
+
+func TestRelationPredicates_MongoRefusesTextWithRelation(t *testing.T) {
+	f := figo.New()
+	f.AddFilter(figo.FullTextSearchExpr{Query: "x"})
+	f.AddFilter(figo.RelationExpr{Relation: "orders", Quantifier: figo.QuantifierAny})
+	f.Build(MongoAdapter{})
+	_, err := BuildMongoAggregatePipeline(f, relMongoJoins)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "$text")
+}
+
+func esRelationJSON(t *testing.T, a ElasticsearchAdapter, dsl string) string {
+	t.Helper()
+	f := figo.New()
+	require.NoError(t, f.AddFiltersFromString(dsl))
+	f.Build(a)
+	q, err := BuildElasticsearchQuery(f)
+	require.NoError(t, err)
+	b, err := json.Marshal(q.Query)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestRelationPredicates_Elasticsearch(t *testing.T) {
+	nested := ElasticsearchAdapter{Relations: map[string]ESRelation{
+		"orders": {Path: "orders"},
+		"items":  {Path: "orders.items"},
+	}}
+	parentChild := ElasticsearchAdapter{Relations: map[string]ESRelation{
+		"orders": {ChildType: "order"},
+	}}
+
+	t.Run("nested any prefixes the condition's fields", func(t *testing.T) {
+		assert.JSONEq(t,
+			`{"nested":{"path":"orders","query":{"bool":{"must":[{"term":{"orders.status":"paid"}},{"range":{"orders.total":{"gt":50}}}]}}}}`,
+			esRelationJSON(t, nested, `orders<any>[status="paid" and total>50]`))
+	})
+	t.Run("nested none", func(t *testing.T) {
+		assert.JSONEq(t,
+			`{"bool":{"must_not":[{"nested":{"path":"orders","query":{"term":{"orders.status":"paid"}}}}]}}`,
+			esRelationJSON(t, nested, `orders<none>[status="paid"]`))
+	})
+	t.Run("nested all looks for a counterexample", func(t *testing.T) {
+		assert.JSONEq(t,
+			`{"bool":{"must_not":[{"nested":{"path":"orders","query":{"bool":{"must_not":[{"term":{"orders.status":"paid"}}]}}}}]}}`,
+			esRelationJSON(t, nested, `orders<all>[status="paid"]`))
+	})
+	t.Run("nested in nested uses the inner relation's full path", func(t *testing.T) {
+		assert.JSONEq(t,
+			`{"nested":{"path":"orders","query":{"nested":{"path":"orders.items","query":{"term":{"orders.items.sku":"B"}}}}}}`,
+			esRelationJSON(t, nested, `orders<any>[items<any>[sku="B"]]`))
+	})
+	t.Run("has_child keeps the child's own field names", func(t *testing.T) {
+		assert.JSONEq(t,
+			`{"has_child":{"type":"order","query":{"term":{"status":"paid"}}}}`,
+			esRelationJSON(t, parentChild, `orders<any>[status="paid"]`))
+	})
+	t.Run("negation is the plain exclusion", func(t *testing.T) {
+		assert.JSONEq(t,
+			`{"bool":{"must_not":[{"has_child":{"type":"order","query":{"match_all":{}}}}]}}`,
+			esRelationJSON(t, parentChild, `not orders<any>[]`))
+	})
+	t.Run("a relation needs exactly one of Path and ChildType", func(t *testing.T) {
+		f := figo.New()
+		require.NoError(t, f.AddFiltersFromString(`orders<any>[]`))
+		f.Build(ElasticsearchAdapter{Relations: map[string]ESRelation{"orders": {Path: "orders", ChildType: "order"}}})
+		_, err := BuildElasticsearchQuery(f)
+		require.Error(t, err)
+	})
+}
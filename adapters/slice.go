@@ -0,0 +1,144 @@
+package adapters
+
+import (
+	figo "github.com/bi0dread/figo/v4"
+
+	"fmt"
+	"reflect"
+)
+
+// SliceAdapter runs figo queries over a Go slice held in memory, so unit tests
+// and small reference-data endpoints share the production DSL handling without
+// a database. The slice is the ctx argument of GetQuery (or the items argument
+// of BuildSliceQuery): any slice or array, or a pointer to one, whose elements
+// are maps with string keys or structs. Filtering, sorting and field
+// resolution are figo.Evaluator's — SQL NULL semantics, struct tags and the
+// instance's NamingFunc — and the page is cut after sorting, as a database
+// would.
+//
+// With select fields set (AddSelectFields), each item in the result is a
+// map[string]any holding just those fields, keyed by the column name the SQL
+// adapters would select (the NamingFunc applied per dotted segment) and
+// valued as figo.Evaluator.Field returns them. Without them, the result holds
+// the original elements.
+//
+// A load= preload is rejected: a preload narrows the related rows an ORM
+// fetches, and a slice has nothing to fetch.
+type SliceAdapter struct{}
+
+// SliceQuery is SliceAdapter's typed GetQuery result.
+type SliceQuery struct {
+	// Items is the requested page of matches, sorted.
+	Items []any
+	// Total counts every match, before paging.
+	Total int
+}
+
+func (SliceQuery) IsQuery() {}
+
+// Store implements figo.StoreAdapter
+func (SliceAdapter) Store() string { return figo.StoreMemory }
+
+// GetSqlString reports false: an in-memory query has no SQL.
+func (SliceAdapter) GetSqlString(f figo.Figo, ctx any, conditionType ...string) (string, bool) {
+	return "", false
+}
+
+// GetQuery evaluates f over the slice passed as ctx. When the query cannot be
+// evaluated it reports false with an empty SliceQuery — never a partial
+// result — so f.GetQuery(items).(adapters.SliceQuery) cannot panic and fails
+// closed; use BuildSliceQuery to see the error.
+func (SliceAdapter) GetQuery(f figo.Figo, ctx any, conditionType ...string) (figo.Query, bool) {
+	if f == nil {
+		return SliceQuery{}, false
+	}
+	q, err := BuildSliceQuery(f, ctx)
+	if err != nil {
+		return SliceQuery{}, false
+	}
+	return q, true
+}
+
+// BuildSliceQuery filters, sorts, pages and projects items with the built
+// figo instance. items is not modified.
+func BuildSliceQuery(f figo.Figo, items any) (SliceQuery, error) {
+	elems, err := sliceElements(items)
+	if err != nil {
+		return SliceQuery{}, err
+	}
+	if preloads := f.GetPreloads(); len(preloads) > 0 {
+		names := make([]string, 0, len(preloads))
+		for relation := range preloads {
+			names = append(names, relation)
+		}
+		sortStrings(names)
+		return SliceQuery{}, fmt.Errorf("slice adapter: cannot apply the load= preload %q to an in-memory slice", names[0])
+	}
+	ev, err := figo.NewEvaluator(f)
+	if err != nil {
+		return SliceQuery{}, err
+	}
+	page, total, err := figo.ApplyEvaluator(ev, elems)
+	if err != nil {
+		return SliceQuery{}, err
+	}
+	columns, err := sliceColumns(f)
+	if err != nil {
+		return SliceQuery{}, err
+	}
+	if len(columns) == 0 {
+		return SliceQuery{Items: page, Total: total}, nil
+	}
+	out := SliceQuery{Items: make([]any, len(page)), Total: total}
+	for i, el := range page {
+		row := make(map[string]any, len(columns))
+		for _, col := range columns {
+			v, err := ev.Field(el, col)
+			if err != nil {
+				return SliceQuery{}, err
+			}
+			row[col] = v
+		}
+		out.Items[i] = row
+	}
+	return out, nil
+}
+
+// sliceElements unpacks a slice, an array or a pointer to either.
+func sliceElements(items any) ([]any, error) {
+	rv := reflect.ValueOf(items)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("slice adapter: the query context must be a slice or an array, got %T", items)
+	}
+	elems := make([]any, rv.Len())
+	for i := range elems {
+		elems[i] = rv.Index(i).Interface()
+	}
+	return elems, nil
+}
+
+// sliceColumns is the projection, named and deduplicated exactly like the
+// raw adapter's SELECT list.
+func sliceColumns(f figo.Figo) ([]string, error) {
+	sel := f.GetSelectFields()
+	if len(sel) == 0 {
+		return nil, nil
+	}
+	cols := make([]string, 0, len(sel))
+	seen := make(map[string]bool, len(sel))
+	for _, name := range sortedKeys(sel) {
+		col := normalizeColumnName(f, name)
+		if seen[col] {
+			continue
+		}
+		seen[col] = true
+		if col == "" {
+			return nil, fmt.Errorf("slice adapter: empty select field")
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
@@ -0,0 +1,128 @@
+package adapters
+
+import (
+	"testing"
+
+	figo "github.com/bi0dread/figo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sliceFixture() []map[string]any {
+	out := make([]map[string]any, 0, len(oracleRows))
+	for _, r := range oracleRows {
+		out = append(out, map[string]any{"id": r.id, "a": r.a, "b": r.b, "name": r.name})
+	}
+	return out
+}
+
+func sliceIDs(t *testing.T, dsl string) []int {
+	t.Helper()
+	f := figo.New()
+	require.NoError(t, f.AddFiltersFromString(dsl), dsl)
+	f.Build(SliceAdapter{})
+	q, ok := f.GetQuery(sliceFixture()).(SliceQuery)
+	require.True(t, ok, dsl)
+	ids := []int{}
+	for _, item := range q.Items {
+		ids = append(ids, item.(map[string]any)["id"].(int))
+	}
+	return ids
+}
+
+// The slice adapter returns the rows, in the order, that the raw adapter's SQL
+// returns from SQLite over the same fixture — NULLs, negation, patterns, sort
+// and page included.
+func TestSliceAdapterAgreesWithSQLite(t *testing.T) {
+	d := oracleDB(t)
+	for _, dsl := range []string{
+		`a=2`,
+		`a!=2`,
+		`not (a=2)`,
+		`a>1 or b<20`,
+		`not (a>1 or b<20)`,
+		`a<null>`,
+		`name<notnull> and b>=20`,
+		`a<in>[1,5,null]`,
+		`a<nin>[1,null]`,
+		`a<nin>[1]`,
+		`b<bet>(10..30)`,
+		`name=^"%o%"`,
+		`name=^"_a%"`,
+		`name!=^"%a%"`,
+		`name.=^"A%"`,
+		`name=""`,
+		`sort=a:asc,id:asc`,
+		`sort=a:desc,id:asc`,
+		`b>0 sort=name:desc page=skip:1,take:2`,
+		`page=skip:4`,
+	} {
+		assert.Equal(t, sqlIDs(t, d, dsl), sliceIDs(t, dsl), dsl)
+	}
+}
+
+type sliceProduct struct {
+	SKU      string `json:"sku"`
+	Price    float64
+	Category *string
+}
+
+func TestSliceAdapterProjectsAndCounts(t *testing.T) {
+	toys := "toys"
+	items := []sliceProduct{{"A1", 9.5, &toys}, {"B2", 20, nil}, {"C3", 3, &toys}}
+
+	f := figo.New()
+	require.NoError(t, f.AddFiltersFromString(`category="toys" sort=price:desc page=skip:0,take:1`))
+	f.AddSelectFields("sku", "price", "Price")
+	f.Build(SliceAdapter{})
+	q, err := BuildSliceQuery(f, &items)
+	require.NoError(t, err)
+	assert.Equal(t, 2, q.Total)
+	assert.Equal(t, []any{map[string]any{"sku": "A1", "price": 9.5}}, q.Items)
+
+	// Without a projection the items are the original elements.
+	g := figo.New()
+	require.NoError(t, g.AddFiltersFromString(`category<null>`))
+	g.Build(SliceAdapter{})
+	q, err = BuildSliceQuery(g, items)
+	require.NoError(t, err)
+	assert.Equal(t, []any{items[1]}, q.Items)
+	assert.Equal(t, 1, q.Total)
+}
+
+// Anything the adapter cannot apply fails the render, and GetQuery answers an
+// empty SliceQuery rather than a partial one.
+func TestSliceAdapterFailsClosed(t *testing.T) {
+	for dsl, ctx := range map[string]any{
+		`a=1 load=[orders:total>1]`: sliceFixture(),
+		`a>"x"`:                     sliceFixture(),
+		`nope=1`:                    []sliceProduct{{}},
+		`a=1`:                       map[string]any{"a": 1},
+	} {
+		f := figo.New()
+		require.NoError(t, f.AddFiltersFromString(dsl), dsl)
+		f.Build(SliceAdapter{})
+		_, err := BuildSliceQuery(f, ctx)
+		assert.Error(t, err, dsl)
+		q, ok := SliceAdapter{}.GetQuery(f, ctx)
+		assert.False(t, ok, dsl)
+		assert.Equal(t, SliceQuery{}, q, dsl)
+		assert.Equal(t, SliceQuery{}, f.GetQuery(ctx), dsl)
+	}
+
+	// Full-text search has no in-memory meaning.
+	f := figo.New()
+	f.AddFilter(figo.FullTextSearchExpr{Query: "hello"})
+	f.Build(SliceAdapter{})
+	_, err := BuildSliceQuery(f, sliceFixture())
+	assert.Error(t, err)
+
+	f = figo.New()
+	f.Build(SliceAdapter{})
+	_, ok := SliceAdapter{}.GetSqlString(f, sliceFixture())
+	assert.False(t, ok)
+
+	q, ok := SliceAdapter{}.GetQuery(nil, sliceFixture())
+	assert.False(t, ok)
+	assert.Equal(t, SliceQuery{}, q, "a nil instance still yields a SliceQuery")
+}
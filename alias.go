@@ -0,0 +1,523 @@
+package figo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Public field names and physical ones drift apart: an API promises
+// `customer` while the column is users.full_name, or promises `age` while the
+// table stores birth_date. A NamingFunc maps one spelling to another; an
+// AliasRegistry maps a public name to whatever the store needs — a column for
+// SQL, a document path for Mongo or Elasticsearch — or expands a VIRTUAL
+// field into an expression over physical ones. Saved DSL keeps working while
+// the schema underneath it changes.
+//
+//	aliases, err := figo.NewAliasRegistry(figo.Aliases{
+//	    "customer": {Field: "users.full_name", Stores: map[string]string{figo.StoreMongo: "user.fullName"}},
+//	    "vip":      {Templates: map[string]string{"=": `tier<in>["gold","platinum"] and active=${value}`}},
+//	    "age":      {Expand: ageFromBirthDate},
+//	})
+//	f.SetAliases(aliases)
+//
+// Aliases apply to what the DSL says — its filters, its load=[...]
+// conditions and its sort= — when Build parses it, before the plugin
+// filters run, so plugins (a FieldsPlugin whitelist, a PolicyPlugin) judge
+// the physical names, as they judge names after the NamingFunc. Expressions
+// added with AddFilter and sorts set with SetSort are code, already written
+// against the schema, and are not aliased.
+
+// Store names for Alias.Stores, as adapters report them through
+// StoreAdapter.
+const (
+	StoreSQL           = "sql"
+	StoreMongo         = "mongo"
+	StoreElasticsearch = "elasticsearch"
+	StoreMemory        = "memory"
+)
+
+// StoreAdapter is implemented by adapters that say which kind of store they
+// render for, so an alias can name the field the way that store does.
+type StoreAdapter interface {
+	Store() string
+}
+
+// AdapterStore returns the store an adapter renders for, or "" when it does
+// not say (or is nil).
+func AdapterStore(a Adapter) string {
+	if s, ok := a.(StoreAdapter); ok {
+		return s.Store()
+	}
+	return ""
+}
+
+// Alias says what a public field name stands for. Set exactly one of:
+//
+//   - Field (and optionally Stores): the physical name, used verbatim — no
+//     NamingFunc applies to it. Stores overrides Field for a store, keyed
+//     by StoreSQL, StoreMongo, ...; a store with no entry uses Field.
+//   - Templates: a virtual field expanded from DSL, one template per
+//     operator ("=", "!=", ">", "<in>", "<null>", ...) — the operator the
+//     caller used picks the template, and an operator with no template is an
+//     error. `${value}` stands for the caller's value, as a whole value: a
+//     scalar, or inside an <in>/<nin> list, which an <in> list fills.
+//     Templates are parsed once, without naming conversion, and the value is
+//     substituted after parsing, so it is never parsed as DSL.
+//   - Expand: a virtual field computed in Go. It receives the caller's
+//     predicate (its Field is the public name, after the NamingFunc) and
+//     returns the expression to use instead, with physical field names.
+//
+// An expansion is not aliased again.
+type Alias struct {
+	Field     string
+	Stores    map[string]string
+	Templates map[string]string
+	Expand    func(e Expr) (Expr, error)
+}
+
+// Aliases maps public field names to what they stand for. Inside a preload
+// (load=[Orders:...]) or a relation predicate (orders<any>[...]), a name is
+// looked up qualified by the relation — "Orders.customer" — since the
+// relation's table has its own columns.
+type Aliases map[string]Alias
+
+// AliasRegistry is a validated, compiled Aliases. It is immutable, so one
+// registry can be shared by every instance.
+type AliasRegistry struct {
+	aliases map[string]compiledAlias
+}
+
+type compiledAlias struct {
+	field     string
+	stores    map[string]string
+	templates map[string]Expr
+	expand    func(e Expr) (Expr, error)
+}
+
+const aliasValuePlaceholder = "${value}"
+
+// aliasOperators are the operators a template may be registered under.
+var aliasOperators = map[string]bool{
+	"=": true, "!=": true, ">": true, ">=": true, "<": true, "<=": true,
+	"=^": true, ".=^": true, "=~": true, "<in>": true, "<nin>": true,
+	"<bet>": true, "<null>": true, "<notnull>": true,
+}
+
+// NewAliasRegistry validates aliases and compiles their templates.
+func NewAliasRegistry(aliases Aliases) (*AliasRegistry, error) {
+	r := &AliasRegistry{aliases: make(map[string]compiledAlias, len(aliases))}
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		a := aliases[name]
+		if name == "" {
+			return nil, fmt.Errorf("alias with an empty name")
+		}
+		kinds := 0
+		if a.Field != "" || len(a.Stores) > 0 {
+			kinds++
+		}
+		if len(a.Templates) > 0 {
+			kinds++
+		}
+		if a.Expand != nil {
+			kinds++
+		}
+		if kinds != 1 {
+			return nil, fmt.Errorf("alias %q: set exactly one of Field/Stores, Templates or Expand", name)
+		}
+		c := compiledAlias{field: a.Field, expand: a.Expand}
+		if len(a.Stores) > 0 {
+			c.stores = make(map[string]string, len(a.Stores))
+			for store, field := range a.Stores {
+				if field == "" {
+					return nil, fmt.Errorf("alias %q: empty field for store %q", name, store)
+				}
+				c.stores[store] = field
+			}
+		}
+		if len(a.Templates) > 0 {
+			c.templates = make(map[string]Expr, len(a.Templates))
+			for op, dsl := range a.Templates {
+				if !aliasOperators[op] {
+					return nil, fmt.Errorf("alias %q: unknown operator %q", name, op)
+				}
+				t, err := compileAliasTemplate(dsl)
+				if err != nil {
+					return nil, fmt.Errorf("alias %q: template for %s: %w", name, op, err)
+				}
+				c.templates[op] = t
+			}
+		}
+		r.aliases[name] = c
+	}
+	return r, nil
+}
+
+// compileAliasTemplate parses one template, refusing directives and
+// placeholders the substitution could not reach (inside a longer string, or
+// in a position without a plain value), which would reach the database as a
+// literal. It parses without building, so a date-math literal stays one and
+// each Build resolves it against that instance's clock and time zone.
+func compileAliasTemplate(dsl string) (Expr, error) {
+	if reason := dslResourceGuard(dsl); reason != "" {
+		return nil, errors.New(reason)
+	}
+	t := New().(*figo)
+	t.namingFunc = NoChangeNaming
+	var diags []error
+	t.mu.Lock()
+	e := t.parseFinal(dsl, &diags)
+	t.mu.Unlock()
+	if len(diags) > 0 {
+		return nil, errors.Join(diags...)
+	}
+	if t.sort != nil || t.page != (Page{}) || len(t.preloads) > 0 {
+		return nil, fmt.Errorf("a template may only filter")
+	}
+	if e == nil {
+		return nil, fmt.Errorf("empty template")
+	}
+	_, seen, err := substituteAliasValue(e, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	if want := strings.Count(dsl, aliasValuePlaceholder); seen != want {
+		return nil, fmt.Errorf("%s must stand alone as a value", aliasValuePlaceholder)
+	}
+	return e, nil
+}
+
+// Names returns the registered public names, sorted.
+func (r *AliasRegistry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, 0, len(r.aliases))
+	for name := range r.aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// aliasLookup resolves names for one Build: each public name is matched as
+// written and as the NamingFunc converts it, since the parsed DSL carries
+// converted names.
+type aliasLookup struct {
+	byName map[string]compiledAlias
+	store  string
+}
+
+func (r *AliasRegistry) lookup(naming NamingFunc, store string) *aliasLookup {
+	l := &aliasLookup{byName: make(map[string]compiledAlias, len(r.aliases)*2), store: store}
+	for name, a := range r.aliases {
+		l.byName[name] = a
+	}
+	if naming != nil {
+		for name, a := range r.aliases {
+			if c := normalizeFieldName(name, naming); c != name {
+				if _, taken := l.byName[c]; !taken {
+					l.byName[c] = a
+				}
+			}
+		}
+	}
+	return l
+}
+
+// physical returns the name a physical alias stands for in this store.
+func (l *aliasLookup) physical(a compiledAlias) string {
+	if f, ok := a.stores[l.store]; ok {
+		return f
+	}
+	return a.field
+}
+
+// rewrite returns e with every aliased field replaced, prefix qualifying
+// names under a relation ("Orders.").
+func (l *aliasLookup) rewrite(e Expr, prefix string) (Expr, error) {
+	operands := func(ops []Expr) ([]Expr, error) {
+		out := make([]Expr, len(ops))
+		for i, o := range ops {
+			r, err := l.rewrite(o, prefix)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+	var err error
+	switch v := e.(type) {
+	case nil:
+		return nil, nil
+	case AndExpr:
+		v.Operands, err = operands(v.Operands)
+		return v, err
+	case OrExpr:
+		v.Operands, err = operands(v.Operands)
+		return v, err
+	case NotExpr:
+		v.Operands, err = operands(v.Operands)
+		return v, err
+	case RelationExpr:
+		v.Cond, err = l.rewrite(v.Cond, prefix+v.Relation+".")
+		return v, err
+	}
+
+	field := exprField(e)
+	if field == "" {
+		return e, nil
+	}
+	a, ok := l.byName[prefix+field]
+	if !ok {
+		return e, nil
+	}
+	name := prefix + field
+	switch {
+	case a.expand != nil:
+		out, err := a.expand(e)
+		if err != nil {
+			return nil, fmt.Errorf("alias %q: %w", name, err)
+		}
+		if out == nil {
+			return nil, fmt.Errorf("alias %q: expanded to nothing", name)
+		}
+		return out, nil
+	case a.templates != nil:
+		op, _ := ExprOperator(e)
+		t, ok := a.templates[op]
+		if !ok {
+			return nil, fmt.Errorf("alias %q: virtual field does not support %s", name, op)
+		}
+		value, has := aliasLeafValue(e)
+		out, seen, err := substituteAliasValue(CloneExpr(t), value, has)
+		if err == nil && !has && seen > 0 {
+			err = fmt.Errorf("%s has no value for %s", op, aliasValuePlaceholder)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("alias %q: %w", name, err)
+		}
+		return out, nil
+	}
+	target := l.physical(a)
+	if target == "" {
+		return nil, fmt.Errorf("alias %q: no field for store %q", name, l.store)
+	}
+	return withNodeField(e, target), nil
+}
+
+// rewriteSort renames aliased sort columns. A virtual field has no column to
+// sort on: it is dropped and reported.
+func (l *aliasLookup) rewriteSort(o *OrderBy) (*OrderBy, []string) {
+	var dropped []string
+	out := &OrderBy{}
+	for _, c := range o.Columns {
+		a, ok := l.byName[c.Name]
+		if !ok {
+			out.Columns = append(out.Columns, c)
+			continue
+		}
+		target := l.physical(a)
+		if a.expand != nil || a.templates != nil || target == "" {
+			dropped = append(dropped, c.Name)
+			continue
+		}
+		c.Name = target
+		out.Columns = append(out.Columns, c)
+	}
+	if len(out.Columns) == 0 {
+		return nil, dropped
+	}
+	return out, dropped
+}
+
+// withNodeField returns a copy of the leaf e with its field set to field.
+func withNodeField(e Expr, field string) Expr {
+	p := reflect.New(reflect.TypeOf(e))
+	p.Elem().Set(reflect.ValueOf(e))
+	if !SetNodeField(p.Interface().(Expr), field) {
+		return e
+	}
+	return p.Elem().Interface().(Expr)
+}
+
+// aliasLeafValue returns the value a caller's predicate carries: a scalar, or
+// the []any list of an <in>/<nin>. has is false for predicates without one
+// (<null>, <bet>, ...).
+func aliasLeafValue(e Expr) (value any, has bool) {
+	switch v := e.(type) {
+	case EqExpr:
+		return v.Value, true
+	case NeqExpr:
+		return v.Value, true
+	case GtExpr:
+		return v.Value, true
+	case GteExpr:
+		return v.Value, true
+	case LtExpr:
+		return v.Value, true
+	case LteExpr:
+		return v.Value, true
+	case LikeExpr:
+		return v.Value, true
+	case ILikeExpr:
+		return v.Value, true
+	case RegexExpr:
+		return v.Value, true
+	case InExpr:
+		return v.Values, true
+	case NotInExpr:
+		return v.Values, true
+	}
+	return nil, false
+}
+
+// substituteAliasValue replaces each ${value} in a template with value and
+// counts them. With has false the placeholders are only counted: that is how
+// templates are checked at compile time, and at expansion it means the
+// caller's predicate has no value to put there.
+func substituteAliasValue(e Expr, value any, has bool) (Expr, int, error) {
+	seen := 0
+	isPlaceholder := func(v any) bool {
+		s, ok := v.(string)
+		return ok && s == aliasValuePlaceholder
+	}
+	values, isList := value.([]any)
+	scalar := func(v any) (any, error) {
+		if !isPlaceholder(v) {
+			return v, nil
+		}
+		seen++
+		switch {
+		case !has:
+			return v, nil
+		case isList:
+			return nil, fmt.Errorf("an <in>/<nin> list can only fill a list")
+		}
+		return value, nil
+	}
+	element := func(v any) ([]any, error) {
+		if !isPlaceholder(v) {
+			return []any{v}, nil
+		}
+		seen++
+		switch {
+		case !has:
+			return []any{v}, nil
+		case isList:
+			return values, nil
+		}
+		return []any{value}, nil
+	}
+
+	out, err := substituteValues(e, scalar, element)
+	return out, seen, err
+}
+
+// substituteValues returns e with the values its leaves carry passed through
+// scalar — a comparison's value, a <bet> bound, a q= search — or, for each
+// element of an <in>/<nin> list, through element, whose result replaces the
+// element. Logical nodes and relation predicates are descended; nothing else
+// is changed.
+func substituteValues(e Expr, scalar func(v any) (any, error), element func(v any) ([]any, error)) (Expr, error) {
+	list := func(vs []any) ([]any, error) {
+		out := make([]any, 0, len(vs))
+		for _, v := range vs {
+			r, err := element(v)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, r...)
+		}
+		return out, nil
+	}
+	var walk func(e Expr) (Expr, error)
+	operands := func(ops []Expr) ([]Expr, error) {
+		out := make([]Expr, len(ops))
+		for i, o := range ops {
+			r, err := walk(o)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+	walk = func(e Expr) (Expr, error) {
+		var err error
+		switch v := e.(type) {
+		case AndExpr:
+			v.Operands, err = operands(v.Operands)
+			return v, err
+		case OrExpr:
+			v.Operands, err = operands(v.Operands)
+			return v, err
+		case NotExpr:
+			v.Operands, err = operands(v.Operands)
+			return v, err
+		case RelationExpr:
+			v.Cond, err = walk(v.Cond)
+			return v, err
+		case EqExpr:
+			v.Value, err = scalar(v.Value)
+			return v, err
+		case NeqExpr:
+			v.Value, err = scalar(v.Value)
+			return v, err
+		case GtExpr:
+			v.Value, err = scalar(v.Value)
+			return v, err
+		case GteExpr:
+			v.Value, err = scalar(v.Value)
+			return v, err
+		case LtExpr:
+			v.Value, err = scalar(v.Value)
+			return v, err
+		case LteExpr:
+			v.Value, err = scalar(v.Value)
+			return v, err
+		case LikeExpr:
+			v.Value, err = scalar(v.Value)
+			return v, err
+		case ILikeExpr:
+			v.Value, err = scalar(v.Value)
+			return v, err
+		case RegexExpr:
+			v.Value, err = scalar(v.Value)
+			return v, err
+		case BetweenExpr:
+			if v.Low, err = scalar(v.Low); err != nil {
+				return v, err
+			}
+			v.High, err = scalar(v.High)
+			return v, err
+		case FullTextSearchExpr:
+			q, err := scalar(v.Query)
+			if err != nil {
+				return v, err
+			}
+			s, ok := q.(string)
+			if !ok {
+				return v, fmt.Errorf("a q= search takes a string, not %T", q)
+			}
+			v.Query = s
+			return v, nil
+		case InExpr:
+			v.Values, err = list(v.Values)
+			return v, err
+		case NotInExpr:
+			v.Values, err = list(v.Values)
+			return v, err
+		}
+		return e, nil
+	}
+	return walk(e)
+}
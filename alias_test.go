@@ -0,0 +1,162 @@
+package figo_test
+
+import (
+	. "github.com/bi0dread/figo/v4"
+	. "github.com/bi0dread/figo/v4/adapters"
+
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var testNow = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ageFromBirthDate turns age>N into birth_date<(now - N years), and so on.
+func ageFromBirthDate(e Expr) (Expr, error) {
+	born := func(v any) (time.Time, error) {
+		n, ok := v.(int64)
+		if !ok {
+			return time.Time{}, fmt.Errorf("age must be a whole number")
+		}
+		return testNow.AddDate(-int(n), 0, 0), nil
+	}
+	switch v := e.(type) {
+	case GtExpr:
+		t, err := born(v.Value)
+		return LtExpr{Field: "birth_date", Value: t}, err
+	case LteExpr:
+		t, err := born(v.Value)
+		return GteExpr{Field: "birth_date", Value: t}, err
+	}
+	op, _ := ExprOperator(e)
+	return nil, fmt.Errorf("age does not support %s", op)
+}
+
+func testAliases(t *testing.T) *AliasRegistry {
+	t.Helper()
+	r, err := NewAliasRegistry(Aliases{
+		"customer":        {Field: "users.full_name", Stores: map[string]string{StoreMongo: "user.fullName"}},
+		"signupDate":      {Field: "created_at"},
+		"vip":             {Templates: map[string]string{"=": `tier<in>["gold","platinum"] and active=${value}`}},
+		"region":          {Templates: map[string]string{"<in>": `country<in>[${value},"XX"]`, "=": `country=${value}`}},
+		"age":             {Expand: ageFromBirthDate},
+		"Orders.customer": {Field: "buyer_name"},
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func TestAliasesRewritePhysicalFields(t *testing.T) {
+	f := New()
+	f.SetAliases(testAliases(t))
+	require.NoError(t, f.AddFiltersFromString(`customer=^"Ann%" and status="open" sort=signupDate:desc`))
+	require.NoError(t, f.BuildE(RawAdapter{}))
+
+	where, args, err := BuildRawWhere(f)
+	require.NoError(t, err)
+	assert.Equal(t, "(`users`.`full_name` LIKE ? AND `status` = ?)", where)
+	assert.Equal(t, []any{"Ann%", "open"}, args)
+	assert.Equal(t, []OrderByColumn{{Name: "created_at", Desc: true}}, f.GetSort().Columns)
+
+	// The same DSL against Mongo uses the store's path.
+	require.NoError(t, f.BuildE(MongoAdapter{}))
+	filter, err := BuildMongoFilter(f)
+	require.NoError(t, err)
+	and := filter["$and"].([]bson.M)
+	require.Len(t, and, 2)
+	assert.Contains(t, and[0], "user.fullName")
+	assert.Equal(t, bson.M{"status": "open"}, and[1])
+}
+
+func TestAliasesExpandVirtualFields(t *testing.T) {
+	for dsl, want := range map[string]struct {
+		where string
+		args  []any
+	}{
+		`vip=true`:                 {"(`tier` IN (?,?) AND `active` = ?)", []any{"gold", "platinum", true}},
+		`region<in>["DE","FR"]`:    {"`country` IN (?,?,?)", []any{"DE", "FR", "XX"}},
+		`region="DE" or vip=false`: {"(`country` = ? OR (`tier` IN (?,?) AND `active` = ?))", []any{"DE", "gold", "platinum", false}},
+		`age>30`:                   {"`birth_date` < ?", []any{testNow.AddDate(-30, 0, 0)}},
+	} {
+		f := New()
+		f.SetAliases(testAliases(t))
+		require.NoError(t, f.AddFiltersFromString(dsl), dsl)
+		require.NoError(t, f.BuildE(RawAdapter{}), dsl)
+		where, args, err := BuildRawWhere(f)
+		require.NoError(t, err, dsl)
+		assert.Equal(t, want.where, where, dsl)
+		assert.Equal(t, want.args, args, dsl)
+	}
+}
+
+func TestAliasesPreloadsAreQualified(t *testing.T) {
+	f := New()
+	f.SetAliases(testAliases(t))
+	require.NoError(t, f.AddFiltersFromString(`customer="a" load=[Orders:customer="b"]`))
+	require.NoError(t, f.BuildE(RawAdapter{}))
+	assert.Equal(t, []Expr{EqExpr{Field: "users.full_name", Value: "a"}}, f.GetClauses())
+	assert.Equal(t, []Expr{EqExpr{Field: "buyer_name", Value: "b"}}, f.GetPreloads()["Orders"])
+
+	// So are relation predicates: Orders.customer, not the parent's customer.
+	require.NoError(t, f.AddFiltersFromString(`Orders<any>[customer="b"]`))
+	require.NoError(t, f.BuildE(nil))
+	assert.Equal(t, []Expr{RelationExpr{Relation: "Orders", Quantifier: QuantifierAny, Cond: EqExpr{Field: "buyer_name", Value: "b"}}}, f.GetClauses())
+}
+
+func TestAliasesFailClosed(t *testing.T) {
+	for dsl, want := range map[string]string{
+		`id=1 and vip!=true`:       `alias "vip": virtual field does not support !=`,
+		`not(age<5)`:               `alias "age": age does not support <`,
+		`region<nin>["DE"]`:        `alias "region": virtual field does not support <nin>`,
+		`age>"thirty" or id=1`:     `alias "age": age must be a whole number; the query matches nothing`,
+		`id=1 sort=vip:asc,id:asc`: `alias "vip": a virtual field cannot be sorted on`,
+	} {
+		f := New()
+		f.SetAliases(testAliases(t))
+		require.NoError(t, f.AddFiltersFromString(dsl), dsl)
+		err := f.BuildE(RawAdapter{})
+		require.Error(t, err, dsl)
+		assert.Contains(t, err.Error(), want, dsl)
+	}
+
+	f := New()
+	f.SetAliases(testAliases(t))
+	require.NoError(t, f.AddFiltersFromString(`not(vip!=true)`))
+	assert.Error(t, f.BuildE(RawAdapter{}))
+	where, _, _ := BuildRawWhere(f)
+	assert.Equal(t, "1=0", where, "an unresolvable alias under not() must not widen the query")
+
+	// A virtual sort key is dropped, the rest of the sort kept.
+	require.NoError(t, f.AddFiltersFromString(`id=1 sort=vip:asc,id:asc`))
+	assert.Error(t, f.BuildE(RawAdapter{}))
+	assert.Equal(t, []OrderByColumn{{Name: "id"}}, f.GetSort().Columns)
+}
+
+func TestNewAliasRegistryValidates(t *testing.T) {
+	for name, tc := range map[string]struct {
+		alias Alias
+		want  string
+	}{
+		"none":        {Alias{}, `alias "x": set exactly one of Field/Stores, Templates or Expand`},
+		"two":         {Alias{Field: "a", Templates: map[string]string{"=": "a=1"}}, "set exactly one"},
+		"store":       {Alias{Stores: map[string]string{StoreSQL: ""}}, `alias "x": empty field for store "sql"`},
+		"operator":    {Alias{Templates: map[string]string{"~": "a=1"}}, `alias "x": unknown operator "~"`},
+		"directive":   {Alias{Templates: map[string]string{"=": "a=${value} sort=a:asc"}}, "a template may only filter"},
+		"embedded":    {Alias{Templates: map[string]string{"=": `a="x${value}"`}}, "${value} must stand alone as a value"},
+		"unparseable": {Alias{Templates: map[string]string{"=": "a=1 and"}}, `alias "x": template for =:`},
+	} {
+		_, err := NewAliasRegistry(Aliases{"x": tc.alias})
+		require.Error(t, err, name)
+		assert.Contains(t, err.Error(), tc.want, name)
+	}
+
+	r := testAliases(t)
+	assert.Equal(t, []string{"Orders.customer", "age", "customer", "region", "signupDate", "vip"}, r.Names())
+	f := New()
+	f.SetAliases(r)
+	assert.Same(t, r, f.Clone().GetAliases())
+}
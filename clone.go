@@ -5,9 +5,10 @@ import "reflect"
 // Clone returns a deep copy of the Figo instance.
 //
 // The query-building state is fully independent: filters (clauses), preloads,
-// pagination, sort, the select-field set, the DSL string and naming strategy
-// are all copied, so mutating the clone (AddFilter, SetPage, AddSelectFields,
-// …) never affects the original and vice versa.
+// pagination, sort, the select-field set, the DSL string, naming strategy and
+// alias registry, a bound Prepared template, and the clock and time zone are
+// all copied, so mutating the clone (AddFilter, SetPage, AddSelectFields, …)
+// never affects the original and vice versa.
 //
 // Independence extends into a node's dynamic value: the containers figo can
 // carry behind an `any` (slices, maps and []byte, nested) are copied too, so
@@ -35,6 +36,10 @@ func (f *figo) Clone() Figo {
 		sortFromDSL:  f.sortFromDSL,
 		builtFromDSL: f.builtFromDSL,
 		namingFunc:   f.namingFunc, // shared transformer; assumed pure
+		aliases:      f.aliases,    // immutable once built
+		bound:        f.bound,      // never mutated; BindTo replaces it
+		clock:        f.clock,
+		timeZone:     f.timeZone,
 
 		// Deep-copied reference-typed state.
 		clauses:           cloneExprs(f.clauses),
@@ -116,6 +121,11 @@ func cloneExprAt(e Expr, ptrBudget int) Expr {
 		return OrExpr{Operands: cloneExprsAt(v.Operands, ptrBudget)}
 	case NotExpr:
 		return NotExpr{Operands: cloneExprsAt(v.Operands, ptrBudget)}
+	case RelationExpr:
+		if v.Cond != nil {
+			v.Cond = cloneExprAt(v.Cond, ptrBudget)
+		}
+		return v
 
 	// Nodes carrying value slices: copy the slice AND its elements (an element
 	// may itself be a slice or map).
@@ -0,0 +1,84 @@
+package figo_test
+
+import (
+	. "github.com/bi0dread/figo/v4"
+
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey struct{}
+
+// ctxPlugin appends ctx's value as a clause, and implements the plain
+// finalizer too, to show the context hook runs instead of it.
+type ctxPlugin struct {
+	plainCalls int
+	seen       []context.Context
+}
+
+func (p *ctxPlugin) Name() string                                   { return "ctx" }
+func (p *ctxPlugin) Version() string                                { return "1" }
+func (p *ctxPlugin) Initialize(Figo) error                          { return nil }
+func (p *ctxPlugin) BeforeQuery(Figo, any) error                    { return nil }
+func (p *ctxPlugin) AfterQuery(Figo, any, any) error                { return nil }
+func (p *ctxPlugin) BeforeParse(_ Figo, dsl string) (string, error) { return dsl, nil }
+func (p *ctxPlugin) AfterParse(Figo, string) error                  { return nil }
+
+func (p *ctxPlugin) FinalizeClauses(_ Figo, clauses []Expr) []Expr {
+	p.plainCalls++
+	return clauses
+}
+
+func (p *ctxPlugin) FinalizeClausesContext(ctx context.Context, _ Figo, clauses []Expr) ([]Expr, error) {
+	p.seen = append(p.seen, ctx)
+	v, ok := ctx.Value(ctxKey{}).(string)
+	if !ok {
+		return nil, errors.New("no value")
+	}
+	return append(clauses, EqExpr{Field: "k", Value: v}), nil
+}
+
+func (p *ctxPlugin) FinalizePreloadsContext(ctx context.Context, _ Figo, _ string, conds []Expr) ([]Expr, error) {
+	if ctx.Value(ctxKey{}) == nil {
+		return nil, errors.New("no value")
+	}
+	return conds, nil
+}
+
+func TestBuildContextPassesTheContextToContextPlugins(t *testing.T) {
+	p := &ctxPlugin{}
+	f := New()
+	require.NoError(t, f.RegisterPlugin(p))
+	require.NoError(t, f.AddFiltersFromString(`a=1 load=[Orders:b=2]`))
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
+	require.NoError(t, f.BuildContext(ctx, nil))
+	assert.Equal(t, []Expr{EqExpr{Field: "a", Value: int64(1)}, EqExpr{Field: "k", Value: "v"}}, f.GetClauses())
+	assert.Equal(t, []Expr{EqExpr{Field: "b", Value: int64(2)}}, f.GetPreloads()["Orders"])
+	assert.Equal(t, 0, p.plainCalls, "the context hook replaces the plain one")
+	require.Len(t, p.seen, 1)
+	assert.Same(t, ctx, p.seen[0])
+
+	// BuildE passes context.Background(): the hook fails, and the build fails
+	// closed with the error reported next to the diagnostics.
+	err := f.BuildE(nil)
+	require.Error(t, err)
+	assert.Equal(t, "plugin ctx FinalizePreloadsContext error (relation \"Orders\"): no value\nplugin ctx FinalizeClausesContext error: no value", err.Error())
+	assert.Equal(t, []Expr{OrExpr{}}, f.GetClauses())
+	assert.Equal(t, []Expr{OrExpr{}}, f.GetPreloads()["Orders"])
+
+	// An empty DSL takes the same path.
+	g := New()
+	require.NoError(t, g.RegisterPlugin(p))
+	assert.Error(t, g.BuildContext(context.Background(), nil))
+	assert.Equal(t, []Expr{OrExpr{}}, g.GetClauses())
+	require.NoError(t, g.BuildContext(ctx, nil))
+	assert.Equal(t, []Expr{EqExpr{Field: "k", Value: "v"}}, g.GetClauses())
+
+	// The manager's plain entry point uses context.Background() too.
+	assert.Equal(t, []Expr{OrExpr{}}, g.GetPluginManager().ExecuteClauseFinalizers(g, nil))
+}
@@ -0,0 +1,268 @@
+package figo
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// "The last 7 days" cannot be written as a date literal: the date moves. An
+// unquoted date-math literal can — `created_at>=now-7d` — in the
+// Elasticsearch syntax: an anchor, then any number of `+N<unit>`/`-N<unit>`
+// steps and `/<unit>` roundings (down to the start of the unit), applied left
+// to right.
+//
+//	now               the current instant
+//	now-7d            seven days ago
+//	now/d             midnight today
+//	now-1M/M          the first of last month
+//	startOfMonth      the first of this month (also startOfDay, startOfWeek, startOfYear)
+//
+// Units are y (year), M (month), w (week), d (day), h or H (hour),
+// m (minute) and s (second); weeks start on Monday. A literal is a date-math
+// literal only when it parses as one, so `status=nowhere` is still a string;
+// quote `"now"` to mean the word.
+//
+// Each Build resolves the literals against the instance's clock and time zone
+// (SetClock, SetTimeZone; time.Now and UTC by default): rounding to a day
+// means the day in that zone. For SQL, MongoDB and in-memory stores the value
+// becomes the time.Time it resolved to. Elasticsearch gets a DateMath, which
+// its adapter renders as native date math in a range query — `"gte":
+// "now-7d"` with the zone as time_zone — so the cluster evaluates it; in any
+// other position, and as a rounded lte or gt bound (which the cluster would
+// round up), it renders the resolved instant.
+
+// DateMath is a date-math literal as an Elasticsearch build leaves it in the
+// tree: Expr in Elasticsearch syntax, anchored at now ("now/M-1M"), and the
+// instant it resolved to at Build. It binds and marshals as that instant, so
+// code unaware of it sees a time.Time.
+type DateMath struct {
+	Expr string
+	Time time.Time
+}
+
+// String returns the Elasticsearch date-math expression.
+func (d DateMath) String() string { return d.Expr }
+
+// Value implements driver.Valuer: a DateMath binds as its instant.
+func (d DateMath) Value() (driver.Value, error) { return d.Time, nil }
+
+// MarshalJSON marshals the instant, as a time.Time would be.
+func (d DateMath) MarshalJSON() ([]byte, error) { return json.Marshal(d.Time) }
+
+// TimeZone returns the zone Expr's roundings are meant in, as Elasticsearch's
+// time_zone spells it: UTC or an IANA name ("Europe/Berlin"), which keep
+// daylight saving, and otherwise the offset in force at Time — a fixed zone's
+// name or "Local" means nothing to the cluster.
+func (d DateMath) TimeZone() string {
+	if name := d.Time.Location().String(); name == "UTC" || strings.Contains(name, "/") {
+		return name
+	}
+	return d.Time.Format("-07:00")
+}
+
+// dateMathAnchors are the named anchors and the now-relative expression each
+// stands for.
+var dateMathAnchors = map[string]string{
+	"now":          "now",
+	"startOfDay":   "now/d",
+	"startOfWeek":  "now/w",
+	"startOfMonth": "now/M",
+	"startOfYear":  "now/y",
+}
+
+// dateMathStep is one +N<unit>, -N<unit> or /<unit> of a literal.
+type dateMathStep struct {
+	op   byte // '+', '-' or '/'
+	n    int
+	unit byte
+}
+
+// parseDateMath parses a date-math literal into its steps (the anchor's
+// rounding included) and its normalized Elasticsearch expression.
+func parseDateMath(s string) ([]dateMathStep, string, bool) {
+	anchor := s
+	if i := strings.IndexAny(s, "+-/"); i >= 0 {
+		anchor = s[:i]
+	}
+	expanded, ok := dateMathAnchors[anchor]
+	if !ok {
+		return nil, "", false
+	}
+	rest := expanded[len("now"):] + s[len(anchor):]
+	var steps []dateMathStep
+	for i := 0; i < len(rest); {
+		step := dateMathStep{op: rest[i]}
+		i++
+		if step.op != '/' {
+			j := i
+			for j < len(rest) && rest[j] >= '0' && rest[j] <= '9' {
+				j++
+			}
+			n, err := strconv.Atoi(rest[i:j])
+			if err != nil || n > 100000 {
+				return nil, "", false
+			}
+			step.n, i = n, j
+		}
+		if i >= len(rest) || !strings.ContainsRune("yMwdhHms", rune(rest[i])) {
+			return nil, "", false
+		}
+		step.unit = rest[i]
+		i++
+		steps = append(steps, step)
+	}
+	return steps, "now" + rest, true
+}
+
+// isDateMath reports whether an unquoted literal is a date-math literal.
+func isDateMath(s string) bool {
+	_, _, ok := parseDateMath(s)
+	return ok
+}
+
+// resolveDateMath evaluates a date-math literal at now, rounding in now's
+// location.
+func resolveDateMath(s string, now time.Time) (time.Time, error) {
+	steps, _, ok := parseDateMath(s)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid date-math literal %q", s)
+	}
+	t := now
+	for _, st := range steps {
+		n := st.n
+		if st.op == '-' {
+			n = -n
+		}
+		switch st.op {
+		case '+', '-':
+			switch st.unit {
+			case 'y':
+				t = addMonths(t, 12*n)
+			case 'M':
+				t = addMonths(t, n)
+			case 'w':
+				t = t.AddDate(0, 0, 7*n)
+			case 'd':
+				t = t.AddDate(0, 0, n)
+			case 'h', 'H':
+				t = t.Add(time.Duration(n) * time.Hour)
+			case 'm':
+				t = t.Add(time.Duration(n) * time.Minute)
+			case 's':
+				t = t.Add(time.Duration(n) * time.Second)
+			}
+		case '/':
+			t = roundDateDown(t, st.unit)
+		}
+	}
+	return t, nil
+}
+
+// addMonths moves t by n calendar months, clamping the day to the end of a
+// shorter target month as Elasticsearch does: a month before 31 March is 28
+// (or 29) February, not 3 March, which time.AddDate would give.
+func addMonths(t time.Time, n int) time.Time {
+	y, mo, d := t.Date()
+	first := time.Date(y, mo+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+// roundDateDown returns the start of the unit t falls in, in t's location.
+func roundDateDown(t time.Time, unit byte) time.Time {
+	y, mo, d := t.Date()
+	loc := t.Location()
+	switch unit {
+	case 'y':
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, loc)
+	case 'M':
+		return time.Date(y, mo, 1, 0, 0, 0, 0, loc)
+	case 'w':
+		back := (int(t.Weekday()) + 6) % 7 // days since Monday
+		return time.Date(y, mo, d-back, 0, 0, 0, 0, loc)
+	case 'd':
+		return time.Date(y, mo, d, 0, 0, 0, 0, loc)
+	case 'h', 'H':
+		return time.Date(y, mo, d, t.Hour(), 0, 0, 0, loc)
+	case 'm':
+		return time.Date(y, mo, d, t.Hour(), t.Minute(), 0, 0, loc)
+	}
+	return time.Date(y, mo, d, t.Hour(), t.Minute(), t.Second(), 0, loc)
+}
+
+// dateMathLiteral is what the parser leaves for an unquoted date-math
+// literal; Build resolves it (resolveDateMathValues) before anything else
+// sees the tree.
+type dateMathLiteral string
+
+// resolveDateMathValues replaces the date-math literals in e with what they
+// resolve to at now: a DateMath for Elasticsearch, the time.Time otherwise.
+func resolveDateMathValues(e Expr, now time.Time, store string) Expr {
+	resolve := func(v any) any {
+		lit, ok := v.(dateMathLiteral)
+		if !ok {
+			return v
+		}
+		t, err := resolveDateMath(string(lit), now)
+		if err != nil {
+			return v // unreachable: the parser only makes literals that parse
+		}
+		if store == StoreElasticsearch {
+			_, expr, _ := parseDateMath(string(lit))
+			return DateMath{Expr: expr, Time: t}
+		}
+		return t
+	}
+	out, _ := substituteValues(e,
+		func(v any) (any, error) { return resolve(v), nil },
+		func(v any) ([]any, error) { return []any{resolve(v)}, nil })
+	return out
+}
+
+// SetClock sets the clock date-math literals are resolved against (nil
+// restores time.Now). Tests pin it for deterministic queries.
+func (f *figo) SetClock(clock func() time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clock = clock
+}
+
+// SetTimeZone sets the zone date-math literals round in (nil restores UTC).
+func (f *figo) SetTimeZone(loc *time.Location) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.timeZone = loc
+}
+
+// GetTimeZone returns the zone date-math literals round in.
+func (f *figo) GetTimeZone() *time.Location {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.zone()
+}
+
+// Now returns the instance clock's current time in its time zone: the now
+// date-math literals are resolved against.
+func (f *figo) Now() time.Time {
+	f.mu.RLock()
+	clock, loc := f.clock, f.zone()
+	f.mu.RUnlock()
+	if clock == nil {
+		clock = time.Now
+	}
+	return clock().In(loc)
+}
+
+// zone returns the configured time zone or UTC. f.mu must be held.
+func (f *figo) zone() *time.Location {
+	if f.timeZone == nil {
+		return time.UTC
+	}
+	return f.timeZone
+}
@@ -0,0 +1,208 @@
+package figo_test
+
+import (
+	. "github.com/bi0dread/figo/v4"
+	. "github.com/bi0dread/figo/v4/adapters"
+
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Sunday 18 October 2026, 15:30 UTC: 17:30 in the +02:00 zone below.
+var dateMathNow = time.Date(2026, 10, 18, 15, 30, 0, 0, time.UTC)
+
+var plusTwo = time.FixedZone("CEST", 2*3600)
+
+func dateMathFigo(t *testing.T, dsl string, adapter Adapter) Figo {
+	t.Helper()
+	f := New()
+	f.SetClock(func() time.Time { return dateMathNow })
+	f.SetTimeZone(plusTwo)
+	require.NoError(t, f.AddFiltersFromString(dsl), dsl)
+	require.NoError(t, f.BuildE(adapter), dsl)
+	return f
+}
+
+func TestDateMathResolvesAgainstInstanceClock(t *testing.T) {
+	at := func(y int, mo time.Month, d, h, m int) time.Time { return time.Date(y, mo, d, h, m, 0, 0, plusTwo) }
+	for lit, want := range map[string]time.Time{
+		`now`:            at(2026, 10, 18, 17, 30),
+		`now-7d`:         at(2026, 10, 11, 17, 30),
+		`now+1h`:         at(2026, 10, 18, 18, 30),
+		`now-90m`:        at(2026, 10, 18, 16, 0),
+		`now/d`:          at(2026, 10, 18, 0, 0),
+		`now-1M/M`:       at(2026, 9, 1, 0, 0),
+		`now/w`:          at(2026, 10, 12, 0, 0),
+		`now/y+1y-1s`:    at(2026, 12, 31, 23, 59).Add(59 * time.Second),
+		`startOfDay`:     at(2026, 10, 18, 0, 0),
+		`startOfWeek`:    at(2026, 10, 12, 0, 0),
+		`startOfMonth`:   at(2026, 10, 1, 0, 0),
+		`startOfYear-1y`: at(2025, 1, 1, 0, 0),
+	} {
+		f := dateMathFigo(t, `created_at>=`+lit, RawAdapter{})
+		require.Len(t, f.GetClauses(), 1, lit)
+		got, ok := f.GetClauses()[0].(GteExpr).Value.(time.Time)
+		require.True(t, ok, lit)
+		assert.True(t, want.Equal(got), "%s: want %v, got %v", lit, want, got)
+	}
+
+	// Lists, <bet> bounds and preload conditions resolve too, against one now.
+	f := dateMathFigo(t, `day<in>[now/d,now/d-1d] and at<bet>(now-1h..now) load=[Orders:placed_at>now-30d]`, RawAdapter{})
+	_, args, err := BuildRawWhere(f)
+	require.NoError(t, err)
+	assert.Equal(t, []any{at(2026, 10, 18, 0, 0), at(2026, 10, 17, 0, 0), at(2026, 10, 18, 16, 30), at(2026, 10, 18, 17, 30)}, args)
+	assert.Equal(t, []Expr{GtExpr{Field: "placed_at", Value: at(2026, 9, 18, 17, 30)}}, f.GetPreloads()["Orders"])
+}
+
+func TestDateMathClampsToMonthEnd(t *testing.T) {
+	for _, tc := range []struct {
+		now  time.Time
+		lit  string
+		want time.Time
+	}{
+		{time.Date(2025, 3, 31, 9, 0, 0, 0, time.UTC), `now-1M`, time.Date(2025, 2, 28, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC), `now-1y`, time.Date(2023, 2, 28, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC), `now+1M`, time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 3, 31, 9, 0, 0, 0, time.UTC), `now-1M+1d`, time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)},
+	} {
+		f := New()
+		f.SetClock(func() time.Time { return tc.now })
+		require.NoError(t, f.AddFiltersFromString(`created_at>=`+tc.lit))
+		require.NoError(t, f.BuildE(RawAdapter{}))
+		assert.Equal(t, tc.want, f.GetClauses()[0].(GteExpr).Value, "%s at %v", tc.lit, tc.now)
+	}
+}
+
+func TestDateMathInAliasTemplates(t *testing.T) {
+	r, err := NewAliasRegistry(Aliases{"recent": {Templates: map[string]string{"=": `created_at>=now-7d and active=${value}`}}})
+	require.NoError(t, err)
+
+	// The template keeps its literal; each Build resolves it against that
+	// instance's clock.
+	f := New()
+	f.SetAliases(r)
+	f.SetClock(func() time.Time { return time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC) })
+	require.NoError(t, f.AddFiltersFromString(`recent=true`))
+	require.NoError(t, f.BuildE(RawAdapter{}))
+	_, args, err := BuildRawWhere(f)
+	require.NoError(t, err)
+	assert.Equal(t, []any{time.Date(2020, 1, 3, 12, 0, 0, 0, time.UTC), true}, args)
+
+	// Elasticsearch still gets native date math.
+	f = dateMathFigo(t, ``, ElasticsearchAdapter{})
+	f.SetAliases(r)
+	require.NoError(t, f.AddFiltersFromString(`recent=true`))
+	require.NoError(t, f.BuildE(nil))
+	q, err := BuildElasticsearchQuery(f)
+	require.NoError(t, err)
+	must := q.Query["bool"].(map[string]interface{})["must"].([]map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"range": map[string]interface{}{"created_at": map[string]interface{}{"gte": "now-7d", "time_zone": "+02:00"}}}, must[0])
+}
+
+func TestDateMathLeavesOtherLiteralsAlone(t *testing.T) {
+	f := dateMathFigo(t, `a=nowhere and b="now" and c=now-7x and d=startOfMonth7`, RawAdapter{})
+	_, args, err := BuildRawWhere(f)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"nowhere", "now", "now-7x", "startOfMonth7"}, args)
+}
+
+func TestDateMathRebuildUsesCurrentTime(t *testing.T) {
+	now := dateMathNow
+	f := New()
+	f.SetClock(func() time.Time { return now })
+	require.NoError(t, f.AddFiltersFromString(`created_at>=now-1d`))
+	require.NoError(t, f.BuildE(RawAdapter{}))
+	assert.Equal(t, now.AddDate(0, 0, -1), f.GetClauses()[0].(GteExpr).Value)
+	assert.Equal(t, time.UTC, f.GetTimeZone())
+
+	now = now.Add(time.Hour)
+	require.NoError(t, f.BuildE(nil))
+	assert.Equal(t, now.AddDate(0, 0, -1), f.GetClauses()[0].(GteExpr).Value)
+
+	clone := f.Clone()
+	assert.Equal(t, now, clone.Now())
+
+	got, ok := ParseValue("now/d").(time.Time)
+	require.True(t, ok)
+	assert.Equal(t, time.UTC, got.Location())
+	assert.Zero(t, got.Hour())
+}
+
+func TestDateMathPerStore(t *testing.T) {
+	dsl := `created_at>=now-7d/d and at<bet>(now-1h..now) and day=now/d`
+
+	// Mongo binds the instant.
+	f := dateMathFigo(t, dsl, MongoAdapter{})
+	filter, err := BuildMongoFilter(f)
+	require.NoError(t, err)
+	and := filter["$and"].([]bson.M)
+	require.Len(t, and, 3)
+	assert.Equal(t, bson.M{"created_at": bson.M{"$gte": time.Date(2026, 10, 11, 0, 0, 0, 0, plusTwo)}}, and[0])
+
+	// Elasticsearch evaluates range bounds itself, in the instance's zone; an
+	// equality gets the instant.
+	f = dateMathFigo(t, dsl, ElasticsearchAdapter{})
+	q, err := BuildElasticsearchQuery(f)
+	require.NoError(t, err)
+	must := q.Query["bool"].(map[string]interface{})["must"].([]map[string]interface{})
+	require.Len(t, must, 3)
+	assert.Equal(t, map[string]interface{}{"range": map[string]interface{}{"created_at": map[string]interface{}{"gte": "now-7d/d", "time_zone": "+02:00"}}}, must[0])
+	assert.Equal(t, map[string]interface{}{"range": map[string]interface{}{"at": map[string]interface{}{"gte": "now-1h", "lte": "now", "time_zone": "+02:00"}}}, must[1])
+	term := must[2]["term"].(map[string]interface{})["day"].(DateMath)
+	assert.Equal(t, "now/d", term.String())
+	assert.True(t, time.Date(2026, 10, 18, 0, 0, 0, 0, plusTwo).Equal(term.Time))
+	b, err := term.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `"2026-10-18T00:00:00+02:00"`, string(b))
+}
+
+func TestDateMathRoundedBoundsAgree(t *testing.T) {
+	// Elasticsearch rounds an lte or gt bound up, to the end of the unit;
+	// every store must read created_at<=now/d as midnight, as SQL does.
+	midnight := time.Date(2026, 10, 18, 0, 0, 0, 0, plusTwo)
+	type row struct {
+		ID        int
+		CreatedAt time.Time
+	}
+	rows := []row{{1, midnight.Add(-time.Second)}, {2, midnight}, {3, midnight.Add(time.Hour)}}
+	for _, tc := range []struct {
+		dsl, op, mongoOp string
+		ids              []int
+	}{
+		{`created_at<=now/d`, "lte", "$lte", []int{1, 2}},
+		{`created_at>now/d`, "gt", "$gt", []int{3}},
+	} {
+		where, args, err := BuildRawWhere(dateMathFigo(t, tc.dsl, RawAdapter{}))
+		require.NoError(t, err)
+		assert.Contains(t, where, "created_at", tc.dsl)
+		require.Len(t, args, 1, tc.dsl)
+		assert.True(t, midnight.Equal(args[0].(time.Time)), tc.dsl)
+
+		filter, err := BuildMongoFilter(dateMathFigo(t, tc.dsl, MongoAdapter{}))
+		require.NoError(t, err)
+		assert.Equal(t, bson.M{"created_at": bson.M{tc.mongoOp: midnight}}, filter, tc.dsl)
+
+		got, err := ApplySlice(dateMathFigo(t, tc.dsl, SliceAdapter{}), rows)
+		require.NoError(t, err)
+		var ids []int
+		for _, r := range got {
+			ids = append(ids, r.ID)
+		}
+		assert.Equal(t, tc.ids, ids, tc.dsl)
+
+		q, err := BuildElasticsearchQuery(dateMathFigo(t, tc.dsl, ElasticsearchAdapter{}))
+		require.NoError(t, err)
+		bound := q.Query["range"].(map[string]interface{})["created_at"].(map[string]interface{})
+		require.Len(t, bound, 1, tc.dsl)
+		assert.True(t, midnight.Equal(bound[tc.op].(time.Time)), tc.dsl)
+	}
+
+	// The bounds Elasticsearch rounds down keep native date math.
+	q, err := BuildElasticsearchQuery(dateMathFigo(t, `created_at<now/d`, ElasticsearchAdapter{}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"created_at": map[string]interface{}{"lt": "now/d", "time_zone": "+02:00"}}, q.Query["range"])
+}
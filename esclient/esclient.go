@@ -0,0 +1,299 @@
+// Package esclient runs the Elasticsearch adapter's queries. The adapter stops
+// at a request body; this package posts it to an index's _search endpoint with
+// nothing but net/http, and decodes the response into typed hits, the total
+// and the raw aggregations:
+//
+//	c := esclient.New("http://localhost:9200")
+//	req, err := esclient.FromFigo("products", f)
+//	if err != nil { ... }
+//	res, err := esclient.Search[Product](ctx, c, req)
+//	// res.Items() []Product, res.Total, res.Aggregations["by_brand"]
+//
+// A response Elasticsearch marks as failed (any status outside 2xx) is an
+// *Error carrying the error type and reason from the body. For tests, the
+// estest subpackage serves canned responses from an httptest server, so no
+// cluster is needed.
+package esclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	figo "github.com/bi0dread/figo/v4"
+	"github.com/bi0dread/figo/v4/adapters"
+)
+
+// Client posts searches to one Elasticsearch endpoint. The zero value is not
+// usable; set URL (or use New). A Client is safe for concurrent use.
+type Client struct {
+	// URL is the cluster's base URL, such as http://localhost:9200.
+	URL string
+	// HTTP sends the requests; nil means http.DefaultClient.
+	HTTP *http.Client
+	// Header is added to every request (Authorization, for one).
+	Header http.Header
+}
+
+// New returns a Client for the cluster at baseURL.
+func New(baseURL string) *Client {
+	return &Client{URL: baseURL}
+}
+
+// Request is one search.
+type Request struct {
+	// Index names the index, alias or comma-separated list to search; empty
+	// searches every index.
+	Index string
+	// Query is the request body the adapter built.
+	Query adapters.ElasticsearchQuery
+	// Aggregations, when set, is sent as the body's "aggs" section. The
+	// response's aggregations come back undecoded in Result.Aggregations.
+	Aggregations map[string]any
+}
+
+// FromFigo builds the search for a built instance with the Elasticsearch
+// adapter. A query the adapter cannot render is an error, never a request.
+func FromFigo(index string, f figo.Figo) (Request, error) {
+	q, err := adapters.BuildElasticsearchQuery(f)
+	if err != nil {
+		return Request{}, err
+	}
+	return Request{Index: index, Query: q}, nil
+}
+
+// Body returns the JSON request body: the query's own encoding, with "aggs"
+// appended when the request has aggregations.
+func (r Request) Body() ([]byte, error) {
+	body, err := json.Marshal(r.Query)
+	if err != nil {
+		return nil, fmt.Errorf("esclient: encoding the query: %w", err)
+	}
+	if len(r.Aggregations) == 0 {
+		return body, nil
+	}
+	aggs, err := json.Marshal(r.Aggregations)
+	if err != nil {
+		return nil, fmt.Errorf("esclient: encoding the aggregations: %w", err)
+	}
+	// Splice rather than re-encode through a map, which would lose the
+	// query's fixed key order. The query always encodes as a non-empty object.
+	out := make([]byte, 0, len(body)+len(aggs)+9)
+	out = append(out, body[:len(body)-1]...)
+	out = append(out, `,"aggs":`...)
+	out = append(out, aggs...)
+	return append(out, '}'), nil
+}
+
+// Hit is one search hit.
+type Hit[T any] struct {
+	Index string `json:"_index"`
+	ID    string `json:"_id"`
+	// Score is nil when Elasticsearch did not score the hit (a sorted search
+	// without track_scores).
+	Score     *float64            `json:"_score"`
+	Source    T                   `json:"_source"`
+	Highlight map[string][]string `json:"highlight,omitempty"`
+	// Sort holds the hit's sort values, the input of a search_after page.
+	Sort []any `json:"sort,omitempty"`
+	// InnerHits holds the matches of each preload, keyed by the preload's
+	// name, as Elasticsearch returns them (hits.hits[]._source per name).
+	InnerHits map[string]json.RawMessage `json:"inner_hits,omitempty"`
+}
+
+// Shards reports how many shards answered.
+type Shards struct {
+	Total      int `json:"total"`
+	Successful int `json:"successful"`
+	Skipped    int `json:"skipped"`
+	Failed     int `json:"failed"`
+}
+
+// Result is a decoded search response.
+type Result[T any] struct {
+	Hits []Hit[T]
+	// Total counts the matching documents. It is a lower bound when
+	// TotalRelation is "gte" (track_total_hits capped the count), and -1 when
+	// the search did not count at all (track_total_hits: false).
+	Total         int64
+	TotalRelation string
+	MaxScore      *float64
+	// Aggregations maps each aggregation name to its undecoded result.
+	Aggregations map[string]json.RawMessage
+	Took         int64
+	// TimedOut and Shards report a partial result: with a timeout set, or a
+	// failed shard, Elasticsearch answers 200 with the hits it has.
+	TimedOut bool
+	Shards   Shards
+}
+
+// Items returns the hits' sources, in hit order.
+func (r Result[T]) Items() []T {
+	items := make([]T, len(r.Hits))
+	for i, h := range r.Hits {
+		items[i] = h.Source
+	}
+	return items
+}
+
+// Error is a search Elasticsearch refused: any response outside 2xx. Type and
+// Reason come from the body's "error" object ("index_not_found_exception",
+// "no such index [x]"); RootCause lists its root causes.
+type Error struct {
+	Status    int
+	Type      string
+	Reason    string
+	RootCause []Cause
+	// Body is the raw response body, truncated to 64 KiB.
+	Body []byte
+}
+
+// Cause is one entry of an error's root_cause list.
+type Cause struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Type != "":
+		return fmt.Sprintf("esclient: elasticsearch returned %d: %s: %s", e.Status, e.Type, e.Reason)
+	case e.Reason != "":
+		return fmt.Sprintf("esclient: elasticsearch returned %d: %s", e.Status, e.Reason)
+	}
+	return fmt.Sprintf("esclient: elasticsearch returned %d", e.Status)
+}
+
+// maxErrorBody caps how much of a failed response is read.
+const maxErrorBody = 64 << 10
+
+// Search posts req to the index's _search endpoint and decodes the response.
+func Search[T any](ctx context.Context, c *Client, req Request) (Result[T], error) {
+	body, err := req.Body()
+	if err != nil {
+		return Result[T]{}, err
+	}
+	endpoint, err := c.searchURL(req.Index)
+	if err != nil {
+		return Result[T]{}, err
+	}
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return Result[T]{}, fmt.Errorf("esclient: %w", err)
+	}
+	for k, vs := range c.Header {
+		for _, v := range vs {
+			hreq.Header.Add(k, v)
+		}
+	}
+	hreq.Header.Set("Content-Type", "application/json")
+	hreq.Header.Set("Accept", "application/json")
+
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(hreq)
+	if err != nil {
+		return Result[T]{}, fmt.Errorf("esclient: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Result[T]{}, decodeError(resp)
+	}
+
+	var raw struct {
+		Took     int64  `json:"took"`
+		TimedOut bool   `json:"timed_out"`
+		Shards   Shards `json:"_shards"`
+		Hits     struct {
+			Total    json.RawMessage `json:"total"`
+			MaxScore *float64        `json:"max_score"`
+			Hits     []Hit[T]        `json:"hits"`
+		} `json:"hits"`
+		Aggregations map[string]json.RawMessage `json:"aggregations"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return Result[T]{}, fmt.Errorf("esclient: decoding the search response: %w", err)
+	}
+	res := Result[T]{
+		Hits:         raw.Hits.Hits,
+		MaxScore:     raw.Hits.MaxScore,
+		Aggregations: raw.Aggregations,
+		Took:         raw.Took,
+		TimedOut:     raw.TimedOut,
+		Shards:       raw.Shards,
+	}
+	if res.Hits == nil {
+		res.Hits = []Hit[T]{}
+	}
+	if res.Total, res.TotalRelation, err = decodeTotal(raw.Hits.Total); err != nil {
+		return Result[T]{}, err
+	}
+	return res, nil
+}
+
+// searchURL joins the base URL, the index and _search.
+func (c *Client) searchURL(index string) (string, error) {
+	base := strings.TrimRight(c.URL, "/")
+	if base == "" {
+		return "", fmt.Errorf("esclient: the client has no URL")
+	}
+	if index == "" {
+		return base + "/_search", nil
+	}
+	if strings.ContainsAny(index, "/?#") {
+		return "", fmt.Errorf("esclient: index %q is not a single path segment", index)
+	}
+	return base + "/" + url.PathEscape(index) + "/_search", nil
+}
+
+// decodeTotal reads hits.total: an object {"value", "relation"} since 7.0, a
+// bare number before (or with rest_total_hits_as_int), absent when not
+// tracked.
+func decodeTotal(raw json.RawMessage) (int64, string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return -1, "", nil
+	}
+	var n int64
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n, "eq", nil
+	}
+	var obj struct {
+		Value    int64  `json:"value"`
+		Relation string `json:"relation"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return 0, "", fmt.Errorf("esclient: decoding hits.total: %w", err)
+	}
+	return obj.Value, obj.Relation, nil
+}
+
+// decodeError builds the *Error for a failed response. The body's "error" is
+// an object on every current version and a plain string on very old ones.
+func decodeError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	e := &Error{Status: resp.StatusCode, Body: body}
+	var env struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &env) != nil || len(env.Error) == 0 {
+		return e
+	}
+	var detail struct {
+		Type      string  `json:"type"`
+		Reason    string  `json:"reason"`
+		RootCause []Cause `json:"root_cause"`
+	}
+	if json.Unmarshal(env.Error, &detail) == nil {
+		e.Type, e.Reason, e.RootCause = detail.Type, detail.Reason, detail.RootCause
+		return e
+	}
+	_ = json.Unmarshal(env.Error, &e.Reason)
+	return e
+}
@@ -0,0 +1,174 @@
+package esclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	figo "github.com/bi0dread/figo/v4"
+	"github.com/bi0dread/figo/v4/adapters"
+	"github.com/bi0dread/figo/v4/esclient/estest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type product struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+func esFigo(t *testing.T, dsl string) figo.Figo {
+	t.Helper()
+	f := figo.New()
+	require.NoError(t, f.AddFiltersFromString(dsl))
+	f.Build(adapters.ElasticsearchAdapter{})
+	return f
+}
+
+func TestSearchPostsTheQueryAndDecodesHits(t *testing.T) {
+	srv := estest.NewServer(estest.Static(
+		product{"a", 1}, product{"b", 2}, product{"c", 3}, product{"d", 4}, product{"e", 5},
+	))
+	defer srv.Close()
+
+	f := esFigo(t, `price>0 sort=price:asc page=skip:2,take:2`)
+	req, err := FromFigo("products", f)
+	require.NoError(t, err)
+	c := New(srv.URL + "/")
+	c.Header = http.Header{"Authorization": {"ApiKey k"}}
+	res, err := Search[product](context.Background(), c, req)
+	require.NoError(t, err)
+
+	assert.Equal(t, []product{{"c", 3}, {"d", 4}}, res.Items())
+	assert.Equal(t, int64(5), res.Total)
+	assert.Equal(t, "eq", res.TotalRelation)
+	assert.Equal(t, "3", res.Hits[0].ID)
+	assert.Equal(t, Shards{Total: 1, Successful: 1}, res.Shards)
+
+	searches := srv.Searches()
+	require.Len(t, searches, 1)
+	assert.Equal(t, "products", searches[0].Index)
+	assert.Equal(t, "ApiKey k", searches[0].Header.Get("Authorization"))
+	assert.Equal(t, "application/json", searches[0].Header.Get("Content-Type"))
+	want, err := json.Marshal(req.Query)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(searches[0].Raw), "the body is the adapter's query")
+}
+
+func TestSearchAggregations(t *testing.T) {
+	resp := estest.Hits()
+	resp["aggregations"] = map[string]any{"by_name": map[string]any{"buckets": []any{map[string]any{"key": "a", "doc_count": 2}}}}
+	srv := estest.NewServer(estest.Respond(http.StatusOK, resp))
+	defer srv.Close()
+
+	req, err := FromFigo("", esFigo(t, `price>1`))
+	require.NoError(t, err)
+	req.Aggregations = map[string]any{"by_name": map[string]any{"terms": map[string]any{"field": "name"}}}
+	res, err := Search[product](context.Background(), New(srv.URL), req)
+	require.NoError(t, err)
+	assert.Equal(t, []product{}, res.Items())
+	assert.Equal(t, int64(0), res.Total)
+	assert.JSONEq(t, `{"buckets":[{"key":"a","doc_count":2}]}`, string(res.Aggregations["by_name"]))
+
+	got := srv.Searches()[0]
+	assert.Equal(t, "", got.Index, "an empty index searches /_search")
+	assert.Equal(t, map[string]any{"terms": map[string]any{"field": "name"}}, got.Body["aggs"].(map[string]any)["by_name"])
+	assert.Contains(t, got.Body, "query")
+}
+
+func TestSearchTotalShapes(t *testing.T) {
+	for name, tc := range map[string]struct {
+		total    any
+		want     int64
+		relation string
+	}{
+		"object":      {map[string]any{"value": 10000, "relation": "gte"}, 10000, "gte"},
+		"number":      {42, 42, "eq"},
+		"not tracked": {nil, -1, ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := estest.Hits(product{"a", 1})
+			resp["hits"].(map[string]any)["total"] = tc.total
+			srv := estest.NewServer(estest.Respond(http.StatusOK, resp))
+			defer srv.Close()
+			res, err := Search[product](context.Background(), New(srv.URL), Request{Index: "p"})
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, res.Total)
+			assert.Equal(t, tc.relation, res.TotalRelation)
+		})
+	}
+}
+
+func TestSearchDecodesInnerHits(t *testing.T) {
+	resp := estest.Hits(product{"a", 1})
+	inner := `{"hits":{"total":{"value":1,"relation":"eq"},"hits":[{"_id":"c1","_source":{"body":"hi"}}]}}`
+	resp["hits"].(map[string]any)["hits"].([]any)[0].(map[string]any)["inner_hits"] =
+		map[string]any{"Comments": json.RawMessage(inner)}
+	srv := estest.NewServer(estest.Respond(http.StatusOK, resp))
+	defer srv.Close()
+
+	res, err := Search[product](context.Background(), New(srv.URL), Request{Index: "p"})
+	require.NoError(t, err)
+	require.Len(t, res.Hits, 1)
+	assert.JSONEq(t, inner, string(res.Hits[0].InnerHits["Comments"]))
+}
+
+func TestSearchSurfacesElasticsearchErrors(t *testing.T) {
+	status, body := estest.Failure(http.StatusNotFound, "index_not_found_exception", "no such index [missing]")
+	srv := estest.NewServer(estest.Respond(status, body))
+	defer srv.Close()
+
+	_, err := Search[product](context.Background(), New(srv.URL), Request{Index: "missing"})
+	var esErr *Error
+	require.True(t, errors.As(err, &esErr))
+	assert.Equal(t, http.StatusNotFound, esErr.Status)
+	assert.Equal(t, "index_not_found_exception", esErr.Type)
+	assert.Equal(t, "no such index [missing]", esErr.Reason)
+	assert.Equal(t, []Cause{{"index_not_found_exception", "no such index [missing]"}}, esErr.RootCause)
+	assert.EqualError(t, err, "esclient: elasticsearch returned 404: index_not_found_exception: no such index [missing]")
+
+	// Pre-5.0 clusters send the error as a string; a proxy may send no JSON.
+	for raw, want := range map[string]string{
+		`{"error":"IndexMissingException[[x] missing]","status":404}`: "esclient: elasticsearch returned 502: IndexMissingException[[x] missing]",
+		`<html>Bad Gateway</html>`:                                    "esclient: elasticsearch returned 502",
+	} {
+		srv := estest.NewServer(estest.Respond(http.StatusBadGateway, []byte(raw)))
+		_, err := Search[product](context.Background(), New(srv.URL), Request{Index: "x"})
+		srv.Close()
+		require.True(t, errors.As(err, &esErr))
+		assert.EqualError(t, err, want)
+		assert.Equal(t, raw, string(esErr.Body))
+	}
+}
+
+func TestSearchFailsClosed(t *testing.T) {
+	srv := estest.NewServer(estest.Static())
+	defer srv.Close()
+	ctx := context.Background()
+
+	// A filter the adapter cannot render never becomes a request.
+	f := figo.New()
+	f.AddFilter(figo.RelationExpr{Relation: "orders", Quantifier: figo.QuantifierAny, Cond: figo.EqExpr{Field: "total", Value: 1}})
+	f.Build(adapters.ElasticsearchAdapter{})
+	_, err := FromFigo("p", f)
+	assert.Error(t, err)
+
+	_, err = Search[product](ctx, New(srv.URL), Request{Index: "a/b"})
+	assert.ErrorContains(t, err, "not a single path segment")
+	_, err = Search[product](ctx, &Client{}, Request{Index: "p"})
+	assert.ErrorContains(t, err, "no URL")
+
+	// A source that does not fit T is a decode error, not a zero value.
+	bad := estest.NewServer(estest.Respond(http.StatusOK, estest.Hits(map[string]any{"price": "free"})))
+	defer bad.Close()
+	_, err = Search[product](ctx, New(bad.URL), Request{Index: "p"})
+	assert.ErrorContains(t, err, "decoding the search response")
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = Search[product](cctx, New(srv.URL), Request{Index: "p"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, srv.Searches())
+}
@@ -0,0 +1,195 @@
+// Package estest is a fake Elasticsearch for testing code built on esclient.
+// A Server is an httptest server answering _search requests from a Handler,
+// and it records every search so a test can assert on the bodies it was
+// sent:
+//
+//	srv := estest.NewServer(estest.Static(Product{Name: "a"}, Product{Name: "b"}))
+//	defer srv.Close()
+//	res, err := esclient.Search[Product](ctx, esclient.New(srv.URL), req)
+//	// srv.Searches()[0].Body["query"] ...
+//
+// The fake does not evaluate queries: handlers choose the response. Static
+// pages through fixed documents with the request's from and size, which is
+// what most paging tests need.
+package estest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Search is one recorded _search request.
+type Search struct {
+	// Index is the path's index segment; empty for a bare /_search.
+	Index string
+	// Body is the decoded request body; Raw is the body as sent.
+	Body   map[string]any
+	Raw    []byte
+	Header http.Header
+}
+
+// Handler answers a search with an HTTP status and a body, which is encoded as
+// JSON ([]byte and json.RawMessage are written as is).
+type Handler func(s Search) (status int, body any)
+
+// Server is a fake Elasticsearch. Close it when the test is done.
+type Server struct {
+	// URL is the server's base URL, the value for esclient.Client.URL.
+	URL string
+
+	srv      *httptest.Server
+	handler  Handler
+	mu       sync.Mutex
+	searches []Search
+}
+
+// NewServer starts a fake answering every _search with h.
+func NewServer(h Handler) *Server {
+	s := &Server{handler: h}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() { s.srv.Close() }
+
+// Searches returns the searches received so far, oldest first.
+func (s *Server) Searches() []Search {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Search(nil), s.searches...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	index, endpoint := "", path
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		index, endpoint = path[:i], path[i+1:]
+	}
+	fail := func(status int, errType, reason string) {
+		status, body := Failure(status, errType, reason)
+		writeJSON(w, status, body)
+	}
+	if endpoint != "_search" || strings.Contains(index, "/") {
+		fail(http.StatusBadRequest, "invalid_index_name_exception", "estest serves only /{index}/_search")
+		return
+	}
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		fail(http.StatusMethodNotAllowed, "method_not_allowed", "estest serves only GET and POST")
+		return
+	}
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		fail(http.StatusBadRequest, "parse_exception", err.Error())
+		return
+	}
+	search := Search{Index: index, Raw: raw, Header: r.Header.Clone()}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &search.Body); err != nil {
+			fail(http.StatusBadRequest, "parse_exception", "request body is not a JSON object: "+err.Error())
+			return
+		}
+	}
+	s.mu.Lock()
+	s.searches = append(s.searches, search)
+	s.mu.Unlock()
+	status, body := s.handler(search)
+	writeJSON(w, status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	var out []byte
+	switch b := body.(type) {
+	case []byte:
+		out = b
+	case json.RawMessage:
+		out = b
+	default:
+		var err error
+		if out, err = json.Marshal(body); err != nil {
+			status, out = http.StatusInternalServerError, []byte(`{"error":"estest: encoding the response failed"}`)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(out)
+}
+
+// Hits returns a successful response body holding docs as the hits' sources,
+// with ids "1", "2", ... and an exact total of len(docs).
+func Hits(docs ...any) map[string]any {
+	return HitsWithTotal(int64(len(docs)), docs...)
+}
+
+// HitsWithTotal is Hits reporting total matching documents, for a page of a
+// larger result.
+func HitsWithTotal(total int64, docs ...any) map[string]any {
+	return hitsFrom(total, 0, docs)
+}
+
+func hitsFrom(total int64, offset int, docs []any) map[string]any {
+	hits := make([]any, len(docs))
+	for i, d := range docs {
+		hits[i] = map[string]any{
+			"_index":  "estest",
+			"_id":     strconv.Itoa(offset + i + 1),
+			"_score":  1.0,
+			"_source": d,
+		}
+	}
+	return map[string]any{
+		"took":      1,
+		"timed_out": false,
+		"_shards":   map[string]any{"total": 1, "successful": 1, "skipped": 0, "failed": 0},
+		"hits": map[string]any{
+			"total":     map[string]any{"value": total, "relation": "eq"},
+			"max_score": 1.0,
+			"hits":      hits,
+		},
+	}
+}
+
+// Failure returns the status and body of an Elasticsearch error response, in
+// the shape every version since 5.0 sends.
+func Failure(status int, errType, reason string) (int, map[string]any) {
+	cause := map[string]any{"type": errType, "reason": reason}
+	return status, map[string]any{
+		"error": map[string]any{
+			"root_cause": []any{cause},
+			"type":       errType,
+			"reason":     reason,
+		},
+		"status": status,
+	}
+}
+
+// Static answers every search with the page of docs its from and size select
+// (size defaults to 10, as in Elasticsearch), reporting len(docs) as the total.
+func Static(docs ...any) Handler {
+	return func(s Search) (int, any) {
+		from, size := intField(s.Body, "from", 0), intField(s.Body, "size", 10)
+		if from < 0 || size < 0 {
+			return Failure(http.StatusBadRequest, "illegal_argument_exception", "[from] and [size] must be non-negative")
+		}
+		lo, hi := min(from, len(docs)), min(from+size, len(docs))
+		return http.StatusOK, hitsFrom(int64(len(docs)), lo, docs[lo:hi])
+	}
+}
+
+// Respond answers every search with the same status and body.
+func Respond(status int, body any) Handler {
+	return func(Search) (int, any) { return status, body }
+}
+
+func intField(body map[string]any, key string, def int) int {
+	if n, ok := body[key].(float64); ok {
+		return int(n)
+	}
+	return def
+}
@@ -0,0 +1,954 @@
+package figo
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Evaluator applies a built figo instance to Go values already in memory —
+// cache entries, webhook payloads, test fixtures — with the semantics the SQL
+// adapters give the same query, so one DSL string means one thing wherever it
+// runs:
+//
+//   - A comparison with NULL is UNKNOWN, and NOT of UNKNOWN is still UNKNOWN:
+//     age!=30 does not match a value without an age, and neither does
+//     not (age=30). NULL is a nil value, a nil pointer, an invalid sql.Null*
+//     (any driver.Valuer returning nil) or a key absent from a map. Only
+//     <null>/<notnull> (and =null/!=null) test for NULL itself.
+//   - LIKE is case-sensitive, '%' and '_' are its only wildcards and there is
+//     no escape character — the Mongo adapter's reading; ILIKE folds case.
+//     =~ is an unanchored Go regexp, as on every adapter.
+//   - <bet> is inclusive on both ends.
+//   - Numbers compare by value whatever their Go type, a numeric string
+//     compares as a number, and time.Time compares chronologically; a date
+//     string on either side of a time comparison is parsed the way the DSL
+//     parses date literals.
+//   - rel<any>/<all>/<none>[cond] ranges over the slice held by the field
+//     named after the relation. As with SQL's EXISTS, an element whose
+//     condition is UNKNOWN does not satisfy it, so it also fails <all>.
+//
+// Field names resolve against map keys and struct fields. A clause holds the
+// name after the instance's NamingFunc, so a key or field matches when it is
+// spelled that way or converts to it: under the default snake_case naming,
+// userName in the DSL finds a "user_name" key, a "userName" key and a UserName
+// field alike. Struct fields also answer to their json, bson and gorm column
+// tags, and embedded structs are searched like Go promotes their fields. A
+// dotted name descends into nested maps and structs. A field missing from a
+// map is NULL; one missing from a struct is an error, as an unknown column is
+// in SQL.
+//
+// Whatever the evaluator cannot decide is an error rather than a guess: an
+// expression type with no in-memory meaning (full-text, geo, JSON path,
+// CustomExpr — NewEvaluator rejects those up front), or values of types that
+// do not compare. MatchFunc turns every error into "no match"; it fails
+// closed like the adapters.
+//
+// An Evaluator is immutable after NewEvaluator and safe for concurrent use.
+type Evaluator struct {
+	clauses []Expr
+	sort    []OrderByColumn
+	page    Page
+	naming  NamingFunc
+
+	// patterns holds the compiled LIKE/ILIKE/regex patterns, keyed by
+	// patternKey; NewEvaluator compiles all of them so a bad pattern is
+	// reported once, before any value is seen.
+	patterns map[string]*regexp.Regexp
+
+	// fields caches the resolved field names of each struct type.
+	fields sync.Map // reflect.Type -> map[string][]int
+}
+
+// NewEvaluator captures the clauses, sort and page of a built figo instance.
+// Later changes to the instance do not reach the Evaluator. Sort keys come
+// from figo.OrderBy nodes in the clause list first and then from the sort=
+// directive, the order the SQL adapters render them in.
+func NewEvaluator(f Figo) (*Evaluator, error) {
+	ev := &Evaluator{
+		clauses:  f.GetClauses(),
+		page:     f.GetPage(),
+		naming:   f.GetNamingFunc(),
+		patterns: map[string]*regexp.Regexp{},
+	}
+	for _, e := range ev.clauses {
+		if ob, ok := e.(OrderBy); ok {
+			ev.sort = append(ev.sort, ob.Columns...)
+		}
+	}
+	if s := f.GetSort(); s != nil {
+		ev.sort = append(ev.sort, s.Columns...)
+	}
+	for _, c := range ev.sort {
+		if c.Name == ScoreSortField {
+			return nil, fmt.Errorf("figo: cannot sort by %s in memory: there is no relevance score", ScoreSortField)
+		}
+	}
+	for _, e := range ev.clauses {
+		if err := ev.prepare(e); err != nil {
+			return nil, err
+		}
+	}
+	return ev, nil
+}
+
+// MatchFunc returns a predicate reporting whether a value satisfies the
+// instance's clauses. It fails closed: when the Evaluator cannot be built, or
+// a value cannot be evaluated, the predicate answers false. Use NewEvaluator
+// and Match to see the error.
+func MatchFunc(f Figo) func(v any) bool {
+	ev, err := NewEvaluator(f)
+	if err != nil {
+		return func(any) bool { return false }
+	}
+	return func(v any) bool {
+		ok, err := ev.Match(v)
+		return ok && err == nil
+	}
+}
+
+// ApplySlice filters items with the instance's clauses, sorts the matches
+// stably by its sort keys and cuts out its page (Take <= 0 means no limit, as
+// on every adapter). It returns a new slice; items is not modified.
+func ApplySlice[T any](f Figo, items []T) ([]T, error) {
+	ev, err := NewEvaluator(f)
+	if err != nil {
+		return nil, err
+	}
+	page, _, err := ApplyEvaluator(ev, items)
+	return page, err
+}
+
+// ApplyEvaluator is ApplySlice with an Evaluator the caller already holds
+// (to read fields with it, say). It also returns the number of matches,
+// before paging.
+func ApplyEvaluator[T any](ev *Evaluator, items []T) ([]T, int, error) {
+	out := make([]T, 0, len(items))
+	for _, item := range items {
+		ok, err := ev.Match(item)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ok {
+			out = append(out, item)
+		}
+	}
+	if len(ev.sort) > 0 {
+		var sortErr error
+		slices.SortStableFunc(out, func(a, b T) int {
+			if sortErr != nil {
+				return 0
+			}
+			c, err := ev.Compare(a, b)
+			if err != nil {
+				sortErr = err
+			}
+			return c
+		})
+		if sortErr != nil {
+			return nil, 0, sortErr
+		}
+	}
+	return PageSlice(out, ev.page), len(out), nil
+}
+
+// PageSlice cuts page p out of items, sharing its backing array. Take <= 0
+// means no limit; a Skip past the end yields an empty slice.
+func PageSlice[T any](items []T, p Page) []T {
+	skip := max(p.Skip, 0)
+	if skip >= len(items) {
+		return items[:0]
+	}
+	items = items[skip:]
+	if p.Take > 0 && p.Take < len(items) {
+		items = items[:p.Take]
+	}
+	return items
+}
+
+// Match reports whether v satisfies every clause. v is a map with string keys,
+// a struct, or a pointer to either. UNKNOWN is not a match.
+func (ev *Evaluator) Match(v any) (bool, error) {
+	t, err := ev.evalAll(v, ev.clauses, true)
+	return t == triTrue, err
+}
+
+// Compare orders two values by the sort keys: negative when a sorts first.
+// NULL sorts before any value in ascending order and after it in descending
+// order, as in SQLite and MySQL. Values that tie on every key compare equal.
+func (ev *Evaluator) Compare(a, b any) (int, error) {
+	for _, col := range ev.sort {
+		if col.Name == "" {
+			continue
+		}
+		va, err := ev.lookup(a, col.Name)
+		if err != nil {
+			return 0, err
+		}
+		vb, err := ev.lookup(b, col.Name)
+		if err != nil {
+			return 0, err
+		}
+		var c int
+		switch {
+		case va == nil && vb == nil:
+		case va == nil:
+			c = -1
+		case vb == nil:
+			c = 1
+		default:
+			if c, err = compareValues(va, vb); err != nil {
+				return 0, fmt.Errorf("figo: sort key %q: %w", col.Name, err)
+			}
+		}
+		if col.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c, nil
+		}
+	}
+	return 0, nil
+}
+
+// Field returns the value of the named field of v, resolved the way clause
+// fields are and normalized the way values are compared: integers as int64
+// (uint64 for unsigned ones), floats as float64, string-kinded values as
+// string, sql.Null* and pointers unwrapped, and nil for NULL.
+func (ev *Evaluator) Field(v any, name string) (any, error) {
+	return ev.lookup(v, name)
+}
+
+// Page returns the captured pagination.
+func (ev *Evaluator) Page() Page { return ev.page }
+
+// tri is SQL's three-valued truth.
+type tri uint8
+
+const (
+	triFalse tri = iota
+	triTrue
+	triUnknown
+)
+
+func triOf(b bool) tri {
+	if b {
+		return triTrue
+	}
+	return triFalse
+}
+
+func (t tri) not() tri {
+	switch t {
+	case triTrue:
+		return triFalse
+	case triFalse:
+		return triTrue
+	}
+	return triUnknown
+}
+
+// evalAll combines operands with AND (and=true) or OR. nil operands are
+// skipped, as the adapters skip them; with none left, AND is true and OR is
+// false.
+func (ev *Evaluator) evalAll(v any, ops []Expr, and bool) (tri, error) {
+	result := triOf(and)
+	for _, op := range ops {
+		if op == nil {
+			continue
+		}
+		t, err := ev.eval(v, op)
+		if err != nil {
+			return triFalse, err
+		}
+		switch {
+		case and && t == triFalse, !and && t == triTrue:
+			return t, nil
+		case t == triUnknown:
+			result = triUnknown
+		}
+	}
+	return result, nil
+}
+
+func (ev *Evaluator) eval(v any, e Expr) (tri, error) {
+	switch x := e.(type) {
+	case AndExpr:
+		return ev.evalAll(v, x.Operands, true)
+	case OrExpr:
+		return ev.evalAll(v, x.Operands, false)
+	case NotExpr:
+		// NOT(a OR b), and a NotExpr without operands is dropped (true), as
+		// the adapters render it.
+		t, err := ev.evalAll(v, x.Operands, false)
+		if err != nil || !hasNonNil(x.Operands) {
+			return triTrue, err
+		}
+		return t.not(), nil
+	case OrderBy:
+		return triTrue, nil
+	case EqExpr:
+		if x.Value == nil {
+			return ev.eval(v, IsNullExpr{Field: x.Field})
+		}
+		return ev.compareField(v, x.Field, x.Value, func(c int) bool { return c == 0 })
+	case NeqExpr:
+		if x.Value == nil {
+			return ev.eval(v, NotNullExpr{Field: x.Field})
+		}
+		return ev.compareField(v, x.Field, x.Value, func(c int) bool { return c != 0 })
+	case GtExpr:
+		return ev.compareField(v, x.Field, x.Value, func(c int) bool { return c > 0 })
+	case GteExpr:
+		return ev.compareField(v, x.Field, x.Value, func(c int) bool { return c >= 0 })
+	case LtExpr:
+		return ev.compareField(v, x.Field, x.Value, func(c int) bool { return c < 0 })
+	case LteExpr:
+		return ev.compareField(v, x.Field, x.Value, func(c int) bool { return c <= 0 })
+	case BetweenExpr:
+		low, err := ev.compareField(v, x.Field, x.Low, func(c int) bool { return c >= 0 })
+		if err != nil || low == triFalse {
+			return low, err
+		}
+		high, err := ev.compareField(v, x.Field, x.High, func(c int) bool { return c <= 0 })
+		if err != nil || high == triFalse {
+			return high, err
+		}
+		if low == triUnknown || high == triUnknown {
+			return triUnknown, nil
+		}
+		return triTrue, nil
+	case IsNullExpr, NotNullExpr:
+		field := ExprField(x)
+		fv, err := ev.lookup(v, field)
+		if err != nil {
+			return triFalse, err
+		}
+		_, isNull := x.(IsNullExpr)
+		return triOf((fv == nil) == isNull), nil
+	case InExpr:
+		return ev.inList(v, x.Field, x.Values, false)
+	case NotInExpr:
+		return ev.inList(v, x.Field, x.Values, true)
+	case LikeExpr:
+		return ev.matchPattern(v, x.Field, patternKey("like", x.Value))
+	case ILikeExpr:
+		return ev.matchPattern(v, x.Field, patternKey("ilike", x.Value))
+	case RegexExpr:
+		return ev.matchPattern(v, x.Field, patternKey("regex", x.Value))
+	case ArrayContainsExpr:
+		return ev.arrayHas(v, x.Field, x.Values, true)
+	case ArrayOverlapsExpr:
+		return ev.arrayHas(v, x.Field, x.Values, false)
+	case RelationExpr:
+		return ev.relation(v, x)
+	default:
+		return triFalse, errEvalUnsupported(e)
+	}
+}
+
+func hasNonNil(ops []Expr) bool {
+	for _, op := range ops {
+		if op != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func errEvalUnsupported(e Expr) error {
+	return fmt.Errorf("figo: the in-memory evaluator cannot evaluate %T", e)
+}
+
+// compareField compares the field's value with a literal; NULL on either side
+// is UNKNOWN.
+func (ev *Evaluator) compareField(v any, field string, lit any, ok func(int) bool) (tri, error) {
+	fv, err := ev.lookup(v, field)
+	if err != nil {
+		return triFalse, err
+	}
+	lit = normalizeValue(lit)
+	if fv == nil || lit == nil {
+		return triUnknown, nil
+	}
+	c, err := compareValues(fv, lit)
+	if err != nil {
+		return triFalse, fmt.Errorf("figo: field %q: %w", field, err)
+	}
+	return triOf(ok(c)), nil
+}
+
+// inList is SQL's IN / NOT IN: a NULL in the list makes a non-match UNKNOWN,
+// so NOT IN with a NULL in its list never matches. The empty list is false for
+// IN and true for NOT IN, NULL or not, as the adapters render it.
+func (ev *Evaluator) inList(v any, field string, values []any, negate bool) (tri, error) {
+	if len(values) == 0 {
+		return triOf(negate), nil
+	}
+	fv, err := ev.lookup(v, field)
+	if err != nil {
+		return triFalse, err
+	}
+	if fv == nil {
+		return triUnknown, nil
+	}
+	result := triFalse
+	for _, lit := range values {
+		lit = normalizeValue(lit)
+		if lit == nil {
+			result = triUnknown
+			continue
+		}
+		c, err := compareValues(fv, lit)
+		if err != nil {
+			return triFalse, fmt.Errorf("figo: field %q: %w", field, err)
+		}
+		if c == 0 {
+			result = triTrue
+			break
+		}
+	}
+	if negate {
+		return result.not(), nil
+	}
+	return result, nil
+}
+
+func (ev *Evaluator) matchPattern(v any, field, key string) (tri, error) {
+	fv, err := ev.lookup(v, field)
+	if err != nil {
+		return triFalse, err
+	}
+	re := ev.patterns[key]
+	if fv == nil || re == nil {
+		// re is nil only for a nil pattern: "LIKE NULL" is UNKNOWN.
+		return triUnknown, nil
+	}
+	s, ok := textOf(fv)
+	if !ok {
+		return triFalse, fmt.Errorf("figo: field %q: cannot match a %T against a pattern", field, fv)
+	}
+	return triOf(re.MatchString(s)), nil
+}
+
+// arrayHas tests a slice-valued field for all (ArrayContainsExpr) or any
+// (ArrayOverlapsExpr) of values. A NULL field is UNKNOWN.
+func (ev *Evaluator) arrayHas(v any, field string, values []any, all bool) (tri, error) {
+	fv, err := ev.lookup(v, field)
+	if err != nil {
+		return triFalse, err
+	}
+	if fv == nil {
+		return triUnknown, nil
+	}
+	rv := reflect.ValueOf(fv)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return triFalse, fmt.Errorf("figo: field %q holds a %T, not an array", field, fv)
+	}
+	has := func(lit any) (bool, error) {
+		lit = normalizeValue(lit)
+		for i := 0; i < rv.Len(); i++ {
+			el := normalizeValue(rv.Index(i).Interface())
+			if el == nil || lit == nil {
+				continue
+			}
+			c, err := compareValues(el, lit)
+			if err != nil {
+				return false, fmt.Errorf("figo: field %q: %w", field, err)
+			}
+			if c == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	for _, lit := range values {
+		found, err := has(lit)
+		if err != nil {
+			return triFalse, err
+		}
+		if found != all {
+			return triOf(found), nil
+		}
+	}
+	return triOf(all), nil
+}
+
+// relation evaluates a quantified predicate over the elements of the field
+// named after the relation; a NULL field has no elements. The result is two-
+// valued, like EXISTS.
+func (ev *Evaluator) relation(v any, x RelationExpr) (tri, error) {
+	fv, err := ev.lookupRelation(v, x.Relation)
+	if err != nil {
+		return triFalse, err
+	}
+	var elems []any
+	if fv != nil {
+		rv := reflect.ValueOf(fv)
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				elems = append(elems, rv.Index(i).Interface())
+			}
+		default:
+			// A to-one relation: the single related value.
+			elems = []any{fv}
+		}
+	}
+	satisfied := 0
+	for _, el := range elems {
+		t := triTrue
+		if x.Cond != nil {
+			if t, err = ev.eval(el, x.Cond); err != nil {
+				return triFalse, fmt.Errorf("figo: relation %q: %w", x.Relation, err)
+			}
+		}
+		if t == triTrue {
+			satisfied++
+		}
+	}
+	switch x.Quantifier {
+	case QuantifierAny:
+		return triOf(satisfied > 0), nil
+	case QuantifierAll:
+		return triOf(satisfied == len(elems)), nil
+	case QuantifierNone:
+		return triOf(satisfied == 0), nil
+	}
+	return triFalse, fmt.Errorf("figo: relation %q has unknown quantifier %q", x.Relation, x.Quantifier)
+}
+
+// lookupRelation resolves a relation name. It is kept verbatim in the clause
+// (it names an adapter relation, not a column), so the converted spelling is
+// tried as well.
+func (ev *Evaluator) lookupRelation(v any, name string) (any, error) {
+	fv, err := ev.lookup(v, name)
+	if err == nil && fv != nil {
+		return fv, nil
+	}
+	if converted := convertFieldName(ev.naming, name); converted != name {
+		if cv, cErr := ev.lookup(v, converted); cErr == nil {
+			return cv, nil
+		}
+	}
+	return fv, err
+}
+
+// prepare checks that every node can be evaluated and compiles its patterns.
+func (ev *Evaluator) prepare(e Expr) error {
+	switch x := e.(type) {
+	case nil:
+		return nil
+	case AndExpr:
+		return ev.prepareAll(x.Operands)
+	case OrExpr:
+		return ev.prepareAll(x.Operands)
+	case NotExpr:
+		return ev.prepareAll(x.Operands)
+	case RelationExpr:
+		if x.Cond == nil {
+			return nil
+		}
+		return ev.prepare(x.Cond)
+	case LikeExpr:
+		return ev.compile("like", x.Value)
+	case ILikeExpr:
+		return ev.compile("ilike", x.Value)
+	case RegexExpr:
+		return ev.compile("regex", x.Value)
+	case EqExpr, NeqExpr, GtExpr, GteExpr, LtExpr, LteExpr, BetweenExpr,
+		IsNullExpr, NotNullExpr, InExpr, NotInExpr, ArrayContainsExpr,
+		ArrayOverlapsExpr, OrderBy:
+		return nil
+	default:
+		return errEvalUnsupported(e)
+	}
+}
+
+func (ev *Evaluator) prepareAll(ops []Expr) error {
+	for _, op := range ops {
+		if err := ev.prepare(op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patternKey identifies a compiled pattern; "" for a nil pattern, which
+// matches nothing.
+func patternKey(kind string, pattern any) string {
+	if pattern == nil {
+		return ""
+	}
+	if re, ok := pattern.(*regexp.Regexp); ok {
+		return kind + "\x00" + re.String()
+	}
+	return kind + "\x00" + fmt.Sprint(pattern)
+}
+
+func (ev *Evaluator) compile(kind string, pattern any) error {
+	key := patternKey(kind, pattern)
+	if key == "" {
+		return nil
+	}
+	if _, done := ev.patterns[key]; done {
+		return nil
+	}
+	src := key[len(kind)+1:]
+	var expr string
+	switch kind {
+	case "regex":
+		expr = src
+	case "like", "ilike":
+		var b strings.Builder
+		// (?s): '%' and '_' match newlines too, as in SQL.
+		b.WriteString("(?s)")
+		if kind == "ilike" {
+			b.WriteString("(?i)")
+		}
+		b.WriteByte('^')
+		for _, r := range src {
+			switch r {
+			case '%':
+				b.WriteString(".*")
+			case '_':
+				b.WriteByte('.')
+			default:
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		b.WriteByte('$')
+		expr = b.String()
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("figo: invalid %s pattern %q: %w", kind, src, err)
+	}
+	ev.patterns[key] = re
+	return nil
+}
+
+// lookup resolves a field of v and normalizes its value; nil means NULL.
+func (ev *Evaluator) lookup(v any, field string) (any, error) {
+	rv, err := ev.resolve(reflect.ValueOf(v), field)
+	if err != nil || !rv.IsValid() {
+		return nil, err
+	}
+	return normalizeValue(rv.Interface()), nil
+}
+
+// resolve finds field in a map or struct, descending through pointers,
+// interfaces and — for a dotted name that is not itself a key — nested values.
+// The invalid Value means NULL.
+func (ev *Evaluator) resolve(rv reflect.Value, field string) (reflect.Value, error) {
+	rv = indirect(rv)
+	if !rv.IsValid() {
+		return reflect.Value{}, nil
+	}
+	if fv, found, err := ev.resolveOne(rv, field); err != nil || found {
+		return fv, err
+	}
+	for i := strings.IndexByte(field, '.'); i > 0; i = nextDot(field, i) {
+		head, found, err := ev.resolveOne(rv, field[:i])
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if found {
+			return ev.resolve(head, field[i+1:])
+		}
+	}
+	if rv.Kind() == reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("figo: %s has no field %q", rv.Type(), field)
+	}
+	return reflect.Value{}, nil
+}
+
+func nextDot(s string, i int) int {
+	j := strings.IndexByte(s[i+1:], '.')
+	if j < 0 {
+		return -1
+	}
+	return i + 1 + j
+}
+
+// resolveOne looks up one (possibly dotted) name without descending.
+func (ev *Evaluator) resolveOne(rv reflect.Value, name string) (reflect.Value, bool, error) {
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false, fmt.Errorf("figo: cannot look up %q in a %s", name, rv.Type())
+		}
+		if mv := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key())); mv.IsValid() {
+			return mv, true, nil
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			if convertFieldName(ev.naming, iter.Key().String()) == name {
+				return iter.Value(), true, nil
+			}
+		}
+		return reflect.Value{}, false, nil
+	case reflect.Struct:
+		index, ok := ev.structFields(rv.Type())[name]
+		if !ok {
+			return reflect.Value{}, false, nil
+		}
+		fv, err := rv.FieldByIndexErr(index)
+		if err != nil {
+			// A nil embedded pointer: the promoted field is NULL.
+			return reflect.Value{}, true, nil
+		}
+		return fv, true, nil
+	default:
+		return reflect.Value{}, false, fmt.Errorf("figo: cannot look up %q in a %s", name, rv.Type())
+	}
+}
+
+// structFields maps every name a field answers to onto its index: its Go
+// name, the name the NamingFunc converts that to, and its json, bson and gorm
+// column tags. A name claimed by a shallower field wins, as in Go.
+func (ev *Evaluator) structFields(t reflect.Type) map[string][]int {
+	if cached, ok := ev.fields.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+	names := map[string][]int{}
+	depth := map[string]int{}
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || sf.Anonymous && indirectType(sf.Type).Kind() == reflect.Struct {
+			continue
+		}
+		for _, name := range structFieldNames(sf, ev.naming) {
+			if d, taken := depth[name]; taken && d <= len(sf.Index) {
+				continue
+			}
+			names[name], depth[name] = sf.Index, len(sf.Index)
+		}
+	}
+	ev.fields.Store(t, names)
+	return names
+}
+
+func structFieldNames(sf reflect.StructField, naming NamingFunc) []string {
+	names := []string{sf.Name, convertFieldName(naming, sf.Name)}
+	for _, tag := range []string{"json", "bson"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(tag), ","); name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	for _, part := range strings.Split(sf.Tag.Get("gorm"), ";") {
+		if col, ok := strings.CutPrefix(strings.TrimSpace(part), "column:"); ok && col != "" {
+			names = append(names, col)
+		}
+	}
+	return names
+}
+
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.IsValid() && (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// normalizeValue reduces a value to the few types compareValues knows:
+// int64, uint64, float64, string, bool and time.Time, with nil for NULL.
+// Pointers are followed and driver.Valuer types (sql.NullString, ...) are
+// unwrapped; other values (slices, maps, structs) are returned as they are.
+func normalizeValue(v any) any {
+	if v == nil {
+		return nil
+	}
+	if _, isTime := v.(time.Time); !isTime {
+		if valuer, ok := v.(driver.Valuer); ok {
+			rv := reflect.ValueOf(v)
+			if rv.Kind() == reflect.Pointer && rv.IsNil() {
+				return nil
+			}
+			dv, err := valuer.Value()
+			if err != nil || dv == nil {
+				return nil
+			}
+			v = dv
+		}
+	}
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return string(rv.Bytes())
+		}
+	case reflect.Struct:
+		if rv.Type() == timeType {
+			return rv.Interface()
+		}
+	}
+	return rv.Interface()
+}
+
+// textOf renders a value as the text LIKE and regex match against; numbers
+// are matched in their decimal form, as SQL casts them.
+func textOf(v any) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case int64:
+		return strconv.FormatInt(x, 10), true
+	case uint64:
+		return strconv.FormatUint(x, 10), true
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// compareValues orders two normalized, non-NULL values.
+func compareValues(a, b any) (int, error) {
+	switch x := a.(type) {
+	case time.Time:
+		y, ok := asTime(b)
+		if !ok {
+			break
+		}
+		return x.Compare(y), nil
+	case string:
+		switch y := b.(type) {
+		case string:
+			return strings.Compare(x, y), nil
+		case time.Time:
+			if xt, ok := asTime(x); ok {
+				return xt.Compare(y), nil
+			}
+		case int64, uint64, float64, bool:
+			if n, ok := parseNumber(x); ok {
+				return compareNumbers(n, y)
+			}
+		}
+	case int64, uint64, float64, bool:
+		switch y := b.(type) {
+		case int64, uint64, float64, bool:
+			return compareNumbers(x, y)
+		case string:
+			if n, ok := parseNumber(y); ok {
+				return compareNumbers(x, n)
+			}
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %T with %T", a, b)
+}
+
+func asTime(v any) (time.Time, bool) {
+	switch x := v.(type) {
+	case time.Time:
+		return x, true
+	case string:
+		t, err := parseDate(x)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+func parseNumber(s string) (any, bool) {
+	s = strings.TrimSpace(s)
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(f) {
+		return f, true
+	}
+	return nil, false
+}
+
+// compareNumbers orders int64, uint64, float64 and bool (false=0, true=1)
+// exactly: integers are not routed through float64 unless one side is a float.
+func compareNumbers(a, b any) (int, error) {
+	if ab, ok := a.(bool); ok {
+		a = boolInt(ab)
+	}
+	if bb, ok := b.(bool); ok {
+		b = boolInt(bb)
+	}
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return cmpOrdered(x, y), nil
+		case uint64:
+			if x < 0 {
+				return -1, nil
+			}
+			return cmpOrdered(uint64(x), y), nil
+		}
+	case uint64:
+		switch y := b.(type) {
+		case uint64:
+			return cmpOrdered(x, y), nil
+		case int64:
+			if y < 0 {
+				return 1, nil
+			}
+			return cmpOrdered(x, uint64(y)), nil
+		}
+	}
+	return cmpOrdered(toFloat(a), toFloat(b)), nil
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func toFloat(v any) float64 {
+	switch x := v.(type) {
+	case int64:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float64:
+		return x
+	}
+	return math.NaN()
+}
+
+func cmpOrdered[T int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
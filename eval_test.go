@@ -0,0 +1,297 @@
+package figo
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func evalBuilt(t *testing.T, dsl string) Figo {
+	t.Helper()
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(dsl))
+	require.NoError(t, f.BuildE(nil))
+	return f
+}
+
+// evalIDs returns the "id" of every row the DSL matches, in input order.
+func evalIDs(t *testing.T, dsl string, rows []map[string]any) []int {
+	t.Helper()
+	ev, err := NewEvaluator(evalBuilt(t, dsl))
+	require.NoError(t, err, dsl)
+	ids := []int{}
+	for _, r := range rows {
+		ok, err := ev.Match(r)
+		require.NoError(t, err, dsl)
+		if ok {
+			ids = append(ids, r["id"].(int))
+		}
+	}
+	return ids
+}
+
+var evalRows = []map[string]any{
+	{"id": 1, "age": 30, "name": "Alice"},
+	{"id": 2, "age": 40, "name": "bob"},
+	{"id": 3, "age": nil, "name": "carol"},
+	{"id": 4, "name": "a_b%c\nd"}, // no age key at all
+}
+
+// A comparison with NULL is UNKNOWN, and negating it does not make it true.
+func TestEvaluatorNullIsUnknown(t *testing.T) {
+	for dsl, want := range map[string][]int{
+		`age=30`:                  {1},
+		`age!=30`:                 {2},
+		`not (age=30)`:            {2},
+		`not (age=30 or age=40)`:  {},
+		`age=30 or age<null>`:     {1, 3, 4},
+		`age<null>`:               {3, 4},
+		`age<notnull>`:            {1, 2},
+		`age=null`:                {3, 4},
+		`age<in>[30,null]`:        {1},
+		`age<nin>[30,null]`:       {},
+		`age<nin>[30]`:            {2},
+		`age<nin>[]`:              {1, 2, 3, 4},
+		`age<bet>(30..40)`:        {1, 2},
+		`age<bet>(31..40)`:        {2},
+		`not (age<bet>(31..40))`:  {1},
+		`age>=30 and name!="bob"`: {1},
+	} {
+		assert.Equal(t, want, evalIDs(t, dsl, evalRows), dsl)
+	}
+}
+
+func TestEvaluatorPatterns(t *testing.T) {
+	for dsl, want := range map[string][]int{
+		`name=^"A%"`:       {1},
+		`name=^"a%"`:       {4},
+		`name.=^"a%"`:      {1, 4},
+		`name=^"_ob"`:      {2},
+		`name=^"a_b%d"`:    {4}, // '_' and '%' are wildcards, and '%' spans the newline
+		`name=^"b"`:        {},  // anchored
+		`name=~"o"`:        {2, 3},
+		`name=~"^[A-Z]"`:   {1},
+		`not (name=~"o")`:  {1, 4},
+		`age=^"3%"`:        {1}, // a number matches in its decimal form
+		`name.=^"%LIC%"`:   {1},
+		`name!=^"%o%"`:     {1, 4},
+		`name<in>["bob"]`:  {2},
+		`name>"b"`:         {2, 3},
+		`name="alice"`:     {},
+		`age="40"`:         {2}, // a numeric string compares as a number
+		`age>35.5`:         {2},
+		`age<bet>(30..30)`: {1},
+	} {
+		assert.Equal(t, want, evalIDs(t, dsl, evalRows), dsl)
+	}
+}
+
+type evalAddress struct {
+	City string `json:"city"`
+}
+
+type evalAudit struct {
+	CreatedAt time.Time
+}
+
+type evalOrder struct {
+	Status string
+	Total  int
+}
+
+type evalUser struct {
+	evalAudit
+	ID       int64
+	UserName string         `json:"login"`
+	Email    sql.NullString `gorm:"column:mail;not null"`
+	Score    *float64
+	Address  evalAddress
+	Orders   []evalOrder
+	secret   string
+}
+
+func evalUsers() []evalUser {
+	score := 7.5
+	return []evalUser{
+		{evalAudit{time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)}, 1, "ann", sql.NullString{String: "ann@x.io", Valid: true}, &score, evalAddress{"Oslo"},
+			[]evalOrder{{"paid", 10}, {"paid", 80}}, ""},
+		{evalAudit{time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}, 2, "ben", sql.NullString{}, nil, evalAddress{"Rome"},
+			[]evalOrder{{"paid", 5}, {"refunded", 60}}, ""},
+		{evalAudit{time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)}, 3, "cy", sql.NullString{String: "cy@y.io", Valid: true}, nil, evalAddress{"Oslo"},
+			nil, ""},
+	}
+}
+
+func evalUserIDs(t *testing.T, f Figo) []int64 {
+	t.Helper()
+	ev, err := NewEvaluator(f)
+	require.NoError(t, err)
+	ids := []int64{}
+	for _, u := range evalUsers() {
+		ok, err := ev.Match(&u)
+		require.NoError(t, err, f.GetDSL())
+		if ok {
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids
+}
+
+// Struct fields answer to the naming func's spelling of their Go name and to
+// their json/bson/gorm column tags; promoted fields are found, pointers and
+// sql.Null* unwrap, and an invalid sql.Null* is NULL.
+func TestEvaluatorStructFields(t *testing.T) {
+	for dsl, want := range map[string][]int64{
+		`userName="ann"`:                         {1},
+		`login="ben"`:                            {2},
+		`mail=^"%.io"`:                           {1, 3},
+		`email<null>`:                            {2},
+		`score>7`:                                {1},
+		`score<null>`:                            {2, 3},
+		`createdAt>=2024-01-01`:                  {1, 2},
+		`createdAt<bet>(2024-01-10..2024-03-01)`: {1},
+		`createdAt<"2024-01-01"`:                 {3},
+	} {
+		assert.Equal(t, want, evalUserIDs(t, evalBuilt(t, dsl)), dsl)
+	}
+
+	// A dotted name descends; it needs naming that keeps the dot.
+	f := New()
+	f.SetNamingFunc(NoChangeNaming)
+	require.NoError(t, f.AddFiltersFromString(`Address.city="Oslo"`))
+	f.Build(nil)
+	assert.Equal(t, []int64{1, 3}, evalUserIDs(t, f))
+}
+
+// An unknown struct field is an error, as an unknown column is in SQL; an
+// unexported field is not a field.
+func TestEvaluatorUnknownStructField(t *testing.T) {
+	for _, dsl := range []string{`nope=1`, `secret="x"`} {
+		ev, err := NewEvaluator(evalBuilt(t, dsl))
+		require.NoError(t, err)
+		_, err = ev.Match(evalUsers()[0])
+		require.Error(t, err, dsl)
+		assert.Contains(t, err.Error(), "has no field")
+		assert.False(t, MatchFunc(evalBuilt(t, dsl))(evalUsers()[0]))
+	}
+}
+
+func TestEvaluatorRelations(t *testing.T) {
+	for dsl, want := range map[string][]int64{
+		`Orders<any>[status="refunded"]`:              {2},
+		`Orders<all>[status="paid"]`:                  {1, 3}, // vacuously true without orders
+		`Orders<none>[total>50]`:                      {3},
+		`Orders<any>[]`:                               {1, 2},
+		`Orders<any>[status="paid" and total>50]`:     {1},
+		`not Orders<any>[status="paid" and total>50]`: {2, 3},
+	} {
+		assert.Equal(t, want, evalUserIDs(t, evalBuilt(t, dsl)), dsl)
+	}
+
+	// An element whose condition is UNKNOWN does not satisfy <all>.
+	rows := []map[string]any{{"id": 1, "items": []map[string]any{{"qty": 1}, {"qty": nil}}}}
+	assert.Equal(t, []int{}, evalIDs(t, `items<all>[qty>0]`, rows))
+	assert.Equal(t, []int{1}, evalIDs(t, `items<any>[qty>0]`, rows))
+}
+
+// Sorting follows sort= with NULL first ascending and last descending; the
+// page is cut after sorting; the input is not reordered.
+func TestApplySliceSortsAndPages(t *testing.T) {
+	rows := []map[string]any{
+		{"id": 1, "age": 30, "name": "c"},
+		{"id": 2, "age": nil, "name": "a"},
+		{"id": 3, "age": 30, "name": "a"},
+		{"id": 4, "age": 25, "name": "b"},
+	}
+	ids := func(rs []map[string]any) []int {
+		out := []int{}
+		for _, r := range rs {
+			out = append(out, r["id"].(int))
+		}
+		return out
+	}
+
+	got, err := ApplySlice(evalBuilt(t, `sort=age:asc,name:asc`), rows)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4, 3, 1}, ids(got))
+
+	got, err = ApplySlice(evalBuilt(t, `sort=age:desc,name:asc`), rows)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 1, 4, 2}, ids(got))
+
+	got, err = ApplySlice(evalBuilt(t, `age<notnull> sort=name:desc page=skip:1,take:1`), rows)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4}, ids(got))
+
+	ev, err := NewEvaluator(evalBuilt(t, `age<notnull> sort=name:desc page=skip:1,take:1`))
+	require.NoError(t, err)
+	got, total, err := ApplyEvaluator(ev, rows)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4}, ids(got))
+	assert.Equal(t, 3, total, "the total counts every match, before paging")
+
+	got, err = ApplySlice(evalBuilt(t, `page=skip:10`), rows)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	assert.Equal(t, []int{1, 2, 3, 4}, ids(rows))
+
+	users, err := ApplySlice(evalBuilt(t, `sort=createdAt:desc`), evalUsers())
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 1, 3}, []int64{users[0].ID, users[1].ID, users[2].ID})
+}
+
+// What the evaluator cannot decide is an error, never a guess, and MatchFunc
+// fails closed on it.
+func TestEvaluatorFailsClosed(t *testing.T) {
+	f := New()
+	f.AddFilter(FullTextSearchExpr{Query: "hello"})
+	f.Build(nil)
+	_, err := NewEvaluator(f)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "FullTextSearchExpr")
+	assert.False(t, MatchFunc(f)(map[string]any{}))
+
+	g := New()
+	g.AddFilter(OrExpr{Operands: []Expr{EqExpr{Field: "a", Value: 1}, CustomExpr{Field: "a"}}})
+	g.Build(nil)
+	_, err = NewEvaluator(g)
+	require.Error(t, err)
+
+	h := New()
+	h.AddFilter(RegexExpr{Field: "a", Value: "("})
+	h.Build(nil)
+	_, err = NewEvaluator(h)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid regex pattern")
+
+	_, err = NewEvaluator(evalBuilt(t, `sort=_score:desc`))
+	require.Error(t, err)
+
+	ev, err := NewEvaluator(evalBuilt(t, `a>5`))
+	require.NoError(t, err)
+	_, err = ev.Match(map[string]any{"a": "five"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot compare string with int64")
+	_, err = ev.Match(42)
+	require.Error(t, err)
+	assert.False(t, MatchFunc(evalBuilt(t, `a>5`))(map[string]any{"a": []int{1}}))
+}
+
+// Map keys are found by their converted spelling too, and the evaluator is
+// unaffected by later changes to the instance.
+func TestEvaluatorNamingAndSnapshot(t *testing.T) {
+	f := evalBuilt(t, `firstName="Ann"`)
+	match := MatchFunc(f)
+	assert.True(t, match(map[string]any{"firstName": "Ann"}))
+	assert.True(t, match(map[string]any{"first_name": "Ann"}))
+	assert.True(t, match(map[string]string{"first_name": "Ann"}))
+
+	require.NoError(t, f.AddFiltersFromString(`firstName="Bob"`))
+	f.Build(nil)
+	assert.True(t, match(map[string]any{"first_name": "Ann"}))
+	assert.True(t, strings.Contains(f.GetDSL(), "Bob"))
+}
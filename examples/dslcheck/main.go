@@ -33,7 +33,9 @@
 // Three adapter behaviours are refusals the playground cannot design away,
 // because they are capability limits of one backend rather than malformed DSL:
 //
-//   - Elasticsearch has no preload path and fails the build for ANY `load=`.
+//   - Elasticsearch fails the build for a `load=` that names no configured
+//     relation or nested path, and the checker builds with the zero adapter,
+//     which configures none.
 //   - MongoDB's Find path fails closed on a preload carrying predicates, and the
 //     adapter rejects a '$'-leading field name outright (it would execute as an
 //     operator). A '$'-leading column is perfectly legal on all three SQL
@@ -280,8 +282,9 @@ func checkElasticsearch(dsl, naming string, fn figo.NamingFunc, add func(failure
 	f := newFigo(fn, dsl)
 	f.Build(adapters.ElasticsearchAdapter{})
 
-	// The ES adapter has no preload path and fails the build for ANY `load=`
-	// (documented). That is an adapter capability limit, not an emitter defect.
+	// The zero ES adapter configures no relation or nested path to preload, so
+	// it fails the build for ANY `load=` (documented). That is an adapter
+	// configuration limit, not an emitter defect.
 	if len(f.GetPreloads()) > 0 {
 		return
 	}
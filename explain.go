@@ -191,6 +191,12 @@ func explainNode(e Expr) (string, []Expr) {
 		return "OR", v.Operands
 	case NotExpr:
 		return "NOT", v.Operands
+	case RelationExpr:
+		label := fmt.Sprintf("%s %s", v.Relation, strings.ToUpper(string(v.Quantifier)))
+		if v.Cond == nil {
+			return label, nil
+		}
+		return label, []Expr{v.Cond}
 
 	// Comparison
 	case EqExpr:
@@ -244,10 +250,14 @@ func explainNode(e Expr) (string, []Expr) {
 	case ArrayOverlapsExpr:
 		return fmt.Sprintf("%s OVERLAPS %s", v.Field, explainList(v.Values)), nil
 	case FullTextSearchExpr:
+		label := fmt.Sprintf("FULLTEXT %q", v.Query)
+		if v.Field != "" {
+			label = v.Field + " " + label
+		}
 		if v.Language != "" {
-			return fmt.Sprintf("%s FULLTEXT %q (lang=%s)", v.Field, v.Query, v.Language), nil
+			label += fmt.Sprintf(" (lang=%s)", v.Language)
 		}
-		return fmt.Sprintf("%s FULLTEXT %q", v.Field, v.Query), nil
+		return label, nil
 	case GeoDistanceExpr:
 		unit := v.Unit
 		if unit == "" {
@@ -1,6 +1,7 @@
 package figo
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -119,10 +120,14 @@ const (
 	OperationSort     Operation = "sort"
 	OperationLoad     Operation = "load"
 	OperationPage     Operation = "page"
+	OperationSearch   Operation = "q"
 	OperationChild    Operation = "----"
 	OperationILike    Operation = ".=^"
 	OperationIsNull   Operation = "<null>"
 	OperationNotNull  Operation = "<notnull>"
+	OperationAny      Operation = "<any>"
+	OperationAll      Operation = "<all>"
+	OperationNone     Operation = "<none>"
 )
 
 // operationDirective marks a parser node standing in for a consumed
@@ -204,6 +209,27 @@ type PreloadFinalizer interface {
 	FinalizePreloads(f Figo, relation string, conditions []Expr) []Expr
 }
 
+// ContextPlugin is an optional interface a Plugin may implement to finalize a
+// Build with the context it was given (BuildContext). It is the context-aware
+// form of ClauseFinalizer and PreloadFinalizer, for policy that depends on the
+// request rather than on the plugin's configuration: one shared ScopePlugin
+// resolving the tenant from the request context, instead of a new plugin per
+// request. For a plugin implementing it, these methods run INSTEAD of
+// FinalizeClauses/FinalizePreloads, at the same points and in the same
+// registration order.
+//
+// Unlike the plain finalizers they can fail, and a failure fails closed: an
+// error from FinalizeClausesContext replaces the clause list with the
+// never-true clause, one from FinalizePreloadsContext replaces that relation's
+// conditions with it, and BuildContext (or BuildE) returns the error. A
+// policy that cannot be resolved — no tenant in the context — must return an
+// error rather than the list unchanged. Build and BuildE pass
+// context.Background(), so a resolver that requires a value refuses them.
+type ContextPlugin interface {
+	FinalizeClausesContext(ctx context.Context, f Figo, clauses []Expr) ([]Expr, error)
+	FinalizePreloadsContext(ctx context.Context, f Figo, relation string, conditions []Expr) ([]Expr, error)
+}
+
 // PluginManager manages plugins. Hooks run in REGISTRATION ORDER — the order
 // is part of the contract (iterating the map made every dispatch order random,
 // so two DSL-rewriting plugins composed differently from call to call).
@@ -385,25 +411,67 @@ func (pm *PluginManager) ExecuteExprFilters(f Figo, e Expr) Expr {
 }
 
 // ExecutePreloadFinalizers runs every registered plugin that implements
-// PreloadFinalizer over one relation's condition list.
+// PreloadFinalizer (or ContextPlugin, with context.Background()) over one
+// relation's condition list. A ContextPlugin error yields the never-true
+// condition; ExecutePreloadFinalizersContext reports it.
 func (pm *PluginManager) ExecutePreloadFinalizers(f Figo, relation string, conds []Expr) []Expr {
+	out, _ := pm.ExecutePreloadFinalizersContext(context.Background(), f, relation, conds)
+	return out
+}
+
+// ExecutePreloadFinalizersContext is ExecutePreloadFinalizers with the build's
+// context. Every hook runs even when an earlier one errors (errors are joined,
+// as ExecuteAfterParse does); any error makes the result the never-true
+// condition.
+func (pm *PluginManager) ExecutePreloadFinalizersContext(ctx context.Context, f Figo, relation string, conds []Expr) ([]Expr, error) {
+	var errs []error
 	for _, plugin := range pm.ListPlugins() {
-		if fin, ok := plugin.(PreloadFinalizer); ok {
+		if cp, ok := plugin.(ContextPlugin); ok {
+			out, err := cp.FinalizePreloadsContext(ctx, f, relation, conds)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("plugin %s FinalizePreloadsContext error (relation %q): %w", plugin.Name(), relation, err))
+				continue
+			}
+			conds = out
+		} else if fin, ok := plugin.(PreloadFinalizer); ok {
 			conds = fin.FinalizePreloads(f, relation, conds)
 		}
 	}
-	return conds
+	if len(errs) > 0 {
+		return []Expr{OrExpr{}}, errors.Join(errs...)
+	}
+	return conds, nil
 }
 
 // ExecuteClauseFinalizers runs every registered plugin that implements
-// ClauseFinalizer over the top-level clause list.
+// ClauseFinalizer (or ContextPlugin, with context.Background()) over the
+// top-level clause list. A ContextPlugin error yields the never-true clause;
+// ExecuteClauseFinalizersContext reports it.
 func (pm *PluginManager) ExecuteClauseFinalizers(f Figo, clauses []Expr) []Expr {
+	out, _ := pm.ExecuteClauseFinalizersContext(context.Background(), f, clauses)
+	return out
+}
+
+// ExecuteClauseFinalizersContext is ExecuteClauseFinalizers with the build's
+// context, under the error rules of ExecutePreloadFinalizersContext.
+func (pm *PluginManager) ExecuteClauseFinalizersContext(ctx context.Context, f Figo, clauses []Expr) ([]Expr, error) {
+	var errs []error
 	for _, plugin := range pm.ListPlugins() {
-		if fin, ok := plugin.(ClauseFinalizer); ok {
+		if cp, ok := plugin.(ContextPlugin); ok {
+			out, err := cp.FinalizeClausesContext(ctx, f, clauses)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("plugin %s FinalizeClausesContext error: %w", plugin.Name(), err))
+				continue
+			}
+			clauses = out
+		} else if fin, ok := plugin.(ClauseFinalizer); ok {
 			clauses = fin.FinalizeClauses(f, clauses)
 		}
 	}
-	return clauses
+	if len(errs) > 0 {
+		return []Expr{OrExpr{}}, errors.Join(errs...)
+	}
+	return clauses, nil
 }
 
 // ParseError represents a DSL parsing error with context
@@ -499,6 +567,12 @@ type OrderByColumn struct {
 // OrderBy is the parsed sort= directive: sort keys in priority order.
 type OrderBy struct{ Columns []OrderByColumn }
 
+// ScoreSortField is the relevance pseudo-column: sort=_score:desc orders a
+// full-text search by score. It names no stored field, so the parser keeps it
+// verbatim instead of passing it through the NamingFunc. Only the
+// Elasticsearch adapter has a score to sort by.
+const ScoreSortField = "_score"
+
 // Query is a marker interface for adapter-agnostic rendered queries
 // Concrete types are provided per adapter (e.g., SQLQuery, MongoFindQuery)
 // Query is the marker interface for adapter-agnostic rendered queries.
@@ -639,6 +713,46 @@ func (FullTextSearchExpr) isExpr() {}
 func (GeoDistanceExpr) isExpr()    {}
 func (CustomExpr) isExpr()         {}
 
+// Quantifier says how a RelationExpr's condition ranges over the related rows.
+type Quantifier string
+
+const (
+	QuantifierAny  Quantifier = "any"  // at least one related row matches
+	QuantifierAll  Quantifier = "all"  // every related row matches (vacuously true without any)
+	QuantifierNone Quantifier = "none" // no related row matches
+)
+
+// RelationExpr filters PARENT rows by their related rows — "users who have at
+// least one paid order" — which load= cannot say: a preload only narrows the
+// children it fetches and never changes which parents come back. The DSL
+// spells it with a quantifier on the relation name:
+//
+//	orders<any>[status="paid"]    // EXISTS a matching order
+//	orders<all>[status="paid"]    // no order fails the condition
+//	orders<none>[status="refunded"]
+//
+// Relation is kept verbatim, exactly like a load= relation name, because it
+// is a key into the adapter's relation configuration rather than a column;
+// the field names inside Cond go through the naming strategy like any other
+// filter. Cond is the condition on the RELATED row and may be nil
+// (orders<any>[] means "has at least one order").
+//
+// Each adapter needs to be told how the relation joins (RawAdapter/GormAdapter
+// Relations, the Mongo joins map, ElasticsearchAdapter Relations) and fails
+// the render for a relation it has no description of — guessing a join key
+// would silently correlate the wrong rows.
+//
+// <all> treats a related row whose condition is UNKNOWN (a NULL column) as
+// failing it, on every adapter: "every order is paid" is not true of an
+// order whose status is missing.
+type RelationExpr struct {
+	Relation   string
+	Quantifier Quantifier
+	Cond       Expr
+}
+
+func (RelationExpr) isExpr() {}
+
 // Figo is the query-building facade: feed it DSL (AddFiltersFromString) or
 // programmatic expressions (AddFilter), Build against an Adapter, then render
 // via GetSqlString/GetQuery or the adapter package's Build* helpers. Create
@@ -656,6 +770,12 @@ type Figo interface {
 	UnregisterPlugin(name string) error
 	SetNamingFunc(fn NamingFunc)
 	GetNamingFunc() NamingFunc
+	SetAliases(aliases *AliasRegistry)
+	GetAliases() *AliasRegistry
+	SetClock(clock func() time.Time)
+	SetTimeZone(loc *time.Location)
+	GetTimeZone() *time.Location
+	Now() time.Time
 	SetPage(skip, take int)
 	SetPageString(v string)
 	SetPageStringE(v string) error
@@ -671,6 +791,7 @@ type Figo interface {
 	GetQuery(ctx any, conditionType ...string) Query
 	Build(adapter Adapter)
 	BuildE(adapter Adapter) error
+	BuildContext(ctx context.Context, adapter Adapter) error
 	Explain() string
 	Clone() Figo
 	Walk(visit func(Expr))
@@ -695,10 +816,15 @@ type figo struct {
 	pluginManager *PluginManager
 	dsl           string
 	namingFunc    NamingFunc // never nil; SnakeCaseNaming by default
+	aliases       *AliasRegistry
+	bound         *boundDSL        // a Prepared template's parse with values bound; set by BindTo, cleared by a new DSL
+	clock         func() time.Time // resolves date-math literals; nil means time.Now
+	timeZone      *time.Location   // zone date-math literals round in; nil means UTC
 	adapterObj    Adapter
 	pageFromDSL   pageOrigin // WHICH page components came from a page= directive (vs SetPage); a DSL replacement resets only those
 	sortFromDSL   bool       // sort came from a sort= directive (vs SetSort), same rule as pageFromDSL
 	builtFromDSL  bool       // last Build materialized clause state from a DSL, so an empty-DSL rebuild must clear it
+	search        string     // text of a q= directive, reset by every Build; see searchDirective
 
 	// selectFieldsAsked is the projection the CALLER asked for, kept apart from
 	// the projection actually rendered. FieldsPlugin prunes the projection from
@@ -879,14 +1005,15 @@ func dslResourceGuard(expr string) string {
 // recorded into diags so BuildE can report what the built query does NOT
 // include.
 func (f *figo) parseDSL(expr string, diags *[]error) *Node {
-	return f.parseDSLDepth(expr, diags, 0)
+	return f.parseDSLDepth(expr, diags, 0, 0)
 }
 
 // parseDSLDepth is parseDSL with the load= nesting level. loadDepth > 0 means
 // the input is the CONTENT of a load=[...] directive, where a further load= is
 // not representable and must be skipped without being parsed (see the branch
-// below).
-func (f *figo) parseDSLDepth(expr string, diags *[]error, loadDepth int) *Node {
+// below). relDepth counts the enclosing rel<any>[...] conditions (see
+// splitRelationToken).
+func (f *figo) parseDSLDepth(expr string, diags *[]error, loadDepth, relDepth int) *Node {
 	root := &Node{Value: "root", Expression: make([]Expr, 0)}
 	stack := []*Node{root}
 	current := root
@@ -1006,8 +1133,11 @@ func (f *figo) parseDSLDepth(expr string, diags *[]error, loadDepth int) *Node {
 						// "price<bet>(10..20)and b=2" used to keep scanning and
 						// produce the token "price<bet>(10..20)and", whose bounds
 						// typed as the strings "(10" and "20)and" while the OR/AND
-						// connector was swallowed — silently, diag=nil.
-						if parenDepth == 0 {
+						// connector was swallowed — silently, diag=nil. Inside a
+						// bracket the group belongs to the bracketed value
+						// (orders<any>[(a=1 or b=2) and c=3]), which only ']'
+						// can end.
+						if parenDepth == 0 && bracketDepth == 0 {
 							break
 						}
 						continue
@@ -1064,7 +1194,7 @@ func (f *figo) parseDSLDepth(expr string, diags *[]error, loadDepth int) *Node {
 				// Require the '=' so ordinary field names that merely start with
 				// these keywords (sortOrder, pageCount, loadedAt) are parsed as
 				// filters rather than swallowed as sort/page/load directives.
-				if strings.HasPrefix(token, string(OperationSort)+"=") || strings.HasPrefix(token, string(OperationPage)+"=") || strings.HasPrefix(token, string(OperationLoad)+"=") {
+				if strings.HasPrefix(token, string(OperationSort)+"=") || strings.HasPrefix(token, string(OperationPage)+"=") || strings.HasPrefix(token, string(OperationLoad)+"=") || f.searchDirective(token) {
 					// Record the directive's POSITION in the token stream (see
 					// operationDirective): it is not an operand, so the precedence
 					// pass has to absorb the connector written next to it and any
@@ -1072,7 +1202,20 @@ func (f *figo) parseDSLDepth(expr string, diags *[]error, loadDepth int) *Node {
 					// and continues, so this is the single place to mark it.
 					current.Children = append(current.Children, &Node{Operator: operationDirective, Value: token, Parent: current})
 
-					if strings.HasPrefix(token, string(OperationLoad)+"=") {
+					if f.searchDirective(token) {
+						text, _ := unquoteLiteral(token[len(OperationSearch)+1:])
+						if strings.TrimSpace(text) == "" {
+							addDiag(diags, "empty q= directive")
+						} else {
+							if f.search != "" {
+								addDiag(diags, "q= directive %q overrides an earlier q= in the same DSL", token)
+							}
+							f.search = text
+						}
+						i = j
+						continue
+
+					} else if strings.HasPrefix(token, string(OperationLoad)+"=") {
 						loadLabel := fmt.Sprintf("%v=[", string(OperationLoad))
 						// Without the '[' there is no bracket to balance: the scan
 						// below starts at bracketCount 1 and would hunt a ']' all
@@ -1191,8 +1334,8 @@ func (f *figo) parseDSLDepth(expr string, diags *[]error, loadDepth int) *Node {
 							// LIMIT/OFFSET, and a nested load= a top-level preload).
 							// Preloads have no sort/page/nested-load representation,
 							// so those directives are dropped with a diagnostic.
-							scratch := &figo{preloads: make(map[string][]Expr), selectFields: make(map[string]bool), namingFunc: f.namingFunc}
-							loadRootNode := scratch.parseDSLDepth(loadContent, diags, loadDepth+1)
+							scratch := &figo{preloads: make(map[string][]Expr), selectFields: make(map[string]bool), namingFunc: f.namingFunc, adapterObj: f.adapterObj}
+							loadRootNode := scratch.parseDSLDepth(loadContent, diags, loadDepth+1, relDepth)
 							if scratch.sort != nil {
 								addDiag(diags, "sort= inside load=[%s:...] is not supported and was ignored", table)
 							}
@@ -1201,6 +1344,9 @@ func (f *figo) parseDSLDepth(expr string, diags *[]error, loadDepth int) *Node {
 								// against its zero value missed page=skip:0,take:0.
 								addDiag(diags, "page= inside load=[%s:...] is not supported and was ignored", table)
 							}
+							if scratch.search != "" {
+								addDiag(diags, "q= inside load=[%s:...] is not supported and was ignored", table)
+							}
 							// (A nested load= is reported by the depth guard above,
 							// which skips it without parsing, so scratch.preloads
 							// can no longer become non-empty.)
@@ -1312,8 +1458,16 @@ func (f *figo) parseDSLDepth(expr string, diags *[]error, loadDepth int) *Node {
 							if dir := strings.ToLower(value); dir != "asc" && dir != "desc" {
 								addDiag(diags, "invalid sort direction %q for field %q (expected asc or desc)", value, field)
 							}
+							name := field
+							if field != ScoreSortField {
+								name = f.parsFieldsName(field)
+							} else if store := AdapterStore(f.adapterObj); store != "" && store != StoreElasticsearch {
+								// SQL would sort by a column named _score.
+								addDiag(diags, "sort=%s: only Elasticsearch has a relevance score to sort by; the sort key was dropped", ScoreSortField)
+								continue
+							}
 							c = append(c, OrderByColumn{
-								Name: f.parsFieldsName(field),
+								Name: name,
 								Desc: strings.ToLower(value) == "desc",
 							})
 
@@ -1348,8 +1502,11 @@ func (f *figo) parseDSLDepth(expr string, diags *[]error, loadDepth int) *Node {
 					}
 
 					// Unreachable: the enclosing condition guarantees the token
-					// starts with load=, page= or sort=, and every one of those
-					// branches advances i and continues.
+					// starts with q=, load=, page= or sort=, and every one of
+					// those branches advances i and continues.
+				} else if relation, quantifier, content, ok := splitRelationToken(token); ok {
+					current.Children = f.appendRelationNode(current, relation, quantifier, content, diags, loadDepth, relDepth)
+					i = j
 				} else {
 					// Try to combine tokens for expressions like "field > value" or "field =^ value"
 					// Only do this for very specific cases to avoid interfering with complex operators
@@ -1594,6 +1751,90 @@ func (f *figo) parseDSLDepth(expr string, diags *[]error, loadDepth int) *Node {
 	return root
 }
 
+// maxRelationDepth bounds how deeply rel<any>[...] conditions may nest. Each
+// level re-parses its content on a scratch instance, so without a bound the
+// cost of a deeply nested input grows with the square of its depth — the same
+// shape as the nested load= DoS — and no real schema chains relations this far.
+const maxRelationDepth = 8
+
+// relationQuantifiers maps the DSL spellings to their quantifier.
+var relationQuantifiers = []struct {
+	op Operation
+	q  Quantifier
+}{
+	{OperationAny, QuantifierAny},
+	{OperationAll, QuantifierAll},
+	{OperationNone, QuantifierNone},
+}
+
+// splitRelationToken recognizes a quantified relation predicate,
+// `orders<any>[status="paid"]`, and returns its relation name, quantifier and
+// bracket content. The tokenizer has already made the whole bracket one token
+// (it tracks bracket depth and quotes), so only the shape is checked here: a
+// plain relation name, a quantifier, and a value that is exactly one bracket.
+// Anything else falls through to the ordinary operator parser.
+func splitRelationToken(token string) (string, Quantifier, string, bool) {
+	idx := indexOutsideQuotes(token, "<")
+	if idx <= 0 || !strings.HasSuffix(token, "]") {
+		return "", "", "", false
+	}
+	relation := token[:idx]
+	if !isSimpleFieldName(relation) {
+		return "", "", "", false
+	}
+	rest := token[idx:]
+	for _, rq := range relationQuantifiers {
+		if strings.HasPrefix(rest, string(rq.op)+"[") {
+			return relation, rq.q, rest[len(rq.op)+1 : len(rest)-1], true
+		}
+	}
+	return "", "", "", false
+}
+
+// appendRelationNode parses the condition of a quantified relation predicate
+// and appends the resulting node to current's children, returning the new
+// child list. The condition is a full DSL expression parsed on a scratch
+// instance, exactly like a load= segment, so a sort=/page=/load= written
+// inside it cannot reach the outer query; none of them means anything for a
+// relation predicate, so each is dropped with a diagnostic.
+func (f *figo) appendRelationNode(current *Node, relation string, quantifier Quantifier, content string, diags *[]error, loadDepth, relDepth int) []*Node {
+	if relDepth >= maxRelationDepth {
+		addDiag(diags, "%s<%s>[...] nests relation predicates deeper than %d levels and was ignored", relation, quantifier, maxRelationDepth)
+		dropDanglingNot(current, diags)
+		return current.Children
+	}
+	var cond Expr
+	if strings.TrimSpace(content) != "" {
+		scratch := &figo{preloads: make(map[string][]Expr), selectFields: make(map[string]bool), namingFunc: f.namingFunc, adapterObj: f.adapterObj}
+		// loadDepth+1: a load= inside the condition is skipped unparsed, as
+		// it is inside load=[...].
+		root := scratch.parseDSLDepth(content, diags, loadDepth+1, relDepth+1)
+		if scratch.sort != nil {
+			addDiag(diags, "sort= inside %s<%s>[...] is not supported and was ignored", relation, quantifier)
+		}
+		if scratch.pageFromDSL != 0 {
+			addDiag(diags, "page= inside %s<%s>[...] is not supported and was ignored", relation, quantifier)
+		}
+		if scratch.search != "" {
+			addDiag(diags, "q= inside %s<%s>[...] is not supported and was ignored", relation, quantifier)
+		}
+		expressionParser(root, diags)
+		cond = getFinalExpr(*root)
+		if cond == nil {
+			// An unparseable condition must not quietly turn into the bare
+			// existence test: orders<none>[garbage] would then demand that the
+			// parent has no orders at all. Drop the predicate, as any other
+			// invalid condition is dropped.
+			addDiag(diags, "%s<%s>[...] condition %q produced no conditions; predicate ignored", relation, quantifier, content)
+			dropDanglingNot(current, diags)
+			return current.Children
+		}
+	}
+	expr := RelationExpr{Relation: relation, Quantifier: quantifier, Cond: cond}
+	node := &Node{Operator: Operation("<" + string(quantifier) + ">"), Value: content, Field: relation, Parent: current, Expression: []Expr{expr}}
+	return append(current.Children, node)
+}
+
 // dropDanglingNot removes trailing "not" operator nodes left behind when the
 // condition they were about to negate is dropped as invalid. Left in place,
 // the not would attach to the NEXT predicate and invert its meaning.
@@ -1608,6 +1849,39 @@ func dropDanglingNot(current *Node, diags *[]error) {
 	}
 }
 
+// isSearchDirective reports whether token has the form of a q= full-text
+// search directive (q=shoes, q="red shoes"). The search text becomes a
+// field-less FullTextSearchExpr ANDed with the filter (see Build), which the
+// Elasticsearch adapter renders as multi_match.
+//
+// q=^ and q=~ stay a LIKE and a regex on a field named q: =^ and =~ are
+// operators in their own right, and reading them as a search for "^..." would
+// silently change what an existing filter means.
+func isSearchDirective(token string) bool {
+	prefix := string(OperationSearch) + "="
+	if !strings.HasPrefix(token, prefix) {
+		return false
+	}
+	rest := token[len(prefix):]
+	return rest == "" || (rest[0] != '^' && rest[0] != '~' && rest[0] != '=')
+}
+
+// searchDirective reports whether token is a q= directive for this build.
+// q= searches only on Elasticsearch, the store that ranks a search by
+// relevance. On every other store, and with no adapter set, q=shoes is what
+// it was before q= existed: a filter on a field named q. f.mu must be held.
+func (f *figo) searchDirective(token string) bool {
+	return AdapterStore(f.adapterObj) == StoreElasticsearch && isSearchDirective(token)
+}
+
+// searchStore stands in for an Elasticsearch adapter where a parse has to be
+// made for that store without one; it renders nothing.
+type searchStore struct{}
+
+func (searchStore) Store() string                                    { return StoreElasticsearch }
+func (searchStore) GetSqlString(Figo, any, ...string) (string, bool) { return "", false }
+func (searchStore) GetQuery(Figo, any, ...string) (Query, bool)      { return nil, false }
+
 func (f *figo) parsFieldsName(str string) string {
 	return convertFieldName(f.namingFunc, str)
 }
@@ -1628,8 +1902,16 @@ func convertFieldName(fn NamingFunc, str string) string {
 // bool/null/int64/float64/date detection. Use it to coerce a value outside
 // the DSL (e.g. one incoming parameter) the way figo would — a=1 and a="1"
 // render different SQL, so matching the DSL's typing matters.
+//
+// A date-math literal (now-7d, startOfMonth) is resolved against time.Now in
+// UTC, there being no instance whose clock and time zone would apply.
 func ParseValue(str string) any {
-	return parseScalarLiteral(str)
+	v := parseScalarLiteral(str)
+	if lit, ok := v.(dateMathLiteral); ok {
+		t, _ := resolveDateMath(string(lit), time.Now().UTC())
+		return t
+	}
+	return v
 }
 
 // mayBeDate is a cheap shape gate in front of parseDate's layout probe. The
@@ -1842,6 +2124,9 @@ func parseScalarLiteral(raw string) any {
 	if s == "null" || s == "NULL" {
 		return nil
 	}
+	if isDateMath(s) {
+		return dateMathLiteral(s)
+	}
 	if s != "" {
 		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
 			return i
@@ -2454,6 +2739,7 @@ func (f *figo) AddFiltersFromString(input string) error {
 	if strings.TrimSpace(input) == "" {
 		f.mu.Lock()
 		f.dsl = ""
+		f.bound = nil
 		if f.builtFromDSL {
 			f.clauses = []Expr{}
 			f.clausesAsked = nil
@@ -2494,6 +2780,7 @@ func (f *figo) AddFiltersFromString(input string) error {
 	// ignores the returned error.
 	f.mu.Lock()
 	f.dsl = input
+	f.bound = nil
 	f.mu.Unlock()
 
 	// Execute AfterParse plugin hooks (error returned unwrapped, as above).
@@ -2548,6 +2835,7 @@ func (f *figo) refuseDSL() {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	f.dsl = ""
+	f.bound = nil
 	f.clauses = []Expr{OrExpr{}}
 	f.clausesAsked = []Expr{OrExpr{}}
 	f.preloads = make(map[string][]Expr)
@@ -2881,6 +3169,21 @@ func (f *figo) GetNamingFunc() NamingFunc {
 	return f.namingFunc
 }
 
+// SetAliases installs the alias registry the next Build rewrites the DSL's
+// public field names with (nil removes it). See AliasRegistry.
+func (f *figo) SetAliases(aliases *AliasRegistry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.aliases = aliases
+}
+
+// GetAliases returns the installed alias registry, or nil.
+func (f *figo) GetAliases() *AliasRegistry {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.aliases
+}
+
 func (f *figo) SetAdapterObject(adapter Adapter) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -3012,6 +3315,19 @@ func (f *figo) Build(adapter Adapter) {
 // should treat a non-nil error as a rejection rather than running the
 // (broader) query that remains.
 func (f *figo) BuildE(adapter Adapter) error {
+	return f.BuildContext(context.Background(), adapter)
+}
+
+// BuildContext is BuildE with a context for the plugins: every ContextPlugin
+// finalizer receives ctx, so request-scoped policy (the caller's tenant, their
+// roles, a deadline) is resolved at finalize time from the request itself. A
+// ContextPlugin error fails the build closed — the affected clause list or
+// preload renders nothing — and is returned joined with the parse
+// diagnostics. A nil ctx means context.Background().
+func (f *figo) BuildContext(ctx context.Context, adapter Adapter) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	f.mu.Lock()
 
 	// A non-nil adapter selects/replaces the adapter; passing nil rebuilds
@@ -3046,8 +3362,7 @@ func (f *figo) BuildE(adapter Adapter) error {
 		// Even with no DSL, clause finalizers must run (a ScopePlugin's
 		// mandatory filter applies to unfiltered queries too).
 		defer f.guardPluginPanic()
-		f.finalizeClauses()
-		return nil
+		return f.finalizeClauses(ctx)
 	}
 
 	// Clear all DSL-derived state before rebuilding so Build is idempotent:
@@ -3062,6 +3377,7 @@ func (f *figo) BuildE(adapter Adapter) error {
 		f.sortFromDSL = false
 	}
 	f.resetDSLPage()
+	f.search = ""
 	f.builtFromDSL = true
 
 	var diags []error
@@ -3075,10 +3391,13 @@ func (f *figo) BuildE(adapter Adapter) error {
 		addDiag(&diags, "%s; the input was not parsed and the query matches nothing", reason)
 		finalExpr = OrExpr{}
 		guardTripped = true
+	} else if f.bound != nil {
+		// A Prepared template bound to this instance: its parse, with the
+		// caller's values in place, stands in for parsing the DSL (which is
+		// the template, placeholders and all). See BindTo.
+		finalExpr = f.bound.apply(f)
 	} else {
-		root := f.parseDSL(f.dsl, &diags)
-		expressionParser(root, &diags)
-		finalExpr = getFinalExpr(*root)
+		finalExpr = f.parseFinal(f.dsl, &diags)
 	}
 
 	// Detach the freshly parsed preloads so plugin filters can run on them
@@ -3087,8 +3406,58 @@ func (f *figo) BuildE(adapter Adapter) error {
 	preloads := f.preloads
 	f.preloads = make(map[string][]Expr)
 	pm := f.pluginManager
+	aliases, naming, store := f.aliases, f.namingFunc, AdapterStore(f.adapterObj)
 	f.mu.Unlock()
 
+	// Public names become physical ones before any plugin sees the tree, as
+	// the NamingFunc's conversions do. An alias that cannot be resolved fails
+	// closed like the resource guard: the whole filter, or the one preload.
+	if aliases != nil && !guardTripped {
+		l := aliases.lookup(naming, store)
+		if finalExpr != nil {
+			rewritten, err := l.rewrite(finalExpr, "")
+			if err != nil {
+				diags = append(diags, fmt.Errorf("%w; the query matches nothing", err))
+				rewritten = OrExpr{}
+				guardTripped = true
+			}
+			finalExpr = rewritten
+		}
+		for table, exprs := range preloads {
+			for i, e := range exprs {
+				rewritten, err := l.rewrite(e, table+".")
+				if err != nil {
+					diags = append(diags, fmt.Errorf("%w; preload %s is not loaded", err, table))
+					delete(preloads, table)
+					break
+				}
+				exprs[i] = rewritten
+			}
+		}
+		f.mu.Lock()
+		if f.sortFromDSL && f.sort != nil {
+			var dropped []string
+			f.sort, dropped = l.rewriteSort(f.sort)
+			for _, name := range dropped {
+				addDiag(&diags, "alias %q: a virtual field cannot be sorted on; the sort key was dropped", name)
+			}
+		}
+		f.mu.Unlock()
+	}
+
+	// Date-math literals become instants here, against one now per Build, so
+	// every condition — an alias template's included — sees the same now and
+	// no plugin sees a literal.
+	now := f.Now()
+	if finalExpr != nil && !guardTripped {
+		finalExpr = resolveDateMathValues(finalExpr, now, store)
+	}
+	for _, exprs := range preloads {
+		for i, e := range exprs {
+			exprs[i] = resolveDateMathValues(e, now, store)
+		}
+	}
+
 	// An empty manager dispatches nothing, so it must not take the plugin path
 	// at all: GetPluginManager creates the manager on first call, and a getter
 	// must not change what the next Build produces.
@@ -3141,7 +3510,10 @@ func (f *figo) BuildE(adapter Adapter) error {
 		sort.Strings(rels)
 		for _, table := range rels {
 			before := preloads[table]
-			final := pm.ExecutePreloadFinalizers(f, table, before)
+			final, err := pm.ExecutePreloadFinalizersContext(ctx, f, table, before)
+			if err != nil {
+				diags = append(diags, err)
+			}
 			// Only a finalizer that actually EMPTIED a non-empty list drops the
 			// relation. An unconditioned preload ("load=[Orders:]", or a segment
 			// whose filter yielded no conditions) legitimately carries zero
@@ -3168,11 +3540,33 @@ func (f *figo) BuildE(adapter Adapter) error {
 	f.preloads = preloads
 	f.mu.Unlock()
 
-	f.finalizeClauses()
+	if err := f.finalizeClauses(ctx); err != nil {
+		diags = append(diags, err)
+	}
 
 	return errors.Join(diags...)
 }
 
+// parseFinal parses dsl into its filter expression (nil when it has none),
+// leaving what its load=, sort= and page= directives say on f. f.mu must be
+// held.
+func (f *figo) parseFinal(dsl string, diags *[]error) Expr {
+	root := f.parseDSL(dsl, diags)
+	expressionParser(root, diags)
+	finalExpr := getFinalExpr(*root)
+	if f.search != "" {
+		// q= narrows like any other term: it is ANDed with the whole
+		// filter, wherever in the DSL it was written.
+		search := FullTextSearchExpr{Query: f.search}
+		if finalExpr == nil {
+			finalExpr = search
+		} else {
+			finalExpr = AndExpr{Operands: []Expr{finalExpr, search}}
+		}
+	}
+	return finalExpr
+}
+
 // guardPluginPanic is deferred around plugin hook execution in BuildE: Build
 // clears the instance state BEFORE the fallible hooks run, so a caller that
 // recovers a plugin panic (e.g. HTTP middleware) would otherwise keep an
@@ -3196,10 +3590,11 @@ func (f *figo) guardPluginPanic() {
 	}
 }
 
-// finalizeClauses runs registered ClauseFinalizer plugins over the top-level
-// clause list and writes the result back. Runs outside the lock (a finalizer
-// may call back into read methods).
-func (f *figo) finalizeClauses() {
+// finalizeClauses runs registered ClauseFinalizer and ContextPlugin plugins
+// over the top-level clause list and writes the result back, returning any
+// ContextPlugin error (the list is then the never-true clause). Runs outside
+// the lock (a finalizer may call back into read methods).
+func (f *figo) finalizeClauses(ctx context.Context) error {
 	f.mu.RLock()
 	pm := f.pluginManager
 	f.mu.RUnlock()
@@ -3207,7 +3602,7 @@ func (f *figo) finalizeClauses() {
 	// hasPlugins (GetPluginManager creates the manager lazily, and a getter must
 	// not change what the next Build produces).
 	if !pm.hasPlugins() {
-		return
+		return nil
 	}
 
 	f.mu.Lock()
@@ -3245,11 +3640,12 @@ func (f *figo) finalizeClauses() {
 		f.mu.Unlock()
 	}()
 
-	finalized := pm.ExecuteClauseFinalizers(f, f.GetClauses())
+	finalized, err := pm.ExecuteClauseFinalizersContext(ctx, f, f.GetClauses())
 
 	f.mu.Lock()
 	f.clauses = finalized
 	f.mu.Unlock()
+	return err
 }
 
 // exprField returns the field a leaf expression filters on, or "" for
@@ -3296,6 +3692,11 @@ func exprField(expr Expr) string {
 		return e.Field
 	case CustomExpr:
 		return e.Field
+	case RelationExpr:
+		// The relation name is what the predicate addresses at THIS level, so
+		// field policy (FieldsPlugin) and identifier screening see it; the
+		// related row's own fields live in Cond and are reached by recursion.
+		return e.Relation
 	default:
 		return ""
 	}
@@ -3317,6 +3718,57 @@ func ExprField(e Expr) string {
 	return exprField(e)
 }
 
+// ExprOperator returns a leaf expression's operator as the DSL spells it
+// ("=", "!=", "<in>", "=^", ...), with "q" for full-text search and
+// "json", "array_contains", "array_overlaps", "geo" and "custom" for the
+// expressions the DSL has no operator for. It reports false for logical
+// nodes and unknown types.
+func ExprOperator(e Expr) (string, bool) {
+	switch e.(type) {
+	case EqExpr:
+		return "=", true
+	case NeqExpr:
+		return "!=", true
+	case GtExpr:
+		return ">", true
+	case GteExpr:
+		return ">=", true
+	case LtExpr:
+		return "<", true
+	case LteExpr:
+		return "<=", true
+	case LikeExpr:
+		return "=^", true
+	case ILikeExpr:
+		return ".=^", true
+	case RegexExpr:
+		return "=~", true
+	case InExpr:
+		return "<in>", true
+	case NotInExpr:
+		return "<nin>", true
+	case BetweenExpr:
+		return "<bet>", true
+	case IsNullExpr:
+		return "<null>", true
+	case NotNullExpr:
+		return "<notnull>", true
+	case FullTextSearchExpr:
+		return "q", true
+	case JsonPathExpr:
+		return "json", true
+	case ArrayContainsExpr:
+		return "array_contains", true
+	case ArrayOverlapsExpr:
+		return "array_overlaps", true
+	case GeoDistanceExpr:
+		return "geo", true
+	case CustomExpr:
+		return "custom", true
+	}
+	return "", false
+}
+
 // CloneExpr returns an independent deep copy of an expression tree.
 func CloneExpr(e Expr) Expr {
 	return cloneExpr(e)
@@ -3422,6 +3874,13 @@ func normalizeExprFields(e Expr, naming func(string) string) Expr {
 	case CustomExpr:
 		v.Field = normalizeFieldName(v.Field, naming)
 		return v
+	case RelationExpr:
+		// The relation name stays verbatim (it keys the adapters' relation
+		// configuration, like a load= name); the related row's fields convert.
+		if v.Cond != nil {
+			v.Cond = normalizeExprFields(v.Cond, naming)
+		}
+		return v
 	default:
 		// Unknown type: pass through rather than dropping it.
 		return e
@@ -3491,6 +3950,22 @@ func pruneExprFields(expr Expr, keep func(field string) bool) Expr {
 			return nil
 		}
 		return NotExpr{Operands: operands}
+	case RelationExpr:
+		if !keep(e.Relation) {
+			return nil
+		}
+		if e.Cond == nil {
+			return e
+		}
+		// A condition pruned away entirely drops the predicate rather than
+		// leaving the bare existence test behind: orders<none>[secret=1]
+		// must not become "has no orders at all".
+		cond := pruneExprFields(e.Cond, keep)
+		if cond == nil {
+			return nil
+		}
+		e.Cond = cond
+		return e
 	default:
 		if field := exprField(e); field != "" && !keep(field) {
 			return nil
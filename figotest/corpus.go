@@ -0,0 +1,143 @@
+package figotest
+
+// Item is a row of the fixture's items table; nil pointers are NULL. Orders
+// holds the item's rows of the orders table, for adapters that store them
+// embedded (a document store, a Go slice) rather than in a table of their own.
+type Item struct {
+	ID     int64    `json:"id"`
+	Name   *string  `json:"name"`
+	Age    *int64   `json:"age"`
+	Score  *float64 `json:"score"`
+	Active *bool    `json:"active"`
+	Orders []Order  `json:"orders" gorm:"-"`
+}
+
+// Order is a row of the fixture's orders table, the related rows of the
+// "orders" relation. ItemID references Item.ID.
+type Order struct {
+	ID     int64   `json:"id"`
+	ItemID int64   `json:"item_id"`
+	Total  *int64  `json:"total"`
+	Status *string `json:"status"`
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// Items returns a fresh copy of the fixture, orders embedded. The values are
+// chosen to make adapters disagree where they can: NULL in every column, an
+// empty string, a zero, duplicate sort keys, LIKE wildcards inside a value,
+// mixed case, an item without orders and an order with a NULL total.
+func Items() []Item {
+	items := []Item{
+		{ID: 1, Name: ptr("alice"), Age: ptr[int64](30), Score: ptr(9.5), Active: ptr(true)},
+		{ID: 2, Name: ptr("Bob"), Age: ptr[int64](41), Score: ptr(7.0), Active: ptr(false)},
+		{ID: 3, Name: ptr("carol"), Score: ptr(8.25), Active: ptr(true)},
+		{ID: 4, Name: ptr("dave"), Age: ptr[int64](30)},
+		{ID: 5, Age: ptr[int64](25), Score: ptr(5.0), Active: ptr(false)},
+		{ID: 6, Name: ptr(""), Age: ptr[int64](0), Score: ptr(0.0), Active: ptr(true)},
+		{ID: 7, Name: ptr("a_b%c"), Age: ptr[int64](52), Score: ptr(9.5), Active: ptr(true)},
+	}
+	orders := []Order{
+		{ID: 1, ItemID: 1, Total: ptr[int64](120), Status: ptr("paid")},
+		{ID: 2, ItemID: 1, Total: ptr[int64](30), Status: ptr("refunded")},
+		{ID: 3, ItemID: 2, Total: ptr[int64](15), Status: ptr("paid")},
+		{ID: 4, ItemID: 3, Status: ptr("paid")},
+		{ID: 5, ItemID: 4, Total: ptr[int64](80)},
+		{ID: 6, ItemID: 7, Total: ptr[int64](60), Status: ptr("paid")},
+	}
+	for _, o := range orders {
+		items[o.ItemID-1].Orders = append(items[o.ItemID-1].Orders, o)
+	}
+	return items
+}
+
+// Case is one DSL query of the corpus.
+type Case struct {
+	// Name identifies the case in results and in Harness.Skip.
+	Name string
+	// DSL is the query, written against the fixture's column names.
+	DSL string
+	// Ordered cases sort (and possibly page) with a total order, so the
+	// result order is compared too; the others compare matched sets.
+	Ordered bool
+	// Features lists what beyond plain comparisons the case exercises, so an
+	// adapter can skip a whole feature in Harness.Skip:
+	//
+	//	null-order  sorts a column holding NULL (SQLite: NULLs first ascending)
+	//	regex       =~
+	//	ilike       .=^
+	//	relation    a quantified relation predicate over "orders"
+	Features []string
+}
+
+// Corpus returns the DSL cases, in a stable order.
+func Corpus() []Case {
+	return []Case{
+		{Name: "eq-int", DSL: `age=30`},
+		{Name: "eq-string", DSL: `name="alice"`},
+		{Name: "eq-string-case", DSL: `name="bob"`},
+		{Name: "eq-empty-string", DSL: `name=""`},
+		{Name: "eq-bool", DSL: `active=true`},
+		{Name: "eq-false", DSL: `active=false`},
+		{Name: "eq-float", DSL: `score=9.5`},
+		{Name: "neq", DSL: `age!=30`},
+		{Name: "gt", DSL: `age>30`},
+		{Name: "gte", DSL: `age>=30`},
+		{Name: "lt", DSL: `age<30`},
+		{Name: "lte-float", DSL: `score<=8.25`},
+		{Name: "gt-string", DSL: `name>"b"`},
+
+		{Name: "is-null", DSL: `age<null>`},
+		{Name: "not-null", DSL: `age<notnull>`},
+		{Name: "eq-null", DSL: `name=null`},
+		{Name: "neq-null", DSL: `active!=null`},
+
+		{Name: "not-eq", DSL: `not (age=30)`},
+		{Name: "not-or", DSL: `not (age>30 or score<8)`},
+		{Name: "not-null-test", DSL: `not (name<null>)`},
+		{Name: "not-not", DSL: `not (not (active=true))`},
+
+		{Name: "and-binds-tighter", DSL: `age=30 or age=41 and active=true`},
+		{Name: "grouped-or", DSL: `(age=30 or age=41) and active=true`},
+		{Name: "mixed", DSL: `age>20 and score>5 or name="carol"`},
+
+		{Name: "in", DSL: `age<in>[30,41]`},
+		{Name: "in-with-null", DSL: `age<in>[30,null]`},
+		{Name: "in-strings", DSL: `name<in>["alice","Bob"]`},
+		{Name: "nin", DSL: `age<nin>[30,41]`},
+		{Name: "nin-with-null", DSL: `age<nin>[30,null]`},
+
+		{Name: "between", DSL: `age<bet>(25..41)`},
+		{Name: "between-float", DSL: `score<bet>(5..8.25)`},
+		{Name: "not-between", DSL: `not (age<bet>(25..41))`},
+
+		{Name: "like-contains", DSL: `name=^"%a%"`},
+		{Name: "like-single", DSL: `name=^"_ob"`},
+		{Name: "like-wildcard-in-value", DSL: `name=^"a_b%"`},
+		{Name: "like-is-case-sensitive", DSL: `name=^"ALICE"`},
+		{Name: "like-anchored", DSL: `name=^"lic"`},
+		{Name: "not-like", DSL: `name!=^"%o%"`},
+
+		{Name: "ilike", DSL: `name.=^"bob"`, Features: []string{"ilike"}},
+		{Name: "ilike-contains", DSL: `name.=^"%O%"`, Features: []string{"ilike"}},
+
+		{Name: "regex", DSL: `name=~"^[a-c]"`, Features: []string{"regex"}},
+		{Name: "regex-unanchored", DSL: `name=~"o"`, Features: []string{"regex"}},
+		{Name: "not-regex", DSL: `not (name=~"e$")`, Features: []string{"regex"}},
+
+		{Name: "rel-any", DSL: `orders<any>[status="paid"]`, Features: []string{"relation"}},
+		{Name: "rel-all", DSL: `orders<all>[status="paid"]`, Features: []string{"relation"}},
+		{Name: "rel-all-null", DSL: `orders<all>[total>10]`, Features: []string{"relation"}},
+		{Name: "rel-none", DSL: `orders<none>[total>100]`, Features: []string{"relation"}},
+		{Name: "rel-exists", DSL: `orders<any>[]`, Features: []string{"relation"}},
+		{Name: "rel-not-any", DSL: `not orders<any>[total<50]`, Features: []string{"relation"}},
+		{Name: "rel-and-filter", DSL: `active=true and orders<any>[total>=60]`, Features: []string{"relation"}},
+
+		{Name: "sort-asc", DSL: `sort=name:asc,id:asc`, Ordered: true, Features: []string{"null-order"}},
+		{Name: "sort-desc", DSL: `sort=score:desc,id:asc`, Ordered: true, Features: []string{"null-order"}},
+		{Name: "sort-filtered", DSL: `active=true sort=name:asc`, Ordered: true},
+		{Name: "sort-page", DSL: `age<notnull> sort=age:desc,id:desc page=skip:2,take:3`, Ordered: true},
+		{Name: "page-skip", DSL: `sort=id:asc page=skip:5`, Ordered: true},
+		{Name: "page-take", DSL: `sort=id:desc page=skip:0,take:2`, Ordered: true},
+	}
+}
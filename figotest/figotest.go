@@ -0,0 +1,180 @@
+// Package figotest lets the author of a figo.Adapter prove that it means what
+// the built-in adapters mean. Rendering a query is easy to test and says
+// little; what matters is that the rendered query selects the same rows. The
+// package ships a fixture (Items), a corpus of DSL cases (Corpus) chosen where
+// backends tend to disagree — NULL under negation, NOT IN with a NULL, LIKE
+// wildcards and case, <bet> bounds, relation quantifiers, NULL sort order —
+// and a harness that runs each case through the adapter under test and
+// through the raw adapter on SQLite, and compares the matched rows:
+//
+//	func TestMyAdapterConforms(t *testing.T) {
+//	    store := loadIntoMyStore(figotest.Items())
+//	    figotest.Run(t, figotest.Harness{
+//	        Adapter: MyAdapter{},
+//	        Query: func(f figo.Figo) ([]int64, error) {
+//	            return store.IDs(f.GetQuery(nil))
+//	        },
+//	        Skip: map[string]string{"regex": "the store has no regex operator"},
+//	    })
+//	}
+//
+// The reference is SQL's three-valued logic as the raw adapter renders it,
+// on SQLite with case-sensitive LIKE and a Go-syntax REGEXP (see OpenSQLite).
+// A deliberate divergence belongs in Harness.Skip with its reason, so it stays
+// documented rather than silently tolerated.
+package figotest
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	figo "github.com/bi0dread/figo/v4"
+	"github.com/bi0dread/figo/v4/adapters"
+)
+
+// Relations describes the fixture's "orders" relation for the SQL adapters;
+// a SQL adapter under test can be configured with it too.
+var Relations = map[string]adapters.SQLRelation{
+	"orders": {Table: "orders", ForeignKey: "item_id"},
+}
+
+// Harness is the adapter under test.
+type Harness struct {
+	// Adapter is what each case's figo instance is built with.
+	Adapter figo.Adapter
+	// Query runs a built instance against the adapter's copy of the fixture
+	// and returns the ids of the matched items, in result order.
+	Query func(f figo.Figo) ([]int64, error)
+	// Skip maps a case name or a Case feature to the reason the adapter does
+	// not support it.
+	Skip map[string]string
+	// Configure, when set, prepares every new instance — the reference's and
+	// the adapter's alike — before the DSL is parsed (a naming func, plugins).
+	Configure func(f figo.Figo)
+}
+
+// Result is the outcome of one case.
+type Result struct {
+	Case Case
+	// Want holds the reference's ids and Got the adapter's, both in result
+	// order.
+	Want, Got []int64
+	// Err is the adapter's build or query error.
+	Err error
+	// Skipped is the Harness.Skip reason, when the case was skipped.
+	Skipped string
+}
+
+// OK reports whether the adapter agreed with the reference (or was skipped):
+// the same ids in the same order for an Ordered case, the same set otherwise.
+func (r Result) OK() bool {
+	if r.Skipped != "" {
+		return true
+	}
+	if r.Err != nil {
+		return false
+	}
+	if r.Case.Ordered {
+		return slices.Equal(r.Want, r.Got)
+	}
+	return slices.Equal(sortedIDs(r.Want), sortedIDs(r.Got))
+}
+
+func (r Result) String() string {
+	switch {
+	case r.Skipped != "":
+		return fmt.Sprintf("%s: skipped: %s", r.Case.Name, r.Skipped)
+	case r.Err != nil:
+		return fmt.Sprintf("%s: %s: %v", r.Case.Name, r.Case.DSL, r.Err)
+	case r.OK():
+		return fmt.Sprintf("%s: ok", r.Case.Name)
+	}
+	return fmt.Sprintf("%s: %s: got ids %v, the reference returns %v", r.Case.Name, r.Case.DSL, r.Got, r.Want)
+}
+
+func sortedIDs(ids []int64) []int64 {
+	out := append([]int64{}, ids...)
+	slices.Sort(out)
+	return out
+}
+
+// Check runs every case of the corpus and returns one Result per case, in
+// corpus order. The error is reserved for the reference itself failing; the
+// adapter's failures are in the results.
+func Check(h Harness) ([]Result, error) {
+	if h.Adapter == nil || h.Query == nil {
+		return nil, fmt.Errorf("figotest: the harness needs an Adapter and a Query func")
+	}
+	db, err := OpenSQLite()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	ref := reference{db: db}
+
+	corpus := Corpus()
+	results := make([]Result, 0, len(corpus))
+	for _, c := range corpus {
+		r := Result{Case: c, Skipped: h.skipReason(c)}
+		if r.Skipped == "" {
+			if r.Want, err = ref.ids(h.newFigo, c); err != nil {
+				return nil, fmt.Errorf("figotest: reference failed on %s: %w", c.Name, err)
+			}
+			r.Got, r.Err = h.run(c)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// Run runs Check with one subtest per case, failing those where the adapter
+// disagrees with the reference.
+func Run(t *testing.T, h Harness) {
+	t.Helper()
+	results, err := Check(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		t.Run(r.Case.Name, func(t *testing.T) {
+			if r.Skipped != "" {
+				t.Skip(r.Skipped)
+			}
+			if !r.OK() {
+				t.Error(r.String())
+			}
+		})
+	}
+}
+
+func (h Harness) skipReason(c Case) string {
+	if reason, ok := h.Skip[c.Name]; ok {
+		return reason
+	}
+	for _, feature := range c.Features {
+		if reason, ok := h.Skip[feature]; ok {
+			return reason
+		}
+	}
+	return ""
+}
+
+func (h Harness) newFigo() figo.Figo {
+	f := figo.New()
+	if h.Configure != nil {
+		h.Configure(f)
+	}
+	return f
+}
+
+func (h Harness) run(c Case) ([]int64, error) {
+	f := h.newFigo()
+	if err := f.AddFiltersFromString(c.DSL); err != nil {
+		return nil, err
+	}
+	if err := f.BuildE(h.Adapter); err != nil {
+		return nil, err
+	}
+	return h.Query(f)
+}
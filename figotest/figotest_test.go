@@ -0,0 +1,96 @@
+package figotest_test
+
+import (
+	"errors"
+	"testing"
+
+	figo "github.com/bi0dread/figo/v4"
+	"github.com/bi0dread/figo/v4/adapters"
+	"github.com/bi0dread/figo/v4/figotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func sliceQuery(f figo.Figo) ([]int64, error) {
+	q, err := adapters.BuildSliceQuery(f, figotest.Items())
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(q.Items))
+	for _, item := range q.Items {
+		ids = append(ids, item.(figotest.Item).ID)
+	}
+	return ids, nil
+}
+
+func TestSliceAdapterConforms(t *testing.T) {
+	figotest.Run(t, figotest.Harness{Adapter: adapters.SliceAdapter{}, Query: sliceQuery})
+}
+
+func TestGormAdapterConforms(t *testing.T) {
+	db, err := figotest.OpenSQLite()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	gdb, err := gorm.Open(sqlite.Dialector{Conn: db}, &gorm.Config{})
+	require.NoError(t, err)
+
+	figotest.Run(t, figotest.Harness{
+		Adapter: adapters.GormAdapter{Relations: figotest.Relations},
+		Query: func(f figo.Figo) ([]int64, error) {
+			var ids []int64
+			err := adapters.ApplyGorm(f, gdb.Table("items")).Pluck("id", &ids).Error
+			return ids, err
+		},
+	})
+}
+
+// The harness must see a divergence, honour Skip by name and by feature, and
+// report the adapter's own errors as failures.
+func TestHarnessReportsDivergence(t *testing.T) {
+	everything := func(figo.Figo) ([]int64, error) { return []int64{1, 2, 3, 4, 5, 6, 7}, nil }
+	results, err := figotest.Check(figotest.Harness{
+		Adapter: adapters.SliceAdapter{},
+		Query:   everything,
+		Skip:    map[string]string{"regex": "no regex", "eq-int": "not today"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, len(figotest.Corpus()))
+
+	byName := map[string]figotest.Result{}
+	for _, r := range results {
+		byName[r.Case.Name] = r
+	}
+	assert.Equal(t, "not today", byName["eq-int"].Skipped)
+	assert.Equal(t, "no regex", byName["regex-unanchored"].Skipped)
+	assert.True(t, byName["regex"].OK())
+
+	neq := byName["neq"]
+	assert.False(t, neq.OK())
+	assert.Equal(t, []int64{2, 5, 6, 7}, neq.Want)
+	assert.Contains(t, neq.String(), "the reference returns [2 5 6 7]")
+
+	// An ordered case compares order, an unordered one only the set.
+	assert.True(t, figotest.Result{Case: figotest.Case{}, Want: []int64{1, 2}, Got: []int64{2, 1}}.OK())
+	assert.False(t, figotest.Result{Case: figotest.Case{Ordered: true}, Want: []int64{1, 2}, Got: []int64{2, 1}}.OK())
+	assert.False(t, figotest.Result{Err: errors.New("boom")}.OK())
+
+	_, err = figotest.Check(figotest.Harness{Query: everything})
+	assert.Error(t, err)
+}
+
+// The reference database is the strict one the corpus expects.
+func TestOpenSQLiteReference(t *testing.T) {
+	db, err := figotest.OpenSQLite()
+	require.NoError(t, err)
+	defer db.Close()
+
+	var n int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM items WHERE name LIKE 'ALICE'`).Scan(&n))
+	assert.Equal(t, 0, n, "LIKE must be case-sensitive")
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM items WHERE name REGEXP '^[a-c]'`).Scan(&n))
+	assert.Equal(t, 3, n)
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM orders`).Scan(&n))
+	assert.Equal(t, 6, n)
+}
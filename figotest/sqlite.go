@@ -0,0 +1,127 @@
+package figotest
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sync"
+
+	figo "github.com/bi0dread/figo/v4"
+	"github.com/bi0dread/figo/v4/adapters"
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriver is a SQLite driver tuned to be a strict reference: stock SQLite
+// has no REGEXP function at all, and its LIKE folds ASCII case, which no other
+// figo backend does.
+const sqliteDriver = "figotest_sqlite3"
+
+var registerOnce sync.Once
+
+func registerDriver() {
+	registerOnce.Do(func() {
+		sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				if _, err := conn.Exec("PRAGMA case_sensitive_like = ON", nil); err != nil {
+					return err
+				}
+				// X REGEXP Y calls regexp(Y, X); NULL in, NULL out.
+				return conn.RegisterFunc("regexp", func(pattern, value any) (any, error) {
+					p, ok1 := pattern.(string)
+					v, ok2 := value.(string)
+					if !ok1 || !ok2 {
+						return nil, nil
+					}
+					re, err := regexp.Compile(p)
+					if err != nil {
+						return nil, err
+					}
+					return re.MatchString(v), nil
+				}, true)
+			},
+		})
+	})
+}
+
+// OpenSQLite returns an in-memory SQLite database holding the fixture in the
+// tables items and orders, with a REGEXP function (Go regexp syntax,
+// unanchored) and case-sensitive LIKE. It is the reference the harness runs
+// every case against, and can back a SQL adapter under test as well. The
+// database lives as long as the returned handle; close it when done.
+func OpenSQLite() (*sql.DB, error) {
+	registerDriver()
+	db, err := sql.Open(sqliteDriver, ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	// Every connection to ":memory:" is a database of its own.
+	db.SetMaxOpenConns(1)
+	if err := Seed(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Seed creates the fixture's items and orders tables in db and fills them.
+// The statements are portable SQL with '?' placeholders.
+func Seed(db *sql.DB) error {
+	stmts := []string{
+		`CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT, age INTEGER, score REAL, active BOOLEAN)`,
+		`CREATE TABLE orders (id INTEGER PRIMARY KEY, item_id INTEGER NOT NULL, total INTEGER, status TEXT)`,
+	}
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			return fmt.Errorf("figotest: %s: %w", s, err)
+		}
+	}
+	for _, it := range Items() {
+		if _, err := db.Exec(`INSERT INTO items (id, name, age, score, active) VALUES (?, ?, ?, ?, ?)`,
+			it.ID, it.Name, it.Age, it.Score, it.Active); err != nil {
+			return fmt.Errorf("figotest: seeding item %d: %w", it.ID, err)
+		}
+		for _, o := range it.Orders {
+			if _, err := db.Exec(`INSERT INTO orders (id, item_id, total, status) VALUES (?, ?, ?, ?)`,
+				o.ID, o.ItemID, o.Total, o.Status); err != nil {
+				return fmt.Errorf("figotest: seeding order %d: %w", o.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// reference runs cases through the raw adapter on the reference database.
+type reference struct {
+	db *sql.DB
+}
+
+func (r reference) ids(newFigo func() figo.Figo, c Case) ([]int64, error) {
+	f := newFigo()
+	if err := f.AddFiltersFromString(c.DSL); err != nil {
+		return nil, err
+	}
+	f.SetSelectFields("id")
+	a := adapters.RawAdapter{Dialect: adapters.SQLiteDialect, Relations: Relations}
+	if err := f.BuildE(a); err != nil {
+		return nil, err
+	}
+	q, ok := a.GetQuery(f, adapters.RawContext{Table: "items"})
+	if !ok {
+		return nil, fmt.Errorf("the raw adapter cannot render %q", c.DSL)
+	}
+	stmt := q.(figo.SQLQuery)
+	rows, err := r.db.Query(stmt.SQL, stmt.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", stmt.SQL, err)
+	}
+	defer rows.Close()
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,408 @@
+// Package httpfigo turns an HTTP request's query parameters into a built
+// figo.Figo. The dance every list endpoint repeats — read the filter, New,
+// register the plugins, AddFiltersFromString, BuildE, answer 400 on failure —
+// becomes one middleware:
+//
+//	cfg := httpfigo.Config{
+//		Adapter: adapters.RawAdapter{Dialect: adapters.PostgresDialect},
+//		Plugins: []figo.Plugin{fieldsPlugin, limitsPlugin},
+//	}
+//	mux.Handle("/users", cfg.Middleware(http.HandlerFunc(listUsers)))
+//
+//	func listUsers(w http.ResponseWriter, r *http.Request) {
+//		f, _ := httpfigo.FromContext(r.Context()) // built, plugins applied
+//		...
+//	}
+//
+// The parameters are filter (the DSL), sort (sort= syntax: name:asc,age:desc),
+// page (page= syntax: skip:0,take:20) and fields (a comma-separated
+// projection). A request with a JSON body (Content-Type application/json, any
+// method but GET and HEAD) may send them as an object instead, with fields as
+// a string or an array. A request that cannot be built is answered with an RFC
+// 7807 problem (application/problem+json) listing every reason, and the next
+// handler never runs.
+package httpfigo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	figo "github.com/bi0dread/figo/v4"
+)
+
+// Parameter names, in the query string and in a JSON body.
+const (
+	ParamFilter = "filter"
+	ParamSort   = "sort"
+	ParamPage   = "page"
+	ParamFields = "fields"
+)
+
+// Problem codes, the "code" member of every problem httpfigo writes.
+const (
+	// CodeInvalidRequest: a parameter or the body is malformed.
+	CodeInvalidRequest = "invalid_request"
+	// CodeInvalidQuery: BuildContext reported diagnostics — part of the DSL
+	// did not parse, so the built query would not express the whole request —
+	// or a figo.ContextPlugin refused the build.
+	CodeInvalidQuery = "invalid_query"
+	// CodeQueryRejected: a plugin refused the DSL (a limit, a validation rule,
+	// the injection guard).
+	CodeQueryRejected = "query_rejected"
+)
+
+// DefaultMaxBodyBytes bounds a JSON body when Config.MaxBodyBytes is zero.
+const DefaultMaxBodyBytes = 1 << 20
+
+// Config configures the middleware. The zero value is usable: it builds with
+// no adapter and no plugins. A Config is read-only once serving starts and is
+// safe for concurrent use.
+type Config struct {
+	// Adapter is passed to BuildContext, with the request's context.
+	Adapter figo.Adapter
+	// Plugins is the shared plugin set. Each request gets its own instance and
+	// its own plugin manager, and every plugin is registered on it in order —
+	// a plugin added by Prepare therefore never leaks into another request.
+	Plugins []figo.Plugin
+	// New, when set, creates each request's instance instead of figo.New: a
+	// template's Clone, or an instance with a custom NamingFunc. Plugins
+	// already registered on it stay registered, ahead of Plugins.
+	New func() figo.Figo
+	// Prepare, when set, runs on the fresh instance after the shared plugins
+	// are registered and before the DSL is added — the place for per-request
+	// state such as a ScopePlugin holding the caller's tenant. An error aborts
+	// the request: a *Problem is written as is, any other error as a 500 whose
+	// detail does not leak the error text.
+	Prepare func(r *http.Request, f figo.Figo) error
+	// MaxBodyBytes bounds a JSON body; 0 means DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+	// ProblemTypeBase, when set, makes each problem's "type" the base followed
+	// by its code (https://example.com/problems/ + invalid_query); otherwise
+	// the type is "about:blank".
+	ProblemTypeBase string
+	// WriteProblem, when set, replaces WriteProblem for this Config.
+	WriteProblem func(w http.ResponseWriter, r *http.Request, p *Problem)
+}
+
+// Problem is an RFC 7807 problem detail. Code and Errors are extension
+// members: the httpfigo code, and one entry per diagnostic or rejection.
+type Problem struct {
+	Type     string   `json:"type"`
+	Title    string   `json:"title"`
+	Status   int      `json:"status"`
+	Detail   string   `json:"detail,omitempty"`
+	Instance string   `json:"instance,omitempty"`
+	Code     string   `json:"code,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	if len(p.Errors) == 0 {
+		return "httpfigo: " + p.Detail
+	}
+	return "httpfigo: " + p.Detail + ": " + strings.Join(p.Errors, "; ")
+}
+
+// WriteProblem writes p as application/problem+json with p.Status.
+func WriteProblem(w http.ResponseWriter, p *Problem) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(body)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying f.
+func NewContext(ctx context.Context, f figo.Figo) context.Context {
+	return context.WithValue(ctx, contextKey{}, f)
+}
+
+// FromContext returns the instance the middleware stored in ctx.
+func FromContext(ctx context.Context) (figo.Figo, bool) {
+	f, ok := ctx.Value(contextKey{}).(figo.Figo)
+	return f, ok
+}
+
+// Middleware wraps next: each request is built with Build, stored in the
+// request context for next, or answered with a problem.
+func (c Config) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, err := c.Build(r)
+		if err != nil {
+			c.writeProblem(w, r, c.problemOf(r, err))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), f)))
+	})
+}
+
+// Handler is Middleware for a function taking the instance directly.
+func (c Config) Handler(fn func(w http.ResponseWriter, r *http.Request, f figo.Figo)) http.Handler {
+	return c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, _ := FromContext(r.Context())
+		fn(w, r, f)
+	}))
+}
+
+// Build is the middleware without the HTTP response: it reads the request's
+// parameters and returns the built instance. Every failure is a *Problem
+// except a Prepare error, which is returned as is.
+func (c Config) Build(r *http.Request) (figo.Figo, error) {
+	params, err := c.readParams(r)
+	if err != nil {
+		return nil, err
+	}
+	dsl, fields, err := params.dsl()
+	if err != nil {
+		return nil, err
+	}
+
+	var f figo.Figo
+	if c.New != nil {
+		f = c.New()
+	} else {
+		f = figo.New()
+	}
+	// A fresh manager: a Clone shares its template's manager, and Prepare's
+	// plugins must stay on this request. The template's own plugins (already
+	// initialized) carry over — dropping them would drop its scope.
+	pm := figo.NewPluginManager()
+	for _, p := range f.GetPluginManager().ListPlugins() {
+		if err := pm.RegisterPlugin(p); err != nil {
+			return nil, fmt.Errorf("httpfigo: copying plugin %s: %w", p.Name(), err)
+		}
+	}
+	f.SetPluginManager(pm)
+	for _, p := range c.Plugins {
+		if err := f.RegisterPlugin(p); err != nil {
+			return nil, fmt.Errorf("httpfigo: registering plugin %s: %w", p.Name(), err)
+		}
+	}
+	if c.Prepare != nil {
+		if err := c.Prepare(r, f); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := f.AddFiltersFromString(dsl); err != nil {
+		return nil, &Problem{
+			Status: http.StatusBadRequest,
+			Code:   CodeQueryRejected,
+			Detail: "the query was rejected",
+			Errors: errorList(err),
+		}
+	}
+	f.AddSelectFields(fields...)
+	// The request's context reaches every figo.ContextPlugin, so a shared
+	// ScopePlugin can resolve the caller's tenant from it.
+	if err := f.BuildContext(r.Context(), c.Adapter); err != nil {
+		return nil, &Problem{
+			Status: http.StatusBadRequest,
+			Code:   CodeInvalidQuery,
+			Detail: "the query could not be built",
+			Errors: errorList(err),
+		}
+	}
+	return f, nil
+}
+
+// params is what a request asked for.
+type params struct {
+	Filter string
+	Sort   string
+	Page   string
+	Fields []string
+}
+
+// readParams reads the JSON body when there is one, the query string
+// otherwise. Mixing the two is refused rather than merged.
+func (c Config) readParams(r *http.Request) (params, error) {
+	q := r.URL.Query()
+	if !hasJSONBody(r) {
+		p := params{Filter: q.Get(ParamFilter), Sort: q.Get(ParamSort), Page: q.Get(ParamPage)}
+		for _, name := range []string{ParamFilter, ParamSort, ParamPage, ParamFields} {
+			if len(q[name]) > 1 {
+				return params{}, invalidRequest("the %s parameter is repeated", name)
+			}
+		}
+		if v := q.Get(ParamFields); v != "" {
+			p.Fields = strings.Split(v, ",")
+		}
+		return p, nil
+	}
+	for _, name := range []string{ParamFilter, ParamSort, ParamPage, ParamFields} {
+		if q.Has(name) {
+			return params{}, invalidRequest("the %s parameter cannot be combined with a JSON body", name)
+		}
+	}
+
+	limit := c.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return params{}, invalidRequest("reading the body: %v", err)
+	}
+	if int64(len(body)) > limit {
+		p := invalidRequest("the body exceeds %d bytes", limit)
+		p.Status = http.StatusRequestEntityTooLarge
+		return params{}, p
+	}
+	// The next handler may want the body too.
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var raw struct {
+		Filter string          `json:"filter"`
+		Sort   string          `json:"sort"`
+		Page   string          `json:"page"`
+		Fields json.RawMessage `json:"fields"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&raw); err != nil {
+		return params{}, invalidRequest("the body is not a valid query object: %v", err)
+	}
+	if dec.More() {
+		return params{}, invalidRequest("the body holds more than one JSON value")
+	}
+	p := params{Filter: raw.Filter, Sort: raw.Sort, Page: raw.Page}
+	if len(raw.Fields) > 0 && string(raw.Fields) != "null" {
+		var list []string
+		var csv string
+		switch {
+		case json.Unmarshal(raw.Fields, &list) == nil:
+			p.Fields = list
+		case json.Unmarshal(raw.Fields, &csv) == nil:
+			if csv != "" {
+				p.Fields = strings.Split(csv, ",")
+			}
+		default:
+			return params{}, invalidRequest("fields must be a string or an array of strings")
+		}
+	}
+	return p, nil
+}
+
+func hasJSONBody(r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Body == nil || r.Body == http.NoBody {
+		return false
+	}
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+}
+
+// dsl assembles the DSL handed to AddFiltersFromString. sort and page are
+// appended as directives, so the plugins see them like any DSL (a LimitsPlugin
+// bounds page=take either way). Both must therefore be exactly one directive
+// value: a space would let "sort=name:asc or 1=1" smuggle a filter term past
+// the filter parameter.
+func (p params) dsl() (string, []string, error) {
+	var errs []string
+	if p.Sort != "" && !isDirectiveValue(p.Sort) {
+		errs = append(errs, fmt.Sprintf("sort %q is not a sort= value (field:asc or field:desc, comma-separated)", p.Sort))
+	}
+	if p.Page != "" && !isDirectiveValue(p.Page) {
+		errs = append(errs, fmt.Sprintf("page %q is not a page= value (skip:N,take:N)", p.Page))
+	}
+	fields := make([]string, 0, len(p.Fields))
+	for _, name := range p.Fields {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			errs = append(errs, "fields has an empty entry")
+			continue
+		}
+		fields = append(fields, name)
+	}
+	if len(errs) > 0 {
+		return "", nil, &Problem{Status: http.StatusBadRequest, Code: CodeInvalidRequest, Detail: "the request parameters are malformed", Errors: errs}
+	}
+
+	dsl := strings.TrimSpace(p.Filter)
+	if p.Sort != "" {
+		dsl += " sort=" + p.Sort
+	}
+	if p.Page != "" {
+		dsl += " page=" + p.Page
+	}
+	return strings.TrimSpace(dsl), fields, nil
+}
+
+// isDirectiveValue reports whether v is one DSL token of the characters a
+// sort= or page= value is made of.
+func isDirectiveValue(v string) bool {
+	for _, r := range v {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '_', r == '.', r == ':', r == ',', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func invalidRequest(format string, args ...any) *Problem {
+	return &Problem{Status: http.StatusBadRequest, Code: CodeInvalidRequest, Detail: fmt.Sprintf(format, args...)}
+}
+
+// errorList flattens joined errors into one message each.
+func errorList(err error) []string {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var out []string
+		for _, e := range joined.Unwrap() {
+			out = append(out, errorList(e)...)
+		}
+		return out
+	}
+	return []string{err.Error()}
+}
+
+// problemOf completes the problem for err: the standard members, and a 500
+// for an error that is not a *Problem.
+func (c Config) problemOf(r *http.Request, err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		cp := *p
+		p = &cp
+	} else {
+		p = &Problem{Status: http.StatusInternalServerError, Detail: "the query could not be prepared"}
+	}
+	if p.Status == 0 {
+		p.Status = http.StatusBadRequest
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+		if c.ProblemTypeBase != "" && p.Code != "" {
+			p.Type = c.ProblemTypeBase + p.Code
+		}
+	}
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	return p
+}
+
+func (c Config) writeProblem(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if c.WriteProblem != nil {
+		c.WriteProblem(w, r, p)
+		return
+	}
+	WriteProblem(w, p)
+}
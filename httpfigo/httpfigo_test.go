@@ -0,0 +1,250 @@
+package httpfigo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	figo "github.com/bi0dread/figo/v4"
+	"github.com/bi0dread/figo/v4/adapters"
+	"github.com/bi0dread/figo/v4/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve runs req through cfg's middleware and returns the response and the
+// instance the next handler received (nil when it did not run).
+func serve(t *testing.T, cfg Config, req *http.Request) (*httptest.ResponseRecorder, figo.Figo) {
+	t.Helper()
+	var got figo.Figo
+	h := cfg.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := FromContext(r.Context())
+		require.True(t, ok)
+		got = f
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec, got
+}
+
+func getReq(params url.Values) *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/users?"+params.Encode(), nil)
+}
+
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	var p Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	return p
+}
+
+func rawWhere(t *testing.T, f figo.Figo) (string, []any) {
+	t.Helper()
+	where, args, err := adapters.BuildRawWhere(f)
+	require.NoError(t, err)
+	return where, args
+}
+
+var rawCfg = Config{Adapter: adapters.RawAdapter{Dialect: adapters.PostgresDialect}}
+
+func TestMiddlewareBuildsFromQueryParameters(t *testing.T) {
+	rec, f := serve(t, rawCfg, getReq(url.Values{
+		"filter": {`age>18 and name=^"%an%"`},
+		"sort":   {"age:desc,name:asc"},
+		"page":   {"skip:20,take:10"},
+		"fields": {"id, name"},
+	}))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.NotNil(t, f)
+
+	where, args := rawWhere(t, f)
+	assert.Equal(t, `("age" > $1 AND "name" LIKE $2)`, where)
+	assert.Equal(t, []any{int64(18), "%an%"}, args)
+	assert.Equal(t, figo.Page{Skip: 20, Take: 10}, f.GetPage())
+	assert.Equal(t, []figo.OrderByColumn{{Name: "age", Desc: true}, {Name: "name"}}, f.GetSort().Columns)
+	assert.Equal(t, map[string]bool{"id": true, "name": true}, f.GetSelectFields())
+
+	// No parameters at all is an empty, valid query.
+	rec, f = serve(t, rawCfg, getReq(nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, f.GetClauses())
+}
+
+func TestMiddlewareReadsAJSONBody(t *testing.T) {
+	body := `{"filter":"status=\"active\"","page":"take:5","fields":["id","status"]}`
+	req := httptest.NewRequest(http.MethodPost, "/users/search", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	var rest []byte
+	h := rawCfg.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, _ = io.ReadAll(r.Body)
+		f, _ := FromContext(r.Context())
+		where, args := rawWhere(t, f)
+		assert.Equal(t, `"status" = $1`, where)
+		assert.Equal(t, []any{"active"}, args)
+		assert.Equal(t, 5, f.GetPage().Take)
+		assert.Equal(t, map[string]bool{"id": true, "status": true}, f.GetSelectFields())
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, body, string(rest), "the body is still readable downstream")
+
+	// fields as a comma-separated string.
+	req = httptest.NewRequest(http.MethodPost, "/users/search", strings.NewReader(`{"fields":"a,b"}`))
+	req.Header.Set("Content-Type", "application/json")
+	_, f := serve(t, rawCfg, req)
+	assert.Equal(t, map[string]bool{"a": true, "b": true}, f.GetSelectFields())
+}
+
+func TestMiddlewareWritesProblems(t *testing.T) {
+	lp := plugins.NewLimitsPlugin(plugins.QueryLimits{MaxFieldCount: 1})
+	cfg := rawCfg
+	cfg.Plugins = []figo.Plugin{lp}
+	cfg.ProblemTypeBase = "https://example.test/problems/"
+
+	// A plugin rejection.
+	rec, f := serve(t, cfg, getReq(url.Values{"filter": {"a=1 and b=2"}}))
+	assert.Nil(t, f, "the next handler never runs")
+	p := decodeProblem(t, rec)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, Problem{
+		Type:     "https://example.test/problems/query_rejected",
+		Title:    "Bad Request",
+		Status:   http.StatusBadRequest,
+		Detail:   "the query was rejected",
+		Instance: "/users",
+		Code:     CodeQueryRejected,
+		Errors:   []string{"plugin figo-limits AfterParse error: query exceeds MaxFieldCount: 2 > 1"},
+	}, p)
+
+	// BuildContext diagnostics, one entry each.
+	rec, _ = serve(t, rawCfg, getReq(url.Values{"filter": {"a=1 sort=a:up"}, "page": {"skip:x"}}))
+	p = decodeProblem(t, rec)
+	assert.Equal(t, "about:blank", p.Type)
+	assert.Equal(t, CodeInvalidQuery, p.Code)
+	assert.Len(t, p.Errors, 2)
+
+	// Malformed parameters never reach the parser.
+	for name, params := range map[string]url.Values{
+		"sort smuggling a term": {"sort": {"name:asc or 1=1"}},
+		"page with a space":     {"page": {"take:1 a=1"}},
+		"empty field":           {"fields": {"id,,name"}},
+		"repeated filter":       {"filter": {"a=1", "b=2"}},
+	} {
+		rec, f := serve(t, rawCfg, getReq(params))
+		assert.Nil(t, f, name)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, name)
+		assert.Equal(t, CodeInvalidRequest, decodeProblem(t, rec).Code, name)
+	}
+
+	for name, body := range map[string]string{
+		"unknown member": `{"filter":"a=1","limit":5}`,
+		"trailing value": `{"filter":"a=1"} {}`,
+		"bad fields":     `{"fields":5}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec, _ := serve(t, rawCfg, req)
+		assert.Equal(t, CodeInvalidRequest, decodeProblem(t, rec).Code, name)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"filter":"`+strings.Repeat("a", 64)+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	small := rawCfg
+	small.MaxBodyBytes = 32
+	rec, _ = serve(t, small, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestMiddlewarePrepareScopesEachRequest(t *testing.T) {
+	cfg := rawCfg
+	cfg.Prepare = func(r *http.Request, f figo.Figo) error {
+		tenant := r.Header.Get("X-Tenant")
+		if tenant == "" {
+			return &Problem{Status: http.StatusUnauthorized, Detail: "no tenant"}
+		}
+		if tenant == "boom" {
+			return errors.New("secret database error")
+		}
+		return f.RegisterPlugin(plugins.NewScopePlugin(figo.EqExpr{Field: "tenant_id", Value: tenant}))
+	}
+
+	for _, tenant := range []string{"t1", "t2"} {
+		req := getReq(url.Values{"filter": {"a=1"}})
+		req.Header.Set("X-Tenant", tenant)
+		_, f := serve(t, cfg, req)
+		_, args := rawWhere(t, f)
+		assert.Equal(t, []any{int64(1), tenant}, args, "each request carries only its own scope")
+	}
+
+	rec, _ := serve(t, cfg, getReq(nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "no tenant", decodeProblem(t, rec).Detail)
+
+	req := getReq(nil)
+	req.Header.Set("X-Tenant", "boom")
+	rec, _ = serve(t, cfg, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "secret", "an internal error is not echoed")
+}
+
+func TestMiddlewareKeepsTemplatePlugins(t *testing.T) {
+	tmpl := figo.New()
+	require.NoError(t, tmpl.RegisterPlugin(plugins.NewScopePlugin(figo.EqExpr{Field: "deleted", Value: false})))
+	cfg := rawCfg
+	cfg.New = tmpl.Clone
+
+	_, f := serve(t, cfg, getReq(url.Values{"filter": {"a=1"}}))
+	where, _ := rawWhere(t, f)
+	assert.Contains(t, where, `"deleted"`)
+	assert.Len(t, tmpl.GetPluginManager().ListPlugins(), 1, "the template's manager is not written to")
+}
+
+func TestHandlerAndCustomProblemWriter(t *testing.T) {
+	cfg := rawCfg
+	cfg.WriteProblem = func(w http.ResponseWriter, r *http.Request, p *Problem) {
+		http.Error(w, p.Code, p.Status)
+	}
+	h := cfg.Handler(func(w http.ResponseWriter, r *http.Request, f figo.Figo) {
+		_, _ = io.WriteString(w, f.GetDSL())
+	})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, getReq(url.Values{"filter": {"a=1"}, "sort": {"a:asc"}}))
+	assert.Equal(t, "a=1 sort=a:asc", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, getReq(url.Values{"sort": {"a asc"}}))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_request\n", rec.Body.String())
+}
+
+type tenantKey struct{}
+
+// One shared ScopePlugin resolves each request's tenant from its context.
+func TestMiddlewareBuildsWithTheRequestContext(t *testing.T) {
+	sp := plugins.NewScopePlugin()
+	sp.AddScopeFunc(plugins.ContextValueScope("tenant_id", tenantKey{}))
+	cfg := rawCfg
+	cfg.Plugins = []figo.Plugin{sp}
+
+	req := getReq(url.Values{"filter": {"a=1"}})
+	_, f := serve(t, cfg, req.WithContext(context.WithValue(req.Context(), tenantKey{}, "t9")))
+	_, args := rawWhere(t, f)
+	assert.Equal(t, []any{int64(1), "t9"}, args)
+
+	rec, f := serve(t, cfg, getReq(url.Values{"filter": {"a=1"}}))
+	assert.Nil(t, f)
+	p := decodeProblem(t, rec)
+	assert.Equal(t, CodeInvalidQuery, p.Code)
+	assert.Contains(t, p.Errors[0], "FinalizeClausesContext")
+}
@@ -0,0 +1,139 @@
+package httpfigo
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	figo "github.com/bi0dread/figo/v4"
+)
+
+// Links are the URLs of a paginated response's neighbouring pages. An empty
+// URL means the page does not exist: no Prev on the first page, no Next on
+// the last, no Last when the total is unknown.
+type Links struct {
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// PageLinks computes the links of page p out of total matches, each one u with
+// its pagination replaced. The page parameter is rewritten when u has one;
+// otherwise a page= directive inside the filter parameter is (figo.ReplacePage);
+// otherwise a page parameter is added. Every other parameter, sort included,
+// is kept, so each link is the same query one page over.
+//
+// A negative total means unknown (Elasticsearch without track_total_hits):
+// Next is then always set and Last never is. A page without a Take is the
+// whole result and has no links at all.
+func PageLinks(u *url.URL, p figo.Page, total int64) Links {
+	if p.Take <= 0 {
+		return Links{}
+	}
+	skip, take := int64(max(p.Skip, 0)), int64(p.Take)
+	at := func(s int64) string { return pageURL(u, figo.Page{Skip: int(s), Take: p.Take}) }
+
+	l := Links{First: at(0)}
+	lastSkip := int64(-1)
+	if total >= 0 {
+		lastSkip = max(total-1, 0) / take * take
+		l.Last = at(lastSkip)
+	}
+	if skip > 0 {
+		prev := max(skip-take, 0)
+		if lastSkip >= 0 && prev > lastSkip {
+			// Past the end: the previous page is the last one that exists.
+			prev = lastSkip
+		}
+		l.Prev = at(prev)
+	}
+	if total < 0 || skip+take < total {
+		l.Next = at(skip + take)
+	}
+	return l
+}
+
+// pageURL is u with its pagination set to p.
+func pageURL(u *url.URL, p figo.Page) string {
+	q := u.Query()
+	value := strings.TrimPrefix(figo.PageDirective(p), string(figo.OperationPage)+"=")
+	switch {
+	case q.Has(ParamPage):
+		q.Set(ParamPage, value)
+	case q.Has(ParamFilter):
+		filter, replaced := figo.ReplacePage(q.Get(ParamFilter), p)
+		if replaced {
+			q.Set(ParamFilter, filter)
+		} else {
+			q.Set(ParamPage, value)
+		}
+	default:
+		q.Set(ParamPage, value)
+	}
+	out := *u
+	out.RawQuery = q.Encode()
+	return out.String()
+}
+
+// String renders the links as an RFC 8288 Link header value:
+// <url>; rel="first", <url>; rel="next", ...
+func (l Links) String() string {
+	var parts []string
+	for _, link := range []struct{ rel, href string }{
+		{"first", l.First}, {"prev", l.Prev}, {"next", l.Next}, {"last", l.Last},
+	} {
+		if link.href != "" {
+			parts = append(parts, fmt.Sprintf("<%s>; rel=%q", link.href, link.rel))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// PageInfo is the pagination half of a response envelope.
+type PageInfo struct {
+	Skip  int   `json:"skip"`
+	Take  int   `json:"take"`
+	Total int64 `json:"total"`
+	Links Links `json:"links"`
+}
+
+// Envelope is a paginated response body: the page's items and where it sits.
+type Envelope[T any] struct {
+	Items []T      `json:"items"`
+	Page  PageInfo `json:"page"`
+}
+
+// NewEnvelope pairs items with the instance's page (GetPage) and the total
+// count. The links are relative to r's URL, which is what a handler sees for
+// a server request; resolve them against the public base URL when a proxy
+// rewrites paths. A request that sent its query in a JSON body gets no
+// links: its URL does not carry the filter, so a link would fetch another
+// page of unfiltered data. Skip, take and the total are still set.
+func NewEnvelope[T any](r *http.Request, f figo.Figo, items []T, total int64) Envelope[T] {
+	if items == nil {
+		items = []T{}
+	}
+	p := f.GetPage()
+	var links Links
+	if !hasJSONBody(r) {
+		links = PageLinks(r.URL, p, total)
+	}
+	return Envelope[T]{
+		Items: items,
+		Page:  PageInfo{Skip: p.Skip, Take: p.Take, Total: total, Links: links},
+	}
+}
+
+// SetPageHeaders sets the Link header (when there are links) and
+// X-Total-Count (when the total is known) for a paginated response.
+func SetPageHeaders(h http.Header, l Links, total int64) {
+	if v := l.String(); v != "" {
+		h.Set("Link", v)
+	}
+	if total >= 0 {
+		h.Set("X-Total-Count", strconv.FormatInt(total, 10))
+	}
+}
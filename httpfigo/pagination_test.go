@@ -0,0 +1,113 @@
+package httpfigo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	figo "github.com/bi0dread/figo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustURL(t *testing.T, s string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(s)
+	require.NoError(t, err)
+	return u
+}
+
+// pageOf reads the page a link points at, through the same DSL the middleware
+// would build.
+func pageOf(t *testing.T, link string) figo.Page {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, link, nil)
+	f, err := Config{}.Build(req)
+	require.NoError(t, err)
+	return f.GetPage()
+}
+
+func TestPageLinks(t *testing.T) {
+	u := mustURL(t, "/users?filter=age%3E1&sort=age%3Adesc&page=skip%3A20%2Ctake%3A10")
+	l := PageLinks(u, figo.Page{Skip: 20, Take: 10}, 45)
+	assert.Equal(t, figo.Page{Skip: 0, Take: 10}, pageOf(t, l.First))
+	assert.Equal(t, figo.Page{Skip: 10, Take: 10}, pageOf(t, l.Prev))
+	assert.Equal(t, figo.Page{Skip: 30, Take: 10}, pageOf(t, l.Next))
+	assert.Equal(t, figo.Page{Skip: 40, Take: 10}, pageOf(t, l.Last))
+	assert.Equal(t, "/users?filter=age%3E1&page=skip%3A30%2Ctake%3A10&sort=age%3Adesc", l.Next, "the other parameters are kept")
+
+	// First and last pages.
+	l = PageLinks(u, figo.Page{Skip: 0, Take: 10}, 45)
+	assert.Empty(t, l.Prev)
+	assert.NotEmpty(t, l.Next)
+	l = PageLinks(u, figo.Page{Skip: 40, Take: 10}, 45)
+	assert.Empty(t, l.Next)
+	assert.Equal(t, l.Last, PageLinks(u, figo.Page{Skip: 30, Take: 10}, 45).Next)
+
+	// Past the end, prev is the last page; an empty result has one page.
+	l = PageLinks(u, figo.Page{Skip: 90, Take: 10}, 45)
+	assert.Equal(t, l.Last, l.Prev)
+	l = PageLinks(u, figo.Page{Take: 10}, 0)
+	assert.Equal(t, Links{First: l.First, Last: l.First}, l)
+
+	// An unknown total always has a next page and no last.
+	l = PageLinks(u, figo.Page{Take: 10}, -1)
+	assert.NotEmpty(t, l.Next)
+	assert.Empty(t, l.Last)
+
+	// No Take: the whole result, no links.
+	assert.Equal(t, Links{}, PageLinks(u, figo.Page{}, 45))
+}
+
+func TestPageLinksReencodeTheDSL(t *testing.T) {
+	// The directive inside the filter is replaced, not duplicated by a page
+	// parameter (which would be a conflicting second directive).
+	u := mustURL(t, "/users?"+url.Values{"filter": {"a=1 and page=skip:0,take:5 and b=2"}}.Encode())
+	l := PageLinks(u, figo.Page{Take: 5}, 12)
+	next := mustURL(t, l.Next)
+	assert.Equal(t, "a=1 and page=skip:5,take:5 and b=2", next.Query().Get("filter"))
+	assert.False(t, next.Query().Has("page"))
+	assert.Equal(t, figo.Page{Skip: 5, Take: 5}, pageOf(t, l.Next))
+
+	// No page anywhere (a SetPage default): a page parameter is added.
+	u = mustURL(t, "/users?filter=a%3D1")
+	assert.Equal(t, "/users?filter=a%3D1&page=skip%3A10%2Ctake%3A10", PageLinks(u, figo.Page{Take: 10}, 50).Next)
+}
+
+func TestLinkHeaderAndEnvelope(t *testing.T) {
+	l := Links{First: "/u?page=a", Next: "/u?page=b"}
+	assert.Equal(t, `</u?page=a>; rel="first", </u?page=b>; rel="next"`, l.String())
+
+	h := http.Header{}
+	SetPageHeaders(h, l, 7)
+	assert.Equal(t, l.String(), h.Get("Link"))
+	assert.Equal(t, "7", h.Get("X-Total-Count"))
+	h = http.Header{}
+	SetPageHeaders(h, Links{}, -1)
+	assert.Empty(t, h)
+
+	req := httptest.NewRequest(http.MethodGet, "/users?page=skip:2,take:2", nil)
+	f, err := Config{}.Build(req)
+	require.NoError(t, err)
+	env := NewEnvelope[string](req, f, nil, 5)
+	body, err := json.Marshal(env)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"items":[],"page":{"skip":2,"take":2,"total":5,"links":{
+		"first":"/users?page=skip%3A0%2Ctake%3A2",
+		"prev":"/users?page=skip%3A0%2Ctake%3A2",
+		"next":"/users?page=skip%3A4%2Ctake%3A2",
+		"last":"/users?page=skip%3A4%2Ctake%3A2"}}}`, string(body))
+
+	// A query sent in a JSON body is not in the URL: a link would drop the
+	// filter, so there are none.
+	req = httptest.NewRequest(http.MethodPost, "/users/search", strings.NewReader(`{"filter":"age>18","page":"skip:2,take:2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	f, err = Config{}.Build(req)
+	require.NoError(t, err)
+	body, err = json.Marshal(NewEnvelope[string](req, f, nil, 5))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"items":[],"page":{"skip":2,"take":2,"total":5,"links":{}}}`, string(body))
+}
@@ -0,0 +1,79 @@
+package figo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PageDirective renders p in the DSL's own syntax, "page=skip:S,take:T" — the
+// inverse of the page= directive parser, so AddFiltersFromString of the result
+// yields p again.
+func PageDirective(p Page) string {
+	return fmt.Sprintf("%s=skip:%d,take:%d", OperationPage, p.Skip, p.Take)
+}
+
+// WithPage returns dsl with its pagination set to p, for re-encoding a request
+// as a neighbouring page (the next/prev links of a paginated response): the
+// page= directives are replaced as ReplacePage does, and a DSL without one
+// gets the directive appended.
+func WithPage(dsl string, p Page) string {
+	out, replaced := ReplacePage(dsl, p)
+	if replaced {
+		return out
+	}
+	if strings.TrimSpace(out) == "" {
+		return PageDirective(p)
+	}
+	return strings.TrimRight(out, " \t\r\n") + " " + PageDirective(p)
+}
+
+// ReplacePage replaces every page= directive of dsl with p's, in place, and
+// reports whether there was one. In place matters: a directive written between
+// two terms ("a=1 and page=take:5 and b=2") cannot leave a dangling connector
+// behind. Only directives the parser would apply are touched — a page= inside
+// a quoted value or inside a bracketed load=[...] / relation [...] body is
+// left alone.
+func ReplacePage(dsl string, p Page) (string, bool) {
+	directive := PageDirective(p)
+	prefix := string(OperationPage) + "="
+	var b strings.Builder
+	replaced := false
+	inQuote := false
+	depth := 0
+	for i := 0; i < len(dsl); {
+		c := dsl[i]
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '[':
+			depth++
+		case c == ']':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && strings.HasPrefix(dsl[i:], prefix) && pageTokenStart(dsl, i):
+			j := i + len(prefix)
+			for j < len(dsl) && !isDSLSpace(dsl[j]) && dsl[j] != '(' && dsl[j] != ')' {
+				j++
+			}
+			b.WriteString(directive)
+			replaced = true
+			i = j
+			continue
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String(), replaced
+}
+
+// pageTokenStart reports whether a token starts at i: at the beginning of the
+// DSL or after whitespace or a parenthesis, as the parser splits tokens.
+func pageTokenStart(dsl string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := dsl[i-1]
+	return isDSLSpace(prev) || prev == '(' || prev == ')'
+}
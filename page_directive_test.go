@@ -0,0 +1,37 @@
+package figo_test
+
+import (
+	"testing"
+
+	. "github.com/bi0dread/figo/v4"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPageReplacesTheDirectiveInPlace(t *testing.T) {
+	p := Page{Skip: 40, Take: 20}
+	for in, want := range map[string]string{
+		``:                                 `page=skip:40,take:20`,
+		`a=1`:                              `a=1 page=skip:40,take:20`,
+		`a=1 page=take:5 `:                 `a=1 page=skip:40,take:20 `,
+		`a=1 and page=skip:1 and b=2`:      `a=1 and page=skip:40,take:20 and b=2`,
+		`(a=1 page=take:5)`:                `(a=1 page=skip:40,take:20)`,
+		`name="page=skip:1"`:               `name="page=skip:1" page=skip:40,take:20`,
+		`load=[Orders:page=1] sort=id:asc`: `load=[Orders:page=1] sort=id:asc page=skip:40,take:20`,
+		`mypage=3`:                         `mypage=3 page=skip:40,take:20`,
+	} {
+		assert.Equal(t, want, WithPage(in, p), in)
+	}
+
+	_, replaced := ReplacePage(`a=1 sort=id:asc`, p)
+	assert.False(t, replaced)
+
+	// The result parses back to the page it encodes, with the filter intact.
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(WithPage(`a=1 and page=take:5 and b=2`, p)))
+	require.NoError(t, f.BuildE(nil))
+	assert.Equal(t, p, f.GetPage())
+	assert.Len(t, f.GetClauses(), 1)
+	assert.IsType(t, AndExpr{}, f.GetClauses()[0])
+}
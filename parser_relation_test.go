@@ -0,0 +1,135 @@
+package figo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func relationClauses(t *testing.T, dsl string) ([]Expr, error) {
+	t.Helper()
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(dsl))
+	err := f.BuildE(nil)
+	return f.GetClauses(), err
+}
+
+func TestRelationPredicateParses(t *testing.T) {
+	clauses, err := relationClauses(t, `orders<any>[status="paid" and total>50]`)
+	require.NoError(t, err)
+	require.Len(t, clauses, 1)
+	assert.Equal(t, RelationExpr{
+		Relation:   "orders",
+		Quantifier: QuantifierAny,
+		Cond: AndExpr{Operands: []Expr{
+			EqExpr{Field: "status", Value: "paid"},
+			GtExpr{Field: "total", Value: int64(50)},
+		}},
+	}, clauses[0])
+
+	for dsl, q := range map[string]Quantifier{
+		`orders<all>[status="paid"]`:  QuantifierAll,
+		`orders<none>[status="paid"]`: QuantifierNone,
+	} {
+		clauses, err := relationClauses(t, dsl)
+		require.NoError(t, err, dsl)
+		require.Len(t, clauses, 1, dsl)
+		assert.Equal(t, q, clauses[0].(RelationExpr).Quantifier, dsl)
+	}
+}
+
+// An empty condition is the bare existence test; the parentheses and quoted
+// brackets inside a condition belong to it, not to the outer query.
+func TestRelationPredicateEmptyAndGroupedConditions(t *testing.T) {
+	clauses, err := relationClauses(t, `orders<none>[]`)
+	require.NoError(t, err)
+	assert.Equal(t, []Expr{RelationExpr{Relation: "orders", Quantifier: QuantifierNone}}, clauses)
+
+	clauses, err = relationClauses(t, `a=1 and orders<any>[(status="x]" or status="y") and total>1] and b=2`)
+	require.NoError(t, err)
+	require.Len(t, clauses, 1)
+	and := clauses[0].(AndExpr)
+	require.Len(t, and.Operands, 3)
+	rel := and.Operands[1].(RelationExpr)
+	assert.Equal(t, EqExpr{Field: "status", Value: "x]"}, rel.Cond.(AndExpr).Operands[0].(OrExpr).Operands[0])
+	assert.Equal(t, EqExpr{Field: "b", Value: int64(2)}, and.Operands[2])
+}
+
+func TestRelationPredicateNegationAndNesting(t *testing.T) {
+	clauses, err := relationClauses(t, `not orders<any>[items<none>[sku="B"]]`)
+	require.NoError(t, err)
+	require.Len(t, clauses, 1)
+	not := clauses[0].(NotExpr)
+	require.Len(t, not.Operands, 1)
+	outer := not.Operands[0].(RelationExpr)
+	assert.Equal(t, RelationExpr{Relation: "items", Quantifier: QuantifierNone, Cond: EqExpr{Field: "sku", Value: "B"}}, outer.Cond)
+}
+
+// The naming func applies to the condition's fields — they are columns of the
+// related table — but not to the relation name, which is a key into the
+// adapter's relation configuration.
+func TestRelationPredicateNaming(t *testing.T) {
+	clauses, err := relationClauses(t, `lineItems<any>[unitPrice>1]`)
+	require.NoError(t, err)
+	require.Len(t, clauses, 1)
+	rel := clauses[0].(RelationExpr)
+	assert.Equal(t, "lineItems", rel.Relation)
+	assert.Equal(t, GtExpr{Field: "unit_price", Value: int64(1)}, rel.Cond)
+}
+
+// sort=/page= inside the brackets cannot reach the outer query.
+func TestRelationPredicateIgnoresDirectives(t *testing.T) {
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(`orders<any>[status="paid" sort=total:desc page=skip:5,take:1]`))
+	err := f.BuildE(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sort= inside orders<any>[...]")
+	assert.Contains(t, err.Error(), "page= inside orders<any>[...]")
+	assert.Nil(t, f.GetSort())
+	assert.Equal(t, []Expr{RelationExpr{Relation: "orders", Quantifier: QuantifierAny, Cond: EqExpr{Field: "status", Value: "paid"}}}, f.GetClauses())
+}
+
+// An unparseable condition drops the whole predicate — it must not degrade to
+// the bare existence test — and a "not" in front of it goes with it.
+func TestRelationPredicateInvalidConditionIsDropped(t *testing.T) {
+	clauses, err := relationClauses(t, `a=1 and not orders<none>[???] or b=2`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "predicate ignored")
+	for _, c := range clauses {
+		Walk(c, func(n Expr) {
+			_, isRel := n.(*RelationExpr)
+			assert.False(t, isRel, "the predicate survived: %#v", clauses)
+			_, isNot := n.(*NotExpr)
+			assert.False(t, isNot, "the dangling not survived: %#v", clauses)
+		})
+	}
+}
+
+func TestRelationPredicateDepthCap(t *testing.T) {
+	deep := strings.Repeat("r<any>[", maxRelationDepth+1) + "a=1" + strings.Repeat("]", maxRelationDepth+1)
+	_, err := relationClauses(t, deep)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "deeper than")
+
+	ok := strings.Repeat("r<any>[", maxRelationDepth) + "a=1" + strings.Repeat("]", maxRelationDepth)
+	_, err = relationClauses(t, ok)
+	assert.NoError(t, err)
+}
+
+func TestRelationPredicateExplainCloneWalk(t *testing.T) {
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(`orders<all>[total>=30]`))
+	f.Build(nil)
+	assert.Equal(t, "orders ALL\n └── total >= 30\n", f.Explain())
+
+	c := f.Clone()
+	c.Walk(func(n Expr) {
+		if field, ok := NodeField(n); ok && field == "total" {
+			SetNodeField(n, "amount")
+		}
+	})
+	assert.Equal(t, GteExpr{Field: "amount", Value: int64(30)}, c.GetClauses()[0].(RelationExpr).Cond)
+	assert.Equal(t, GteExpr{Field: "total", Value: int64(30)}, f.GetClauses()[0].(RelationExpr).Cond, "the clone must not share the condition")
+}
@@ -0,0 +1,86 @@
+package figo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// On Elasticsearch q= is a directive: its text becomes a field-less
+// full-text search ANDed with the whole filter, wherever it was written.
+func TestSearchDirectiveAddsFullTextClause(t *testing.T) {
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(`q="red shoes" status="open" or status="new"`))
+	require.NoError(t, f.BuildE(searchStore{}))
+	assert.Equal(t, []Expr{AndExpr{Operands: []Expr{
+		OrExpr{Operands: []Expr{EqExpr{Field: "status", Value: "open"}, EqExpr{Field: "status", Value: "new"}}},
+		FullTextSearchExpr{Query: "red shoes"},
+	}}}, f.GetClauses())
+
+	// Alone, and unquoted.
+	f = New()
+	require.NoError(t, f.AddFiltersFromString(`q=shoes`))
+	require.NoError(t, f.BuildE(searchStore{}))
+	assert.Equal(t, []Expr{FullTextSearchExpr{Query: "shoes"}}, f.GetClauses())
+
+	// A rebuild does not append it twice.
+	f.Build(nil)
+	assert.Equal(t, []Expr{FullTextSearchExpr{Query: "shoes"}}, f.GetClauses())
+}
+
+// Elsewhere q= filters a field named q, as it did before the directive.
+func TestSearchDirectiveOnlyOnElasticsearch(t *testing.T) {
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(`q="shoes" or a=1`))
+	require.NoError(t, f.BuildE(nil))
+	want := []Expr{OrExpr{Operands: []Expr{EqExpr{Field: "q", Value: "shoes"}, EqExpr{Field: "a", Value: int64(1)}}}}
+	assert.Equal(t, want, f.GetClauses())
+
+	// The adapter is read at every Build.
+	require.NoError(t, f.BuildE(searchStore{}))
+	assert.Equal(t, []Expr{AndExpr{Operands: []Expr{EqExpr{Field: "a", Value: int64(1)}, FullTextSearchExpr{Query: "shoes"}}}}, f.GetClauses())
+}
+
+// =^ and =~ are operators of their own: q=^ and q=~ still filter a field
+// named q.
+func TestSearchDirectiveLeavesLikeAndRegexOnFieldQ(t *testing.T) {
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(`q=^"%x%" and q=~"^a"`))
+	require.NoError(t, f.BuildE(nil))
+	assert.Equal(t, []Expr{AndExpr{Operands: []Expr{
+		LikeExpr{Field: "q", Value: "%x%"},
+		RegexExpr{Field: "q", Value: "^a"},
+	}}}, f.GetClauses())
+}
+
+func TestSearchDirectiveDiagnostics(t *testing.T) {
+	for dsl, want := range map[string]string{
+		`a=1 q=`:                       "empty q= directive",
+		`q=a q=b`:                      "overrides an earlier q=",
+		`load=[orders:q=x]`:            "q= inside load=[orders:...]",
+		`orders<any>[q=x and total>1]`: "q= inside orders<any>[...]",
+	} {
+		f := New()
+		require.NoError(t, f.AddFiltersFromString(dsl))
+		err := f.BuildE(searchStore{})
+		require.Error(t, err, dsl)
+		assert.Contains(t, err.Error(), want, dsl)
+	}
+}
+
+// _score names no stored field, so it bypasses the NamingFunc.
+func TestScoreSortKeptVerbatim(t *testing.T) {
+	f := New()
+	f.SetNamingFunc(func(s string) string { return "t_" + s })
+	require.NoError(t, f.AddFiltersFromString(`sort=_score:desc,name:asc`))
+	require.NoError(t, f.BuildE(nil))
+	assert.Equal(t, &OrderBy{Columns: []OrderByColumn{{Name: ScoreSortField, Desc: true}, {Name: "t_name"}}}, f.GetSort())
+}
+
+func TestSearchDirectiveExplain(t *testing.T) {
+	f := New()
+	require.NoError(t, f.AddFiltersFromString(`q="red shoes"`))
+	f.Build(searchStore{})
+	assert.Equal(t, "FULLTEXT \"red shoes\"\n", f.Explain())
+}
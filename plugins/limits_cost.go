@@ -0,0 +1,291 @@
+package plugins
+
+import (
+	figo "github.com/bi0dread/figo/v4"
+)
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Counting expressions is not how a database prices a query: `id=1` on a
+// primary key and `bio=~"x"` on an unindexed text column are one expression
+// each. QueryLimits.MaxCost budgets an ESTIMATE instead, priced by a
+// CostEstimator — by default a WeightedCost, which weighs every field/operator
+// pair from a table the people who know the schema maintain:
+//
+//	lp := plugins.NewLimitsPlugin(plugins.QueryLimits{MaxCost: 100})
+//	w := plugins.DefaultWeightedCost()
+//	w.Weights["id"] = map[string]float64{"=": 0.5, "<in>": 1} // primary key
+//	w.Weights["bio"] = map[string]float64{"*": 50}             // unindexed text
+//	lp.SetCostEstimator(w)
+//
+// A query over budget is rejected by AfterParse with the predicates that cost
+// the most, so the caller (or the DBA reading the log) sees why:
+//
+//	query exceeds MaxCost: 160 > 100 (bio =~ (cost 50), relation Orders (cost 10), ...)
+
+// CostEstimator prices the predicates of a query for QueryLimits.MaxCost.
+// PredicateCost prices one leaf predicate: field is the column as rendered
+// (after the naming func) and qualified by the relations it sits under; op is
+// its operator as the DSL spells it (see RolePolicy for the vocabulary).
+// RelationFactor is what a relation multiplies: a preloaded relation is one
+// more query, a quantified relation predicate a correlated subquery, and each
+// adds its factor to the cost and multiplies its own conditions' costs by it.
+type CostEstimator interface {
+	PredicateCost(field, op string, e figo.Expr) float64
+	RelationFactor(relation string) float64
+}
+
+// WeightedCost is a CostEstimator reading weights from tables. A predicate's
+// weight is the first entry found for Weights[field][op], Weights[field]["*"],
+// Weights["*"][op] and Weights["*"]["*"], else Default; a relation's factor
+// is Relations[relation], Relations["*"], else DefaultRelationFactor. Field
+// keys are column names as rendered, so snake_case under the default naming.
+type WeightedCost struct {
+	Weights               map[string]map[string]float64
+	Default               float64
+	Relations             map[string]float64
+	DefaultRelationFactor float64
+}
+
+// DefaultWeightedCost returns a starting table: equality and null checks are
+// cheap, a bounded range costs more, an unbounded one (>, <) more again, and
+// patterns cost the most, regex above all. It knows nothing about your
+// indexes — weigh the indexed columns down and the unindexed ones up.
+func DefaultWeightedCost() WeightedCost {
+	return WeightedCost{
+		Weights: map[string]map[string]float64{
+			"*": {
+				"=": 1, "<null>": 1, "<notnull>": 2, "<in>": 2,
+				"<bet>": 3, ">": 5, ">=": 5, "<": 5, "<=": 5,
+				"!=": 5, "<nin>": 5,
+				"=^": 10, ".=^": 15, "q": 20, "=~": 25,
+			},
+		},
+		Default:               5,
+		DefaultRelationFactor: 10,
+	}
+}
+
+// PredicateCost implements CostEstimator
+func (w WeightedCost) PredicateCost(field, op string, _ figo.Expr) float64 {
+	for _, f := range []string{field, "*"} {
+		ops, ok := w.Weights[f]
+		if !ok {
+			continue
+		}
+		if c, ok := ops[op]; ok {
+			return c
+		}
+		if c, ok := ops["*"]; ok {
+			return c
+		}
+	}
+	return w.Default
+}
+
+// RelationFactor implements CostEstimator
+func (w WeightedCost) RelationFactor(relation string) float64 {
+	if c, ok := w.Relations[relation]; ok {
+		return c
+	}
+	if c, ok := w.Relations["*"]; ok {
+		return c
+	}
+	return w.DefaultRelationFactor
+}
+
+// CostItem is one priced part of a query: a predicate ("bio =~",
+// "Orders.total >", "age <bet>" for a range bounded on both sides) or a
+// relation ("relation Orders").
+type CostItem struct {
+	Predicate string
+	Cost      float64
+}
+
+// CostReport is a query's estimated cost and what it is made of, most
+// expensive first.
+type CostReport struct {
+	Total float64
+	Items []CostItem
+}
+
+// String lists the total and the five most expensive items.
+func (r CostReport) String() string {
+	var b strings.Builder
+	b.WriteString(formatCost(r.Total))
+	if len(r.Items) > 0 {
+		b.WriteString(" (")
+		b.WriteString(r.topItems(5))
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+func (r CostReport) topItems(n int) string {
+	parts := make([]string, 0, n+1)
+	for i, it := range r.Items {
+		if i == n {
+			parts = append(parts, "...")
+			break
+		}
+		parts = append(parts, it.Predicate+" (cost "+formatCost(it.Cost)+")")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatCost(c float64) string { return strconv.FormatFloat(c, 'f', -1, 64) }
+
+// SetCostEstimator replaces the estimator MaxCost is measured with (nil
+// restores DefaultWeightedCost).
+func (p *LimitsPlugin) SetCostEstimator(e CostEstimator) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.estimator = e
+}
+
+// EstimateCost prices f's query as AfterParse does: after expression filters,
+// without clause finalizers (see cloneForInspection).
+func (p *LimitsPlugin) EstimateCost(f figo.Figo) CostReport {
+	c := cloneForInspection(f)
+	c.Build(nil)
+	return p.estimateCost(c.GetClauses(), c.GetPreloads())
+}
+
+func (p *LimitsPlugin) costEstimator() CostEstimator {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.estimator == nil {
+		return DefaultWeightedCost()
+	}
+	return p.estimator
+}
+
+// estimateCost prices built clauses and preloads.
+func (p *LimitsPlugin) estimateCost(clauses []figo.Expr, preloads map[string][]figo.Expr) CostReport {
+	est := p.costEstimator()
+	costs := map[string]float64{}
+	for _, e := range clauses {
+		addCost(est, e, "", 1, costs)
+	}
+	relations := make([]string, 0, len(preloads))
+	for rel := range preloads {
+		relations = append(relations, rel)
+	}
+	sort.Strings(relations)
+	for _, rel := range relations {
+		factor := est.RelationFactor(rel)
+		costs["relation "+rel] += factor
+		for _, e := range preloads[rel] {
+			addCost(est, e, rel+".", factor, costs)
+		}
+	}
+
+	var r CostReport
+	for pred, cost := range costs {
+		r.Total += cost
+		r.Items = append(r.Items, CostItem{Predicate: pred, Cost: cost})
+	}
+	sort.Slice(r.Items, func(i, j int) bool {
+		if r.Items[i].Cost != r.Items[j].Cost {
+			return r.Items[i].Cost > r.Items[j].Cost
+		}
+		return r.Items[i].Predicate < r.Items[j].Predicate
+	})
+	return r
+}
+
+// addCost adds e's predicates to costs, keyed "field op" (the same predicate
+// used twice is one item costing both). prefix qualifies fields under a
+// relation and mult is the product of the enclosing relation factors.
+//
+// A lower and an upper bound on one field ANDed together (a>1 and a<5) are
+// one bounded range, priced as <bet>: the unbounded-range weight of > and <
+// is for a range open on one side, which scans to the end of the index.
+func addCost(est CostEstimator, e figo.Expr, prefix string, mult float64, costs map[string]float64) {
+	switch v := e.(type) {
+	case nil:
+	case figo.AndExpr:
+		conjuncts := flattenAnd(v.Operands)
+		for _, r := range pairBounds(conjuncts) {
+			field := prefix + r.Field
+			costs[field+" <bet>"] += mult * est.PredicateCost(field, "<bet>", r)
+		}
+		for _, o := range conjuncts {
+			if o != nil {
+				addCost(est, o, prefix, mult, costs)
+			}
+		}
+	case figo.OrExpr:
+		for _, o := range v.Operands {
+			addCost(est, o, prefix, mult, costs)
+		}
+	case figo.NotExpr:
+		for _, o := range v.Operands {
+			addCost(est, o, prefix, mult, costs)
+		}
+	case figo.RelationExpr:
+		rel := prefix + v.Relation
+		factor := est.RelationFactor(rel)
+		costs["relation "+rel] += mult * factor
+		addCost(est, v.Cond, rel+".", mult*factor, costs)
+	default:
+		op, ok := figo.ExprOperator(e)
+		if !ok {
+			op = fmt.Sprintf("%T", e)
+		}
+		field := prefix + figo.ExprField(e)
+		label := field + " " + op
+		if figo.ExprField(e) == "" {
+			label = prefix + op
+		}
+		costs[label] += mult * est.PredicateCost(field, op, e)
+	}
+}
+
+// pairBounds takes a lower and an upper bound on the same field out of
+// conjuncts (setting them to nil) and returns each pair as the range they
+// bound. A bound left without a partner stays, to be priced alone.
+func pairBounds(conjuncts []figo.Expr) []figo.BetweenExpr {
+	lower := map[string]int{}
+	var ranges []figo.BetweenExpr
+	for i, c := range conjuncts {
+		switch v := c.(type) {
+		case figo.GtExpr, figo.GteExpr:
+			if _, ok := lower[figo.ExprField(v)]; !ok {
+				lower[figo.ExprField(v)] = i
+			}
+		}
+	}
+	for i, c := range conjuncts {
+		var high any
+		switch v := c.(type) {
+		case figo.LtExpr:
+			high = v.Value
+		case figo.LteExpr:
+			high = v.Value
+		default:
+			continue
+		}
+		field := figo.ExprField(c)
+		j, ok := lower[field]
+		if !ok {
+			continue
+		}
+		var low any
+		switch v := conjuncts[j].(type) {
+		case figo.GtExpr:
+			low = v.Value
+		case figo.GteExpr:
+			low = v.Value
+		}
+		ranges = append(ranges, figo.BetweenExpr{Field: field, Low: low, High: high})
+		conjuncts[i], conjuncts[j] = nil, nil
+		delete(lower, field)
+	}
+	return ranges
+}
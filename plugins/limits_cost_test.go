@@ -0,0 +1,98 @@
+package plugins
+
+import (
+	. "github.com/bi0dread/figo/v4"
+	. "github.com/bi0dread/figo/v4/adapters"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitsCostEstimate(t *testing.T) {
+	lp := NewLimitsPlugin(QueryLimits{})
+	w := DefaultWeightedCost()
+	w.Weights["id"] = map[string]float64{"=": 0.5}
+	w.Weights["bio"] = map[string]float64{"*": 50}
+	w.Relations = map[string]float64{"Orders": 4}
+	lp.SetCostEstimator(w)
+
+	f := New()
+	require.NoError(t, f.RegisterPlugin(lp))
+	require.NoError(t, f.AddFiltersFromString(`id=1 and (bio=~"x" or not(status="a")) and age>18 load=[Orders:total>5 and total<9]`))
+
+	r := lp.EstimateCost(f)
+	assert.Equal(t, CostReport{Total: 0.5 + 50 + 1 + 5 + 4 + 4*3, Items: []CostItem{
+		{Predicate: "bio =~", Cost: 50},
+		{Predicate: "Orders.total <bet>", Cost: 12},
+		{Predicate: "age >", Cost: 5},
+		{Predicate: "relation Orders", Cost: 4},
+		{Predicate: "status =", Cost: 1},
+		{Predicate: "id =", Cost: 0.5},
+	}}, r)
+	assert.Equal(t, "72.5 (bio =~ (cost 50), Orders.total <bet> (cost 12), age > (cost 5), relation Orders (cost 4), status = (cost 1), ...)", r.String())
+
+	// A quantified relation multiplies like a preload, nested ones compound.
+	f = New()
+	require.NoError(t, f.AddFiltersFromString(`orders<any>[items<any>[sku="x"]]`))
+	r = NewLimitsPlugin(QueryLimits{}).EstimateCost(f)
+	assert.Equal(t, 10+100+100.0, r.Total)
+	assert.Equal(t, CostItem{Predicate: "relation orders.items", Cost: 100}, r.Items[1])
+}
+
+// A range bounded on both sides costs less than one open on a side, not the
+// two bounds' weights added up.
+func TestLimitsCostPricesBoundedRanges(t *testing.T) {
+	lp := NewLimitsPlugin(QueryLimits{})
+	for dsl, want := range map[string]float64{
+		`a>1`:                      5,
+		`a>1 and a<5`:              3,
+		`a>=1 and b=2 and a<=5`:    3 + 1,
+		`a>1 and (a<5 and b<2)`:    3 + 5,
+		`a>1 and a>2 and a<5`:      3 + 5,
+		`a>1 or a<5`:               5 + 5,
+		`a>1 and b<5`:              5 + 5,
+		`a>1 and (a<5 or b=1)`:     5 + 5 + 1,
+		`orders<any>[a>1 and a<5]`: 10 + 10*3,
+		`a<bet>(1..5) and a>1`:     3 + 5,
+	} {
+		f := New()
+		require.NoError(t, f.AddFiltersFromString(dsl))
+		assert.Equal(t, want, lp.EstimateCost(f).Total, dsl)
+	}
+}
+
+func TestLimitsMaxCostRejects(t *testing.T) {
+	lp := NewLimitsPlugin(QueryLimits{MaxCost: 30})
+	f := New()
+	require.NoError(t, f.RegisterPlugin(lp))
+
+	require.NoError(t, f.AddFiltersFromString(`a=1 and b=~"x"`))
+	err := f.AddFiltersFromString(`a=1 and b=~"x" and c=^"y%"`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "query exceeds MaxCost: 36 > 30 (b =~ (cost 25), c =^ (cost 10), a = (cost 1))")
+
+	// A custom estimator.
+	lp.SetCostEstimator(WeightedCost{Default: 40})
+	assert.ErrorContains(t, f.AddFiltersFromString(`a=1`), "query exceeds MaxCost: 40 > 30")
+	lp.SetCostEstimator(nil)
+	assert.NoError(t, f.AddFiltersFromString(`a=1`))
+}
+
+func TestLimitsMaxCostAtBuild(t *testing.T) {
+	// AfterParse never sees an AddFilter clause: the build prices it and
+	// fails closed.
+	f := limitedFigo(t, QueryLimits{MaxCost: 20})
+	f.AddFilter(RegexExpr{Field: "name", Value: "x"})
+	err := f.BuildE(RawAdapter{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "query exceeds MaxCost: 25 > 20 (name =~ (cost 25))")
+	where, _, err := BuildRawWhere(f)
+	require.NoError(t, err)
+	assert.Equal(t, "1=0", where)
+
+	f = limitedFigo(t, QueryLimits{MaxCost: 30})
+	f.AddFilter(RegexExpr{Field: "name", Value: "x"})
+	assert.NoError(t, f.BuildE(RawAdapter{}))
+}
@@ -0,0 +1,139 @@
+package plugins
+
+import (
+	. "github.com/bi0dread/figo/v4"
+	. "github.com/bi0dread/figo/v4/adapters"
+
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func limitedFigo(t *testing.T, limits QueryLimits) Figo {
+	t.Helper()
+	f := New()
+	require.NoError(t, f.RegisterPlugin(NewLimitsPlugin(limits)))
+	return f
+}
+
+func TestLimitsRejectPagingAndSizes(t *testing.T) {
+	limits := QueryLimits{MaxTake: 100, MaxSkip: 1000, MaxInListSize: 3, MaxValueBytes: 8, MaxSortKeys: 2}
+
+	for dsl, want := range map[string]string{
+		`page=take:1000000`:                       "query exceeds MaxTake: 1000000 > 100",
+		`page=skip:5000,take:10`:                  "query exceeds MaxSkip: 5000 > 1000",
+		`id<in>[1,2,3,4]`:                         "query exceeds MaxInListSize: 4 > 3 (field id)",
+		`load=[Orders:sku<nin>["a","b","c","d"]]`: "query exceeds MaxInListSize: 4 > 3 (field sku)",
+		`name="` + strings.Repeat("x", 9) + `"`:   "query exceeds MaxValueBytes: 9 > 8 (field name)",
+		`q="` + strings.Repeat("x", 9) + `"`:      "query exceeds MaxValueBytes: 9 > 8 (field q)",
+		`a=1 sort=a:asc,b:asc,c:desc`:             "query exceeds MaxSortKeys: 3 > 2",
+	} {
+		f := limitedFigo(t, limits)
+		err := f.AddFiltersFromString(dsl)
+		require.Error(t, err, dsl)
+		assert.Contains(t, err.Error(), want, dsl)
+	}
+
+	f := limitedFigo(t, limits)
+	require.NoError(t, f.AddFiltersFromString(`id<in>[1,2,3] and name="12345678" page=skip:1000,take:100 sort=a:asc,b:desc`))
+}
+
+// The ceilings hold however the page, sort or values were set: what never
+// went through the DSL is refused at Build, and the build fails closed.
+func TestLimitsRejectAtBuild(t *testing.T) {
+	limits := QueryLimits{MaxTake: 100, MaxSkip: 1000, MaxInListSize: 3, MaxValueBytes: 8, MaxSortKeys: 1}
+	for name, tc := range map[string]struct {
+		set  func(f Figo)
+		want string
+	}{
+		"take":   {func(f Figo) { f.SetPage(0, 1000000) }, "query exceeds MaxTake: 1000000 > 100"},
+		"skip":   {func(f Figo) { f.SetPage(5000, 10) }, "query exceeds MaxSkip: 5000 > 1000"},
+		"sort":   {func(f Figo) { f.SetSort(&OrderBy{Columns: []OrderByColumn{{Name: "a"}, {Name: "b"}}}) }, "query exceeds MaxSortKeys: 2 > 1"},
+		"list":   {func(f Figo) { f.AddFilter(InExpr{Field: "id", Values: []any{1, 2, 3, 4}}) }, "query exceeds MaxInListSize: 4 > 3 (field id)"},
+		"value":  {func(f Figo) { f.AddFilter(EqExpr{Field: "name", Value: strings.Repeat("x", 9)}) }, "query exceeds MaxValueBytes: 9 > 8 (field name)"},
+		"in dsl": {func(f Figo) { _ = f.AddFiltersFromString(`a=1`); f.SetPage(0, 500) }, "query exceeds MaxTake: 500 > 100"},
+	} {
+		f := limitedFigo(t, limits)
+		tc.set(f)
+		err := f.BuildE(RawAdapter{})
+		require.Error(t, err, name)
+		assert.Contains(t, err.Error(), tc.want, name)
+		where, _, err := BuildRawWhere(f)
+		require.NoError(t, err, name)
+		assert.Equal(t, "1=0", where, name)
+	}
+
+	// Clamped ceilings still clamp.
+	f := limitedFigo(t, QueryLimits{MaxTake: 100, Clamp: ClampTake})
+	f.SetPage(0, 1000000)
+	require.NoError(t, f.BuildE(RawAdapter{}))
+	assert.Equal(t, Page{Take: 100}, f.GetPage())
+}
+
+func TestLimitsClampPagingAndSizes(t *testing.T) {
+	limits := QueryLimits{
+		MaxTake: 100, MaxSkip: 1000, MaxInListSize: 2, MaxSortKeys: 1,
+		Clamp: ClampTake | ClampSkip | ClampInListSize | ClampSortKeys,
+	}
+	f := limitedFigo(t, limits)
+	require.NoError(t, f.AddFiltersFromString(`id<in>[1,2,3] load=[Orders:sku<in>["a","b","c"]] page=skip:5000,take:500 sort=a:asc,b:desc`))
+	for i := 0; i < 2; i++ { // a rebuild clamps the same way
+		require.NoError(t, f.BuildE(RawAdapter{}))
+		assert.Equal(t, Page{Skip: 1000, Take: 100}, f.GetPage())
+		assert.Equal(t, []OrderByColumn{{Name: "a"}}, f.GetSort().Columns)
+		assert.Equal(t, []Expr{InExpr{Field: "id", Values: []any{int64(1), int64(2)}}}, f.GetClauses())
+		assert.Equal(t, []Expr{InExpr{Field: "sku", Values: []any{"a", "b"}}}, f.GetPreloads()["Orders"])
+	}
+
+	// Dropping values from a <nin> list would widen it: rejected regardless.
+	f = limitedFigo(t, limits)
+	assert.ErrorContains(t, f.AddFiltersFromString(`id<nin>[1,2,3]`), "MaxInListSize")
+
+	// So would cutting an <in> list under a negation.
+	for _, dsl := range []string{`not(id<in>[1,2,3])`, `orders<none>[id<in>[1,2,3]]`, `not(orders<any>[id<in>[1,2,3]])`} {
+		f = limitedFigo(t, limits)
+		assert.ErrorContains(t, f.AddFiltersFromString(dsl), "query exceeds MaxInListSize: 3 > 2 (field id)", dsl)
+	}
+	f = limitedFigo(t, limits)
+	f.AddFilter(NotExpr{Operands: []Expr{InExpr{Field: "id", Values: []any{1, 2, 3}}}})
+	assert.ErrorContains(t, f.BuildE(RawAdapter{}), "MaxInListSize")
+	where, _, err := BuildRawWhere(f)
+	require.NoError(t, err)
+	assert.Equal(t, "1=0", where, "not(id IN (1,2)) would match row 3")
+
+	// Two negations cancel out: the list is clamped.
+	f = limitedFigo(t, limits)
+	require.NoError(t, f.AddFiltersFromString(`not(orders<none>[id<in>[1,2,3]])`))
+	require.NoError(t, f.BuildE(RawAdapter{}))
+	rel := f.GetClauses()[0].(NotExpr).Operands[0].(RelationExpr)
+	assert.Equal(t, InExpr{Field: "id", Values: []any{int64(1), int64(2)}}, rel.Cond)
+}
+
+func TestLimitsDefaultTake(t *testing.T) {
+	f := limitedFigo(t, QueryLimits{DefaultTake: 20, MaxTake: 100})
+	require.NoError(t, f.AddFiltersFromString(`a=1`))
+	f.Build(nil)
+	assert.Equal(t, Page{Take: 20}, f.GetPage())
+
+	// A page= directive wins over the default.
+	require.NoError(t, f.AddFiltersFromString(`a=1 page=take:50`))
+	f.Build(nil)
+	assert.Equal(t, Page{Take: 50}, f.GetPage())
+
+	// With only a ceiling, an absent page is the ceiling, not every row.
+	f = limitedFigo(t, QueryLimits{MaxTake: 100})
+	f.Build(nil)
+	assert.Equal(t, Page{Take: 100}, f.GetPage())
+
+	// A default above the ceiling is capped to it.
+	f = limitedFigo(t, QueryLimits{DefaultTake: 500, MaxTake: 100})
+	f.Build(nil)
+	assert.Equal(t, 100, f.GetPage().Take)
+
+	// No paging limits: the page is left alone.
+	f = limitedFigo(t, DefaultQueryLimits())
+	f.Build(nil)
+	assert.Equal(t, Page{}, f.GetPage())
+}
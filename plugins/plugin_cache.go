@@ -1094,6 +1094,8 @@ func appendValueTypes(b byteWriter, e figo.Expr) {
 		for _, op := range x.Operands {
 			appendValueTypes(b, op)
 		}
+	case figo.RelationExpr:
+		appendValueTypes(b, x.Cond)
 	}
 }
 
@@ -0,0 +1,258 @@
+package plugins
+
+import (
+	figo "github.com/bi0dread/figo/v4"
+)
+
+import (
+	"sync"
+)
+
+// DefaultsPlugin adds default predicates — `deleted_at<null>`,
+// `archived=false` — to queries that do not say otherwise. Where a
+// ScopePlugin scope is mandatory, a default is a convenience the caller may
+// override. Mentioning a field of the default anywhere in the query lifts it:
+// `deleted_at<notnull>` lists the trash, so `deleted_at<null>` is not added,
+// and `archived=true or archived=false` lists everything. So does a top-level
+// opt-out flag you allow, `with_deleted=true`, which lifts the defaults on the
+// fields the flag names and is itself removed from the query (the table has
+// no such column); `with_deleted=false` is removed too and lifts nothing.
+//
+//	dp := plugins.NewDefaultsPlugin(figo.IsNullExpr{Field: "deleted_at"})
+//	dp.AllowOptOut("with_deleted", "deleted_at")
+//	f.RegisterPlugin(dp)
+//	f.AddFiltersFromString(`name="x"`)                      // ... AND deleted_at IS NULL
+//	f.AddFiltersFromString(`name="x" and with_deleted=true`) // name = 'x'
+//
+// Because the caller can lift them, defaults are NOT access control: rows a
+// caller must never see belong in a ScopePlugin scope or a PolicyPlugin row
+// predicate, which nothing in the DSL can remove.
+//
+// Preloads get the defaults registered for their relation (AddPreloadDefault,
+// AddPreloadDefaultAll) under the same rules, judged on the preload's own
+// conditions: `load=[Orders:with_deleted=true]` lists an order's deleted
+// lines without lifting the parent's default.
+//
+// The defaults are added by the ClauseFinalizer and PreloadFinalizer hooks,
+// at the end of every Build, so an unfiltered query gets them too. Register
+// the plugin BEFORE plugins that judge the finished clauses (PolicyPlugin,
+// IndexAdvisorPlugin), so they see the defaults and never see the flag; a
+// FieldsPlugin whitelist or ignore list that drops the flag leaves the
+// defaults in place, which is the safe outcome.
+type DefaultsPlugin struct {
+	mu              sync.RWMutex
+	defaults        []figo.Expr
+	preloadDefaults map[string][]figo.Expr // relation -> default conditions
+	allPreloads     []figo.Expr            // defaults for every preloaded relation
+	optOuts         map[string][]string    // flag -> fields it lifts (none: all)
+}
+
+// NewDefaultsPlugin creates a defaults plugin adding the given predicates
+func NewDefaultsPlugin(defaults ...figo.Expr) *DefaultsPlugin {
+	p := &DefaultsPlugin{}
+	p.AddDefault(defaults...)
+	return p
+}
+
+// AddDefault registers additional default predicates for the top-level query
+func (p *DefaultsPlugin) AddDefault(defaults ...figo.Expr) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, d := range defaults {
+		if d != nil {
+			p.defaults = append(p.defaults, d)
+		}
+	}
+}
+
+// AddPreloadDefault registers default predicates for ONE preloaded relation,
+// named as it appears in load=[relation:...].
+func (p *DefaultsPlugin) AddPreloadDefault(relation string, defaults ...figo.Expr) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.preloadDefaults == nil {
+		p.preloadDefaults = make(map[string][]figo.Expr)
+	}
+	for _, d := range defaults {
+		if d != nil {
+			p.preloadDefaults[relation] = append(p.preloadDefaults[relation], d)
+		}
+	}
+}
+
+// AddPreloadDefaultAll registers default predicates for EVERY preloaded
+// relation. Only correct when every relation the DSL can name has the column.
+func (p *DefaultsPlugin) AddPreloadDefaultAll(defaults ...figo.Expr) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, d := range defaults {
+		if d != nil {
+			p.allPreloads = append(p.allPreloads, d)
+		}
+	}
+}
+
+// AllowOptOut accepts `flag=true` as a top-level condition lifting the
+// defaults on fields (every default, when fields is empty). A flag that is
+// not allowed here is an ordinary condition on a column of that name.
+func (p *DefaultsPlugin) AllowOptOut(flag string, fields ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.optOuts == nil {
+		p.optOuts = make(map[string][]string)
+	}
+	p.optOuts[flag] = append([]string(nil), fields...)
+}
+
+// GetDefaults returns a copy of the registered top-level defaults
+func (p *DefaultsPlugin) GetDefaults() []figo.Expr {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	out := make([]figo.Expr, len(p.defaults))
+	copy(out, p.defaults)
+	return out
+}
+
+// Name implements Plugin
+func (p *DefaultsPlugin) Name() string { return "figo-defaults" }
+
+// Version implements Plugin
+func (p *DefaultsPlugin) Version() string { return "1.0.0" }
+
+// Initialize implements Plugin
+func (p *DefaultsPlugin) Initialize(figo.Figo) error { return nil }
+
+// BeforeQuery implements Plugin
+func (p *DefaultsPlugin) BeforeQuery(figo.Figo, any) error { return nil }
+
+// AfterQuery implements Plugin
+func (p *DefaultsPlugin) AfterQuery(figo.Figo, any, any) error { return nil }
+
+// BeforeParse implements Plugin
+func (p *DefaultsPlugin) BeforeParse(_ figo.Figo, dsl string) (string, error) { return dsl, nil }
+
+// AfterParse implements Plugin
+func (p *DefaultsPlugin) AfterParse(figo.Figo, string) error { return nil }
+
+// FinalizeClauses implements ClauseFinalizer: it removes the allowed opt-out
+// flags and appends each default the query neither mentions nor lifts.
+func (p *DefaultsPlugin) FinalizeClauses(f figo.Figo, clauses []figo.Expr) []figo.Expr {
+	return p.apply(f, clauses, p.GetDefaults())
+}
+
+// FinalizePreloads implements figo.PreloadFinalizer: FinalizeClauses for one
+// relation's conditions, with the defaults registered for it.
+func (p *DefaultsPlugin) FinalizePreloads(f figo.Figo, relation string, conds []figo.Expr) []figo.Expr {
+	p.mu.RLock()
+	defaults := append(append([]figo.Expr(nil), p.preloadDefaults[relation]...), p.allPreloads...)
+	p.mu.RUnlock()
+	return p.apply(f, conds, defaults)
+}
+
+func (p *DefaultsPlugin) apply(f figo.Figo, clauses []figo.Expr, defaults []figo.Expr) []figo.Expr {
+	var naming figo.NamingFunc
+	if f != nil {
+		naming = f.GetNamingFunc()
+	}
+	convert := func(name string) string {
+		if naming == nil {
+			return name
+		}
+		return naming(name)
+	}
+
+	p.mu.RLock()
+	flags := make(map[string][]string, len(p.optOuts))
+	for flag, fields := range p.optOuts {
+		flags[convert(flag)] = fields
+	}
+	p.mu.RUnlock()
+
+	clauses, raised := stripFlags(clauses, flags)
+	if len(defaults) == 0 {
+		return clauses
+	}
+
+	lifted := map[string]bool{}
+	all := false
+	for _, flag := range raised {
+		if len(flags[flag]) == 0 {
+			all = true
+		}
+		for _, field := range flags[flag] {
+			lifted[convert(field)] = true
+		}
+	}
+	if all {
+		return clauses
+	}
+	for _, field := range filterFields(flattenAnd(clauses)) {
+		lifted[field] = true
+	}
+
+	for _, d := range defaults {
+		def := scopeExprFor(f, d)
+		overridden := false
+		for _, field := range filterFields([]figo.Expr{def}) {
+			if lifted[field] {
+				overridden = true
+				break
+			}
+		}
+		if !overridden && !containsEqualExpr(clauses, def) {
+			clauses = append(clauses, def)
+		}
+	}
+	return clauses
+}
+
+// stripFlags removes the top-level `flag=true`/`flag=false` conditions for
+// the allowed flags from clauses (descending into top-level ANDs only — a
+// flag under an or cannot be read as the query's intent) and returns the
+// flags raised with true.
+func stripFlags(clauses []figo.Expr, flags map[string][]string) ([]figo.Expr, []string) {
+	if len(flags) == 0 {
+		return clauses, nil
+	}
+	var raised []string
+	var strip func(e figo.Expr) (figo.Expr, bool)
+	strip = func(e figo.Expr) (figo.Expr, bool) {
+		switch v := e.(type) {
+		case figo.EqExpr:
+			if _, ok := flags[v.Field]; !ok {
+				return e, true
+			}
+			on, ok := v.Value.(bool)
+			if !ok {
+				return e, true
+			}
+			if on {
+				raised = append(raised, v.Field)
+			}
+			return nil, false
+		case figo.AndExpr:
+			var kept []figo.Expr
+			for _, o := range v.Operands {
+				if o, keep := strip(o); keep {
+					kept = append(kept, o)
+				}
+			}
+			switch len(kept) {
+			case 0:
+				return nil, false
+			case 1:
+				return kept[0], true
+			}
+			return figo.AndExpr{Operands: kept}, true
+		}
+		return e, true
+	}
+
+	out := make([]figo.Expr, 0, len(clauses))
+	for _, c := range clauses {
+		if c, keep := strip(c); keep {
+			out = append(out, c)
+		}
+	}
+	return out, raised
+}
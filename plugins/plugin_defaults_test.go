@@ -0,0 +1,91 @@
+package plugins
+
+import (
+	. "github.com/bi0dread/figo/v4"
+	. "github.com/bi0dread/figo/v4/adapters"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func defaultsFigo(t *testing.T, dp *DefaultsPlugin, dsl string) Figo {
+	t.Helper()
+	f := New()
+	require.NoError(t, f.RegisterPlugin(dp))
+	require.NoError(t, f.AddFiltersFromString(dsl), dsl)
+	require.NoError(t, f.BuildE(RawAdapter{}), dsl)
+	return f
+}
+
+func TestDefaultsApplyUnlessMentioned(t *testing.T) {
+	dp := NewDefaultsPlugin(IsNullExpr{Field: "deletedAt"}, EqExpr{Field: "archived", Value: false})
+
+	for dsl, want := range map[string]string{
+		``:                                "`deleted_at` IS NULL AND `archived` = ?",
+		`name="x"`:                        "`name` = ? AND `deleted_at` IS NULL AND `archived` = ?",
+		`name="x" and deletedAt<notnull>`: "(`name` = ? AND `deleted_at` IS NOT NULL) AND `archived` = ?",
+		`archived=true or archived=false`: "(`archived` = ? OR `archived` = ?) AND `deleted_at` IS NULL",
+		`not(deleted_at<null>) and archived=true`: "(NOT (`deleted_at` IS NULL) AND `archived` = ?)",
+	} {
+		f := defaultsFigo(t, dp, dsl)
+		where, _, err := BuildRawWhere(f)
+		require.NoError(t, err, dsl)
+		assert.Equal(t, want, where, dsl)
+	}
+
+	// A related row's archived is not this table's: the default stays.
+	f := defaultsFigo(t, dp, `orders<any>[archived=true]`)
+	assert.Contains(t, f.GetClauses(), Expr(EqExpr{Field: "archived", Value: false}))
+
+	// Rebuilds never duplicate a default.
+	f = New()
+	require.NoError(t, f.RegisterPlugin(dp))
+	f.AddFilter(EqExpr{Field: "id", Value: 1})
+	for i := 0; i < 2; i++ {
+		require.NoError(t, f.BuildE(RawAdapter{}))
+		assert.Len(t, f.GetClauses(), 3)
+	}
+}
+
+func TestDefaultsOptOutFlag(t *testing.T) {
+	dp := NewDefaultsPlugin(IsNullExpr{Field: "deleted_at"}, EqExpr{Field: "archived", Value: false})
+	dp.AllowOptOut("withDeleted", "deleted_at")
+
+	for dsl, want := range map[string]string{
+		`name="x" and with_deleted=true`:     "`name` = ? AND `archived` = ?",
+		`withDeleted=true`:                   "`archived` = ?",
+		`name="x" and withDeleted=false`:     "`name` = ? AND `deleted_at` IS NULL AND `archived` = ?",
+		`(a=1 and with_deleted=true) or b=2`: "((`a` = ? AND `with_deleted` = ?) OR `b` = ?) AND `deleted_at` IS NULL AND `archived` = ?",
+	} {
+		f := defaultsFigo(t, dp, dsl)
+		where, _, err := BuildRawWhere(f)
+		require.NoError(t, err, dsl)
+		assert.Equal(t, want, where, dsl)
+	}
+
+	// A flag allowed with no fields lifts every default; a flag that is not
+	// allowed is an ordinary condition.
+	dp.AllowOptOut("include_all")
+	where, _, err := BuildRawWhere(defaultsFigo(t, dp, `id=1 and include_all=true`))
+	require.NoError(t, err)
+	assert.Equal(t, "`id` = ?", where)
+	where, _, err = BuildRawWhere(defaultsFigo(t, dp, `id=1 and with_archived=true`))
+	require.NoError(t, err)
+	assert.Equal(t, "(`id` = ? AND `with_archived` = ?) AND `deleted_at` IS NULL AND `archived` = ?", where)
+}
+
+func TestDefaultsPreloads(t *testing.T) {
+	dp := NewDefaultsPlugin(IsNullExpr{Field: "deleted_at"})
+	dp.AddPreloadDefault("Orders", EqExpr{Field: "voided", Value: false})
+	dp.AddPreloadDefaultAll(IsNullExpr{Field: "deleted_at"})
+	dp.AllowOptOut("with_deleted", "deleted_at")
+
+	f := defaultsFigo(t, dp, `id=1 load=[Orders:total>5 and with_deleted=true | Notes:body="x"]`)
+	assert.Equal(t, []Expr{GtExpr{Field: "total", Value: int64(5)}, EqExpr{Field: "voided", Value: false}}, f.GetPreloads()["Orders"])
+	assert.Equal(t, []Expr{EqExpr{Field: "body", Value: "x"}, IsNullExpr{Field: "deleted_at"}}, f.GetPreloads()["Notes"])
+	where, _, err := BuildRawWhere(f)
+	require.NoError(t, err)
+	assert.Equal(t, "`id` = ? AND `deleted_at` IS NULL", where, "a preload's flag does not lift the parent's default")
+}
@@ -289,7 +289,7 @@ func (p *FieldsPlugin) FinalizeClauses(f figo.Figo, clauses []figo.Expr) []figo.
 		return true
 	}
 
-	p.enforceSort(f, permitted)
+	p.enforceSort(f, permitted, denied)
 	if !p.enforceSelectFields(f, permitted, allowed, whitelist) {
 		// Every requested column is forbidden and there is no permitted
 		// projection to substitute. Leaving the caller's set intact returned
@@ -303,8 +303,11 @@ func (p *FieldsPlugin) FinalizeClauses(f figo.Figo, clauses []figo.Expr) []figo.
 	return clauses
 }
 
-// enforceSort drops forbidden columns from the sort specification.
-func (p *FieldsPlugin) enforceSort(f figo.Figo, permitted func(string) bool) {
+// enforceSort drops forbidden columns from the sort specification. The
+// relevance pseudo-column (figo.ScoreSortField) reads no stored value, so a
+// whitelist that does not list it still permits it; an explicit ignore of it
+// is honored.
+func (p *FieldsPlugin) enforceSort(f figo.Figo, permitted, denied func(string) bool) {
 	sort := f.GetSort()
 	if sort == nil || len(sort.Columns) == 0 {
 		return
@@ -312,6 +315,10 @@ func (p *FieldsPlugin) enforceSort(f figo.Figo, permitted func(string) bool) {
 
 	kept := make([]figo.OrderByColumn, 0, len(sort.Columns))
 	for _, col := range sort.Columns {
+		if col.Name == figo.ScoreSortField && !denied(col.Name) {
+			kept = append(kept, col)
+			continue
+		}
 		if permitted(col.Name) {
 			kept = append(kept, col)
 		}
@@ -0,0 +1,398 @@
+package plugins
+
+import (
+	figo "github.com/bi0dread/figo/v4"
+)
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// IndexAdvisorPlugin refuses (or reports) queries that cannot use any index
+// of the table they run against — the full scan that a harmless-looking
+// `name=^"%x"` turns into on a table of a hundred million rows.
+//
+// The indexes are declared, not discovered: an IndexCatalog lists each
+// table's (or collection's) indexes, single or compound, with their key
+// order. A query is indexable when it has a SARGABLE condition on the LEADING
+// key of some index, in a position every matching row must satisfy (a
+// top-level conjunct): an equality, <in>, a range (>, >=, <, <=, <bet>),
+// <null>, or a LIKE with a literal prefix (`abc%`). The later keys of a
+// compound index are only reachable through its earlier ones — (tenant_id,
+// created_at) serves tenant_id=1 and tenant_id=1 and created_at>x, never
+// created_at>x alone. An `or` is indexable when each of its branches is (an
+// index union). Conditions under not(...), !=, <nin>, regexes, ILIKE and
+// leading-wildcard LIKEs use no index.
+//
+// A sort= must follow some index's key order — every key in direction, or
+// every key reversed — so the engine reads rows in order instead of sorting
+// them all. Keys the query pins with an equality may be skipped: with index
+// (tenant_id, created_at), `tenant_id=1 sort=created_at:desc` is in order.
+//
+// A query with no filter at all is accepted only with a page size (a LIMIT
+// stops the scan early) and an index-ordered sort, if it sorts.
+//
+//	catalog := plugins.IndexCatalog{
+//	    "users": {
+//	        plugins.IndexOn("pk", "id"),
+//	        plugins.IndexOn("by_tenant", "tenant_id", "created_at:desc"),
+//	    },
+//	}
+//	ia, err := plugins.NewIndexAdvisorPlugin(catalog, plugins.IndexAdvisorConfig{Table: "users"})
+//	f.RegisterPlugin(ia) // register LAST
+//
+// The check runs on the finished clause list, from FinalizeClausesContext, so
+// a tenant scope or policy row predicate another plugin adds counts — which
+// is why the advisor must be registered after those plugins. A rejected query
+// fails closed and BuildContext/BuildE report why; with IndexWarn the query is
+// built as is and each finding goes to OnWarn instead. Preload conditions are
+// not checked: a preload query is driven by the relation's foreign key.
+//
+// Field names are matched as rendered (after the naming func), which is what
+// an index's keys are: column names.
+type IndexAdvisorPlugin struct {
+	mu      sync.RWMutex
+	table   string
+	indexes []Index
+	config  IndexAdvisorConfig
+}
+
+// IndexCatalog lists the indexes of each table or collection, by name.
+type IndexCatalog map[string][]Index
+
+// Index is one declared index: its keys in order.
+type Index struct {
+	Name string
+	Keys []IndexKey
+}
+
+// IndexKey is one key of an index and its direction.
+type IndexKey struct {
+	Field string
+	Desc  bool
+}
+
+// IndexOn declares an index from keys written as in a sort= directive:
+// "created_at" or "created_at:desc".
+func IndexOn(name string, keys ...string) Index {
+	idx := Index{Name: name}
+	for _, k := range keys {
+		field, dir, _ := strings.Cut(k, ":")
+		idx.Keys = append(idx.Keys, IndexKey{Field: field, Desc: strings.EqualFold(dir, "desc")})
+	}
+	return idx
+}
+
+func (idx Index) String() string {
+	keys := make([]string, len(idx.Keys))
+	for i, k := range idx.Keys {
+		keys[i] = k.Field
+		if k.Desc {
+			keys[i] += ":desc"
+		}
+	}
+	return idx.Name + "(" + strings.Join(keys, ",") + ")"
+}
+
+// IndexAdvisorAction says what IndexAdvisorPlugin does with a finding.
+type IndexAdvisorAction int
+
+const (
+	// IndexReject refuses the query (the default).
+	IndexReject IndexAdvisorAction = iota
+	// IndexWarn builds the query and reports each finding to OnWarn.
+	IndexWarn
+)
+
+// IndexAdvisorConfig configures an IndexAdvisorPlugin for one table.
+type IndexAdvisorConfig struct {
+	Table  string
+	Action IndexAdvisorAction
+
+	// OnWarn, when set, is called with each finding under IndexWarn: the
+	// findings live traffic would have been refused for.
+	OnWarn func(IndexAdvice)
+}
+
+// IndexAdvice is one finding: a query on Table that cannot use an index.
+type IndexAdvice struct {
+	Table   string
+	Problem string
+}
+
+func (a IndexAdvice) Error() string {
+	return fmt.Sprintf("index advisor: %s: %s", a.Table, a.Problem)
+}
+
+// NewIndexAdvisorPlugin creates an advisor for config.Table, which must be
+// declared in catalog with at least one index, each with at least one key.
+func NewIndexAdvisorPlugin(catalog IndexCatalog, config IndexAdvisorConfig) (*IndexAdvisorPlugin, error) {
+	indexes, ok := catalog[config.Table]
+	if !ok || len(indexes) == 0 {
+		return nil, fmt.Errorf("index advisor: no indexes declared for table %q", config.Table)
+	}
+	for _, idx := range indexes {
+		if len(idx.Keys) == 0 {
+			return nil, fmt.Errorf("index advisor: %s: index %q has no keys", config.Table, idx.Name)
+		}
+		for _, k := range idx.Keys {
+			if k.Field == "" {
+				return nil, fmt.Errorf("index advisor: %s: index %q has an empty key", config.Table, idx.Name)
+			}
+		}
+	}
+	return &IndexAdvisorPlugin{
+		table:   config.Table,
+		indexes: append([]Index(nil), indexes...),
+		config:  config,
+	}, nil
+}
+
+// Name implements Plugin
+func (p *IndexAdvisorPlugin) Name() string { return "figo-index-advisor" }
+
+// Version implements Plugin
+func (p *IndexAdvisorPlugin) Version() string { return "1.0.0" }
+
+// Initialize implements Plugin
+func (p *IndexAdvisorPlugin) Initialize(figo.Figo) error { return nil }
+
+// BeforeQuery implements Plugin
+func (p *IndexAdvisorPlugin) BeforeQuery(figo.Figo, any) error { return nil }
+
+// AfterQuery implements Plugin
+func (p *IndexAdvisorPlugin) AfterQuery(figo.Figo, any, any) error { return nil }
+
+// BeforeParse implements Plugin
+func (p *IndexAdvisorPlugin) BeforeParse(_ figo.Figo, dsl string) (string, error) { return dsl, nil }
+
+// AfterParse implements Plugin
+func (p *IndexAdvisorPlugin) AfterParse(figo.Figo, string) error { return nil }
+
+// SetAction replaces the advisor's action
+func (p *IndexAdvisorPlugin) SetAction(action IndexAdvisorAction) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config.Action = action
+}
+
+// FinalizeClausesContext implements figo.ContextPlugin: it checks the
+// finished clause list, sort and page, and refuses the query or reports the
+// findings, per the configured action.
+func (p *IndexAdvisorPlugin) FinalizeClausesContext(_ context.Context, f figo.Figo, clauses []figo.Expr) ([]figo.Expr, error) {
+	p.mu.RLock()
+	config := p.config
+	p.mu.RUnlock()
+
+	advice := p.advise(clauses, f.GetSort(), f.GetPage())
+	if len(advice) == 0 {
+		return clauses, nil
+	}
+	if config.Action == IndexWarn {
+		if config.OnWarn != nil {
+			for _, a := range advice {
+				config.OnWarn(a)
+			}
+		}
+		return clauses, nil
+	}
+	errs := make([]error, len(advice))
+	for i, a := range advice {
+		errs[i] = a
+	}
+	return nil, errors.Join(errs...)
+}
+
+// FinalizePreloadsContext implements figo.ContextPlugin. Preloads are not
+// checked (see IndexAdvisorPlugin).
+func (p *IndexAdvisorPlugin) FinalizePreloadsContext(_ context.Context, _ figo.Figo, _ string, conds []figo.Expr) ([]figo.Expr, error) {
+	return conds, nil
+}
+
+// Advise returns the findings for f as it was last built, whatever the
+// configured action — for tests and tooling that vet queries ahead of time.
+func (p *IndexAdvisorPlugin) Advise(f figo.Figo) []IndexAdvice {
+	return p.advise(f.GetClauses(), f.GetSort(), f.GetPage())
+}
+
+func (p *IndexAdvisorPlugin) advise(clauses []figo.Expr, order *figo.OrderBy, page figo.Page) []IndexAdvice {
+	conj := flattenAnd(clauses)
+	for _, c := range conj {
+		if o, ok := c.(figo.OrExpr); ok && len(o.Operands) == 0 {
+			return nil // the never-true clause: nothing is read
+		}
+	}
+
+	var advice []IndexAdvice
+	finding := func(format string, args ...any) {
+		advice = append(advice, IndexAdvice{Table: p.table, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case len(conj) == 0 && page.Take <= 0:
+		finding("no filter and no page size: the query reads every row")
+	case len(conj) > 0 && !p.indexable(conj):
+		finding("no condition can use an index (filters on %s; indexes %s)",
+			strings.Join(filterFields(conj), ", "), p.indexList())
+	}
+
+	if order != nil && len(order.Columns) > 0 && !p.ordered(order.Columns, pinnedFields(conj)) {
+		cols := make([]string, len(order.Columns))
+		for i, c := range order.Columns {
+			cols[i] = c.Name + ":asc"
+			if c.Desc {
+				cols[i] = c.Name + ":desc"
+			}
+		}
+		finding("sort=%s follows no index order (indexes %s)", strings.Join(cols, ","), p.indexList())
+	}
+	return advice
+}
+
+// indexable reports whether some conjunct can drive an index: a sargable
+// condition on a leading key, or an or whose every branch is indexable.
+func (p *IndexAdvisorPlugin) indexable(conj []figo.Expr) bool {
+	for _, c := range conj {
+		if o, ok := c.(figo.OrExpr); ok {
+			all := true
+			for _, branch := range o.Operands {
+				if !p.indexable(flattenAnd([]figo.Expr{branch})) {
+					all = false
+					break
+				}
+			}
+			if all {
+				return true
+			}
+			continue
+		}
+		if !sargable(c) {
+			continue
+		}
+		field := figo.ExprField(c)
+		for _, idx := range p.indexes {
+			if idx.Keys[0].Field == field {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ordered reports whether cols follow some index's key order, all in the
+// index's direction or all reversed, skipping keys pinned by an equality.
+func (p *IndexAdvisorPlugin) ordered(cols []figo.OrderByColumn, pinned map[string]bool) bool {
+	// A sort key pinned by an equality orders nothing.
+	var want []figo.OrderByColumn
+	for _, c := range cols {
+		if !pinned[c.Name] {
+			want = append(want, c)
+		}
+	}
+	if len(want) == 0 {
+		return true
+	}
+	for _, idx := range p.indexes {
+		i := 0
+		reversed, decided := false, false
+		for _, k := range idx.Keys {
+			if i == len(want) {
+				break
+			}
+			if k.Field == want[i].Name {
+				flip := k.Desc != want[i].Desc
+				if decided && flip != reversed {
+					break
+				}
+				reversed, decided = flip, true
+				i++
+				continue
+			}
+			if !pinned[k.Field] {
+				break
+			}
+		}
+		if i == len(want) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *IndexAdvisorPlugin) indexList() string {
+	names := make([]string, len(p.indexes))
+	for i, idx := range p.indexes {
+		names[i] = idx.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// flattenAnd returns the conjuncts of clauses, which the adapters AND
+// together, with nested AndExprs flattened into them.
+func flattenAnd(clauses []figo.Expr) []figo.Expr {
+	var out []figo.Expr
+	for _, c := range clauses {
+		if a, ok := c.(figo.AndExpr); ok {
+			out = append(out, flattenAnd(a.Operands)...)
+		} else if c != nil {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// sargable reports whether a leaf can seek an index on its field.
+func sargable(e figo.Expr) bool {
+	switch v := e.(type) {
+	case figo.EqExpr, figo.GtExpr, figo.GteExpr, figo.LtExpr, figo.LteExpr,
+		figo.BetweenExpr, figo.IsNullExpr:
+		return true
+	case figo.InExpr:
+		return len(v.Values) > 0
+	case figo.LikeExpr:
+		s, ok := v.Value.(string)
+		return ok && s != "" && s[0] != '%' && s[0] != '_'
+	}
+	return false
+}
+
+// pinnedFields returns the fields a conjunct fixes to one value.
+func pinnedFields(conj []figo.Expr) map[string]bool {
+	pinned := map[string]bool{}
+	for _, c := range conj {
+		switch v := c.(type) {
+		case figo.EqExpr, figo.IsNullExpr:
+			pinned[figo.ExprField(v)] = true
+		case figo.InExpr:
+			if len(v.Values) == 1 {
+				pinned[v.Field] = true
+			}
+		}
+	}
+	return pinned
+}
+
+// filterFields lists the fields conj filters on, sorted. A relation counts
+// by its name; the fields in its condition are the related table's.
+func filterFields(conj []figo.Expr) []string {
+	tree := figo.Walk(figo.AndExpr{Operands: conj}, func(n figo.Expr) {
+		if r, ok := n.(*figo.RelationExpr); ok {
+			r.Cond = nil
+		}
+	})
+	seen := map[string]bool{}
+	var out []string
+	figo.Walk(tree, func(n figo.Expr) {
+		if field, ok := figo.NodeField(n); ok && field != "" && !seen[field] {
+			seen[field] = true
+			out = append(out, field)
+		}
+	})
+	sort.Strings(out)
+	return out
+}
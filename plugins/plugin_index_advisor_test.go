@@ -0,0 +1,117 @@
+package plugins
+
+import (
+	. "github.com/bi0dread/figo/v4"
+	. "github.com/bi0dread/figo/v4/adapters"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var advisorCatalog = IndexCatalog{
+	"users": {
+		IndexOn("pk", "id"),
+		IndexOn("by_tenant", "tenant_id", "created_at:desc"),
+		IndexOn("by_email", "email"),
+	},
+}
+
+func advisedFigo(t *testing.T, config IndexAdvisorConfig) (Figo, *IndexAdvisorPlugin) {
+	t.Helper()
+	config.Table = "users"
+	ia, err := NewIndexAdvisorPlugin(advisorCatalog, config)
+	require.NoError(t, err)
+	f := New()
+	require.NoError(t, f.RegisterPlugin(ia))
+	return f, ia
+}
+
+func TestIndexAdvisorAcceptsIndexedQueries(t *testing.T) {
+	for _, dsl := range []string{
+		`id=1`,
+		`id<in>[1,2,3] and name=~"x"`,
+		`tenantId=1 and createdAt>"2024-01-01"`,
+		`email=^"jo%"`,
+		`email<null>`,
+		`(id=1 or email="a@b") and name!="x"`,
+		`tenantId=1 sort=createdAt:desc`,
+		`tenantId=1 sort=createdAt:asc`, // read backwards
+		`tenantId<in>[1] sort=createdAt:desc`,
+		`tenantId>1 sort=tenantId:asc,createdAt:desc`,
+		`page=take:20`,
+		`page=take:20 sort=id:desc`,
+	} {
+		f, _ := advisedFigo(t, IndexAdvisorConfig{})
+		require.NoError(t, f.AddFiltersFromString(dsl), dsl)
+		assert.NoError(t, f.BuildE(RawAdapter{}), dsl)
+	}
+}
+
+func TestIndexAdvisorRejectsFullScans(t *testing.T) {
+	for dsl, want := range map[string]string{
+		`name="x"`:                             "index advisor: users: no condition can use an index (filters on name; indexes pk(id), by_tenant(tenant_id,created_at:desc), by_email(email))",
+		`createdAt>"2024-01-01"`:               "no condition can use an index (filters on created_at;",
+		`email=^"%son"`:                        "no condition can use an index",
+		`id!=1`:                                "no condition can use an index",
+		`not(id=1)`:                            "no condition can use an index",
+		`id=1 or name="x"`:                     "no condition can use an index (filters on id, name;",
+		``:                                     "no filter and no page size: the query reads every row",
+		`id>1 sort=name:asc`:                   "sort=name:asc follows no index order",
+		`tenantId>1 sort=createdAt:desc`:       "sort=created_at:desc follows no index order",
+		`id=1 sort=tenantId:asc,createdAt:asc`: "sort=tenant_id:asc,created_at:asc follows no index order",
+		`page=take:20 sort=createdAt:desc`:     "follows no index order",
+	} {
+		f, _ := advisedFigo(t, IndexAdvisorConfig{})
+		require.NoError(t, f.AddFiltersFromString(dsl), dsl)
+		err := f.BuildE(RawAdapter{})
+		require.Error(t, err, dsl)
+		assert.Contains(t, err.Error(), want, dsl)
+		where, _, _ := BuildRawWhere(f)
+		assert.Equal(t, "1=0", where, dsl)
+	}
+}
+
+func TestIndexAdvisorSeesScopedClauses(t *testing.T) {
+	// A scope another plugin adds counts when the advisor is registered after it.
+	ia, err := NewIndexAdvisorPlugin(advisorCatalog, IndexAdvisorConfig{Table: "users"})
+	require.NoError(t, err)
+	f := New()
+	require.NoError(t, f.RegisterPlugin(NewScopePlugin(EqExpr{Field: "tenant_id", Value: 7})))
+	require.NoError(t, f.RegisterPlugin(ia))
+	require.NoError(t, f.AddFiltersFromString(`name="x"`))
+	assert.NoError(t, f.BuildE(RawAdapter{}))
+
+	// The never-true clause reads nothing.
+	f, _ = advisedFigo(t, IndexAdvisorConfig{})
+	f.AddFilter(OrExpr{})
+	assert.NoError(t, f.BuildE(RawAdapter{}))
+}
+
+func TestIndexAdvisorWarns(t *testing.T) {
+	var warned []IndexAdvice
+	f, ia := advisedFigo(t, IndexAdvisorConfig{Action: IndexWarn, OnWarn: func(a IndexAdvice) { warned = append(warned, a) }})
+	require.NoError(t, f.AddFiltersFromString(`name="x" sort=age:asc`))
+	require.NoError(t, f.BuildE(RawAdapter{}))
+
+	where, _, err := BuildRawWhere(f)
+	require.NoError(t, err)
+	assert.Equal(t, "`name` = ?", where)
+	require.Len(t, warned, 2)
+	assert.Equal(t, "users", warned[0].Table)
+	assert.Contains(t, warned[0].Problem, "no condition can use an index")
+	assert.Contains(t, warned[1].Problem, "sort=age:asc follows no index order")
+	assert.Equal(t, warned, ia.Advise(f))
+
+	ia.SetAction(IndexReject)
+	assert.Error(t, f.BuildE(RawAdapter{}))
+}
+
+func TestNewIndexAdvisorPluginValidates(t *testing.T) {
+	_, err := NewIndexAdvisorPlugin(advisorCatalog, IndexAdvisorConfig{Table: "orders"})
+	assert.EqualError(t, err, `index advisor: no indexes declared for table "orders"`)
+	_, err = NewIndexAdvisorPlugin(IndexCatalog{"t": {{Name: "empty"}}}, IndexAdvisorConfig{Table: "t"})
+	assert.EqualError(t, err, `index advisor: t: index "empty" has no keys`)
+	assert.Equal(t, "by_tenant(tenant_id,created_at:desc)", IndexOn("by_tenant", "tenant_id", "created_at:desc").String())
+}
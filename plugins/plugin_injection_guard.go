@@ -712,6 +712,12 @@ func (p *InjectionGuardPlugin) screenExprWith(e figo.Expr, screen func(Position,
 			}
 			vs = append(vs, screen(PositionFilterField, v.Field)...)
 			return
+		case *figo.FullTextSearchExpr:
+			if v.Field == "" {
+				// A field-less search (q=) searches the adapter's configured
+				// fields; it names no identifier to screen.
+				return
+			}
 		}
 		if field, ok := figo.NodeField(n); ok {
 			vs = append(vs, screen(PositionFilterField, field)...)
@@ -752,6 +758,13 @@ func derefLogical(e figo.Expr) figo.Expr {
 		return figo.OrExpr{Operands: derefOperands(v.Operands)}
 	case figo.NotExpr:
 		return figo.NotExpr{Operands: derefOperands(v.Operands)}
+	case *figo.RelationExpr:
+		r := *v
+		r.Cond = derefLogical(r.Cond)
+		return r
+	case figo.RelationExpr:
+		v.Cond = derefLogical(v.Cond)
+		return v
 	}
 	return e
 }
@@ -5,6 +5,7 @@ import (
 )
 
 import (
+	"context"
 	"fmt"
 	"sync"
 )
@@ -26,13 +27,51 @@ import (
 // 1 — regardless of how the parser nested its binary nodes internally — and
 // each embedded group of a DIFFERENT connector adds a level, so
 // "a=1 and (b=2 or c=3)" is depth 2.
+//
+// The pagination and size ceilings reject by default, like the complexity
+// limits; Clamp selects those that are clamped to the ceiling instead. A
+// query with no take gets DefaultTake — or MaxTake when DefaultTake is unset,
+// because once a ceiling exists an absent page must not mean "every row".
+// MaxInListSize and MaxValueBytes count preload conditions too.
 type QueryLimits struct {
 	MaxNestingDepth    int // max logical nesting (a flat query has depth 1)
 	MaxFieldCount      int // max number of distinct fields referenced
 	MaxParameterCount  int // max total number of filter values (each <in> element counts)
 	MaxExpressionCount int // max total number of expression nodes
+
+	MaxTake       int // max page size (page=take:N)
+	DefaultTake   int // page size applied when no take is given
+	MaxSkip       int // max page offset (page=skip:N)
+	MaxInListSize int // max values in one <in>, <nin> or array list
+	MaxValueBytes int // max bytes in one string value (patterns included)
+	MaxSortKeys   int // max sort= columns
+
+	Clamp LimitClamp // ceilings clamped rather than rejected
+
+	// MaxCost budgets the query's estimated cost, priced by the plugin's
+	// CostEstimator (DefaultWeightedCost unless SetCostEstimator is called).
+	// It is never clamped.
+	MaxCost float64
 }
 
+// LimitClamp selects the QueryLimits ceilings a LimitsPlugin clamps instead
+// of rejecting. Clamping happens as the query is built: the take, the skip
+// and the sort keys are cut down to the ceiling, and an <in> list is cut to
+// its first MaxInListSize values. A <nin> or array list cannot be clamped —
+// dropping values from it WIDENS what it matches (or changes it) — and
+// neither can an <in> list under not(...) or inside a <none> relation, where
+// the same cut widens the query; those are rejected even with
+// ClampInListSize. MaxValueBytes has no clamp at all: a truncated value is a
+// different value.
+type LimitClamp uint8
+
+const (
+	ClampTake LimitClamp = 1 << iota
+	ClampSkip
+	ClampInListSize
+	ClampSortKeys
+)
+
 // DefaultQueryLimits returns the defaults figo's core used to seed
 // (nesting 10, fields 50, parameters 100, expressions 200).
 func DefaultQueryLimits() QueryLimits {
@@ -44,10 +83,12 @@ func DefaultQueryLimits() QueryLimits {
 	}
 }
 
-// LimitsPlugin enforces QueryLimits on parsed DSL input
+// LimitsPlugin enforces QueryLimits on parsed DSL input: AfterParse rejects,
+// and the clause and preload finalizers apply DefaultTake and the clamps.
 type LimitsPlugin struct {
-	mu     sync.RWMutex
-	limits QueryLimits
+	mu        sync.RWMutex
+	limits    QueryLimits
+	estimator CostEstimator // nil: DefaultWeightedCost
 }
 
 // NewLimitsPlugin creates a limits plugin enforcing the given limits
@@ -131,15 +172,207 @@ func (p *LimitsPlugin) AfterParse(f figo.Figo, _ string) error {
 	if limits.MaxExpressionCount > 0 && m.expressions > limits.MaxExpressionCount {
 		return fmt.Errorf("query exceeds MaxExpressionCount: %d > %d", m.expressions, limits.MaxExpressionCount)
 	}
+	if err := checkValues(limits, m); err != nil {
+		return err
+	}
+	if err := checkPage(limits, c.GetPage(), c.GetSort()); err != nil {
+		return err
+	}
+	return p.checkCost(limits, c.GetClauses(), c.GetPreloads())
+}
+
+// FinalizeClauses implements ClauseFinalizer: it applies the default take and
+// every clamp. The page and sort are rewritten through SetPage and SetSort,
+// which makes them the caller's rather than the DSL's; a page= or sort=
+// directive still wins on the next Build, and is clamped again. A plugin
+// registered with the instance runs FinalizeClausesContext instead, which
+// also rejects.
+func (p *LimitsPlugin) FinalizeClauses(f figo.Figo, clauses []figo.Expr) []figo.Expr {
+	return finalizeLimits(f, clauses, p.GetLimits())
+}
+
+// FinalizePreloads implements figo.PreloadFinalizer: it clamps the <in>
+// lists of a preload's conditions like FinalizeClauses does the clauses'.
+func (p *LimitsPlugin) FinalizePreloads(_ figo.Figo, _ string, conds []figo.Expr) []figo.Expr {
+	return clampInLists(conds, p.GetLimits())
+}
+
+// FinalizeClausesContext implements figo.ContextPlugin: FinalizeClauses, and
+// then the ceilings' rejections and the cost budget again, on each Build.
+// AfterParse judges only the DSL; a SetPage(0, 1000000), a SetSort or an
+// AddFilter list or regex is first seen here, and an error fails the build
+// closed.
+func (p *LimitsPlugin) FinalizeClausesContext(_ context.Context, f figo.Figo, clauses []figo.Expr) ([]figo.Expr, error) {
+	limits := p.GetLimits()
+	clauses = finalizeLimits(f, clauses, limits)
+	if err := checkPage(limits, f.GetPage(), f.GetSort()); err != nil {
+		return nil, err
+	}
+	if err := checkValues(limits, measureAll(clauses)); err != nil {
+		return nil, err
+	}
+	if err := p.checkCost(limits, clauses, f.GetPreloads()); err != nil {
+		return nil, err
+	}
+	return clauses, nil
+}
+
+// FinalizePreloadsContext implements figo.ContextPlugin for a preload's
+// conditions, as FinalizeClausesContext does for the clauses.
+func (p *LimitsPlugin) FinalizePreloadsContext(_ context.Context, _ figo.Figo, _ string, conds []figo.Expr) ([]figo.Expr, error) {
+	limits := p.GetLimits()
+	conds = clampInLists(conds, limits)
+	if err := checkValues(limits, measureAll(conds)); err != nil {
+		return nil, err
+	}
+	return conds, nil
+}
+
+// finalizeLimits applies the default take and the clamps to f and clauses.
+func finalizeLimits(f figo.Figo, clauses []figo.Expr, limits QueryLimits) []figo.Expr {
+	page := f.GetPage()
+	take, skip := page.Take, page.Skip
+	if take == 0 {
+		take = limits.DefaultTake
+		if limits.MaxTake > 0 && (take == 0 || take > limits.MaxTake) {
+			take = limits.MaxTake
+		}
+	}
+	if limits.MaxTake > 0 && take > limits.MaxTake && limits.Clamp&ClampTake != 0 {
+		take = limits.MaxTake
+	}
+	if limits.MaxSkip > 0 && skip > limits.MaxSkip && limits.Clamp&ClampSkip != 0 {
+		skip = limits.MaxSkip
+	}
+	if take != page.Take || skip != page.Skip {
+		f.SetPage(skip, take)
+	}
+
+	if sort := f.GetSort(); limits.MaxSortKeys > 0 && sort != nil &&
+		len(sort.Columns) > limits.MaxSortKeys && limits.Clamp&ClampSortKeys != 0 {
+		sort.Columns = sort.Columns[:limits.MaxSortKeys]
+		f.SetSort(sort)
+	}
+	return clampInLists(clauses, limits)
+}
+
+// checkCost rejects clauses and preloads over the MaxCost budget.
+func (p *LimitsPlugin) checkCost(limits QueryLimits, clauses []figo.Expr, preloads map[string][]figo.Expr) error {
+	if limits.MaxCost <= 0 {
+		return nil
+	}
+	if r := p.estimateCost(clauses, preloads); r.Total > limits.MaxCost {
+		return fmt.Errorf("query exceeds MaxCost: %s > %s (%s)", formatCost(r.Total), formatCost(limits.MaxCost), r.topItems(5))
+	}
+	return nil
+}
+
+// checkPage rejects a take, skip or sort over a ceiling it is not clamped to.
+func checkPage(limits QueryLimits, page figo.Page, sort *figo.OrderBy) error {
+	if limits.MaxTake > 0 && page.Take > limits.MaxTake && limits.Clamp&ClampTake == 0 {
+		return fmt.Errorf("query exceeds MaxTake: %d > %d", page.Take, limits.MaxTake)
+	}
+	if limits.MaxSkip > 0 && page.Skip > limits.MaxSkip && limits.Clamp&ClampSkip == 0 {
+		return fmt.Errorf("query exceeds MaxSkip: %d > %d", page.Skip, limits.MaxSkip)
+	}
+	if limits.MaxSortKeys > 0 && sort != nil && len(sort.Columns) > limits.MaxSortKeys && limits.Clamp&ClampSortKeys == 0 {
+		return fmt.Errorf("query exceeds MaxSortKeys: %d > %d", len(sort.Columns), limits.MaxSortKeys)
+	}
+	return nil
+}
+
+// checkValues rejects a value list or string value over its ceiling. An <in>
+// list under ClampInListSize passes: the finalizers cut it.
+func checkValues(limits QueryLimits, m *queryMeasure) error {
+	if limits.MaxInListSize > 0 {
+		for _, l := range m.lists {
+			if l.size > limits.MaxInListSize && !(l.clampable && limits.Clamp&ClampInListSize != 0) {
+				return fmt.Errorf("query exceeds MaxInListSize: %d > %d (field %s)", l.size, limits.MaxInListSize, l.field)
+			}
+		}
+	}
+	if limits.MaxValueBytes > 0 && m.maxValueBytes > limits.MaxValueBytes {
+		return fmt.Errorf("query exceeds MaxValueBytes: %d > %d (field %s)", m.maxValueBytes, limits.MaxValueBytes, m.maxValueField)
+	}
 	return nil
 }
 
+// measureAll measures exprs into one queryMeasure.
+func measureAll(exprs []figo.Expr) *queryMeasure {
+	m := &queryMeasure{fields: make(map[string]bool)}
+	for _, e := range exprs {
+		measureExpr(e, m)
+	}
+	return m
+}
+
+// clampInLists cuts every <in> list in exprs that is not negated to
+// MaxInListSize values when ClampInListSize is set. The input is not
+// modified.
+func clampInLists(exprs []figo.Expr, limits QueryLimits) []figo.Expr {
+	if limits.MaxInListSize <= 0 || limits.Clamp&ClampInListSize == 0 {
+		return exprs
+	}
+	out := make([]figo.Expr, len(exprs))
+	for i, e := range exprs {
+		out[i] = clampInList(e, limits.MaxInListSize, false)
+	}
+	return out
+}
+
+// clampInList cuts e's <in> lists, tracking whether e sits under an odd
+// number of negations — a not(...) or a <none> relation — where cutting a
+// list would widen the query. Those lists are left whole; measureExpr marks
+// them unclampable, so checkValues rejects them.
+func clampInList(e figo.Expr, max int, negated bool) figo.Expr {
+	operands := func(ops []figo.Expr, negated bool) []figo.Expr {
+		out := make([]figo.Expr, len(ops))
+		for i, o := range ops {
+			out[i] = clampInList(o, max, negated)
+		}
+		return out
+	}
+	switch v := e.(type) {
+	case figo.AndExpr:
+		return figo.AndExpr{Operands: operands(v.Operands, negated)}
+	case figo.OrExpr:
+		return figo.OrExpr{Operands: operands(v.Operands, negated)}
+	case figo.NotExpr:
+		return figo.NotExpr{Operands: operands(v.Operands, !negated)}
+	case figo.RelationExpr:
+		if v.Cond != nil {
+			v.Cond = clampInList(v.Cond, max, negated != (v.Quantifier == figo.QuantifierNone))
+		}
+		return v
+	case figo.InExpr:
+		if !negated && len(v.Values) > max {
+			v.Values = append([]any(nil), v.Values[:max]...)
+		}
+		return v
+	}
+	return e
+}
+
 // queryMeasure accumulates complexity metrics over an expression tree
 type queryMeasure struct {
 	expressions int
 	params      int
 	maxDepth    int
 	fields      map[string]bool
+
+	lists         []listSize
+	maxValueBytes int
+	maxValueField string
+
+	negated bool // under an odd number of not(...) and <none> relations
+}
+
+// listSize is one value list a query carries. Only an <in> list that is not
+// negated can be clamped (see LimitClamp).
+type listSize struct {
+	field     string
+	size      int
+	clampable bool
 }
 
 // measureExpr walks an expression tree counting nodes, values, and distinct
@@ -169,16 +402,88 @@ func measureExpr(e figo.Expr, m *queryMeasure) {
 			measureExpr(op, m)
 		}
 	case figo.NotExpr:
+		m.negated = !m.negated
 		for _, op := range v.Operands {
 			measureExpr(op, m)
 		}
+		m.negated = !m.negated
+	case figo.RelationExpr:
+		// The relation is a field the query addresses; its condition is
+		// filter complexity like any other subtree. <none> negates it.
+		m.fields[v.Relation] = true
+		none := v.Quantifier == figo.QuantifierNone
+		m.negated = m.negated != none
+		measureExpr(v.Cond, m)
+		m.negated = m.negated != none
 	case figo.OrderBy:
 		// Sorting isn't filter complexity; only the node itself is counted.
 	default:
-		if field := figo.ExprField(e); field != "" {
+		field := figo.ExprField(e)
+		if field != "" {
 			m.fields[field] = true
 		}
 		m.params += exprParamCount(e)
+		measureValues(e, field, m)
+	}
+}
+
+// measureValues records a leaf's value lists and its longest string value.
+func measureValues(e figo.Expr, field string, m *queryMeasure) {
+	var values []any
+	switch v := e.(type) {
+	case figo.InExpr:
+		m.lists = append(m.lists, listSize{field: field, size: len(v.Values), clampable: !m.negated})
+		values = v.Values
+	case figo.NotInExpr:
+		m.lists = append(m.lists, listSize{field: field, size: len(v.Values)})
+		values = v.Values
+	case figo.ArrayContainsExpr:
+		m.lists = append(m.lists, listSize{field: field, size: len(v.Values)})
+		values = v.Values
+	case figo.ArrayOverlapsExpr:
+		m.lists = append(m.lists, listSize{field: field, size: len(v.Values)})
+		values = v.Values
+	case figo.EqExpr:
+		values = []any{v.Value}
+	case figo.NeqExpr:
+		values = []any{v.Value}
+	case figo.GtExpr:
+		values = []any{v.Value}
+	case figo.GteExpr:
+		values = []any{v.Value}
+	case figo.LtExpr:
+		values = []any{v.Value}
+	case figo.LteExpr:
+		values = []any{v.Value}
+	case figo.LikeExpr:
+		values = []any{v.Value}
+	case figo.ILikeExpr:
+		values = []any{v.Value}
+	case figo.RegexExpr:
+		values = []any{v.Value}
+	case figo.BetweenExpr:
+		values = []any{v.Low, v.High}
+	case figo.JsonPathExpr:
+		values = []any{v.Value}
+	case figo.FullTextSearchExpr:
+		values = []any{v.Query}
+		if field == "" {
+			field = "q" // a q= search addresses no single field
+		}
+	case figo.CustomExpr:
+		values = []any{v.Value}
+	}
+	for _, v := range values {
+		n := 0
+		switch s := v.(type) {
+		case string:
+			n = len(s)
+		case []byte:
+			n = len(s)
+		}
+		if n > m.maxValueBytes {
+			m.maxValueBytes, m.maxValueField = n, field
+		}
 	}
 }
 
@@ -224,6 +529,10 @@ func exprDepth(e figo.Expr, parentOp figo.Operation) int {
 		op, operands = figo.OperationOr, v.Operands
 	case figo.NotExpr:
 		op, operands = figo.OperationNot, v.Operands
+	case figo.RelationExpr:
+		// A quantified relation is a correlated subquery: it always opens a
+		// level of its own, even nested directly in another relation.
+		return exprDepth(v.Cond, figo.Operation("")) + 1
 	default:
 		return 0
 	}
@@ -0,0 +1,399 @@
+package plugins
+
+import (
+	figo "github.com/bi0dread/figo/v4"
+)
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"sync"
+)
+
+// PatternGuardPlugin screens the PATTERNS of regex (=~) and LIKE (=^, .=^)
+// conditions before they reach the database. Their values are bound like any
+// other, so they cannot inject SQL — but the engine still has to run them,
+// and a pattern is a program:
+//
+//   - A regex with nested quantifiers, `(a+)+$`, backtracks exponentially on
+//     a near-miss in engines that backtrack (PCRE on Mongo, MySQL's ICU,
+//     Elasticsearch's regexp on some versions): one request pins a core.
+//   - A huge repetition count, `a{999}`, compiles to a huge automaton.
+//   - A backreference, `(a)\1`, makes matching NP-hard in general and cannot
+//     be run by a linear-time engine at all.
+//   - A LIKE pattern starting with a wildcard, `%x`, cannot use an index, so
+//     on a large table it is a full scan per request.
+//
+// Regex values are parsed with regexp/syntax (Perl flags), and a pattern the
+// parser refuses is reported rather than waved through: Go does not support
+// backreferences or lookaround, so whatever it cannot analyse is not known to
+// be safe. The syntax is close to, not identical with, each database's
+// dialect; the guard is a screen for the shapes above, not a proof of cost.
+//
+//	g := plugins.NewPatternGuardPlugin(plugins.DefaultPatternGuardConfig())
+//	g.BanLeadingWildcard("name", "email")
+//	f.RegisterPlugin(g)
+//	err := f.AddFiltersFromString(`name=^"%son"`)
+//	// pattern guard: field "name": LIKE pattern starts with a wildcard
+//
+// By default a violation REJECTS the query, with an error naming the field:
+// AddFiltersFromString returns it (and core renders the refused query as the
+// never-true clause), and an expression added through AddFilter is refused by
+// BuildContext/BuildE the same way. PatternPrune drops the offending
+// condition instead and reports it to OnPrune. Dropping a condition widens
+// what it was ANDed with, exactly like FieldsPlugin's pruning — choose it only
+// where a broader result is acceptable and a refusal is not.
+type PatternGuardPlugin struct {
+	mu     sync.RWMutex
+	config PatternGuardConfig
+	noLead map[string]bool // fields banned a leading LIKE wildcard
+}
+
+// PatternGuardAction says what PatternGuardPlugin does with a violation.
+type PatternGuardAction int
+
+const (
+	// PatternReject refuses the query (the default).
+	PatternReject PatternGuardAction = iota
+	// PatternPrune drops the offending condition and reports it to OnPrune.
+	PatternPrune
+)
+
+// PatternGuardConfig configures a PatternGuardPlugin. A zero limit disables
+// that limit; nested quantifiers and backreferences are always refused.
+type PatternGuardConfig struct {
+	MaxPatternLength int // max bytes in a regex or LIKE pattern
+	MaxRepeat        int // max count in a {n,m} repetition
+	Action           PatternGuardAction
+
+	// OnPrune, when set, is called once per condition PatternPrune drops, so
+	// the queries it widened can be logged or counted.
+	OnPrune func(PatternViolation)
+}
+
+// DefaultPatternGuardConfig returns a config rejecting patterns longer than
+// 256 bytes and repetition counts above 100.
+func DefaultPatternGuardConfig() PatternGuardConfig {
+	return PatternGuardConfig{MaxPatternLength: 256, MaxRepeat: 100}
+}
+
+// PatternViolation is one refused pattern: the field it filtered and why.
+type PatternViolation struct {
+	Field  string
+	Reason string
+}
+
+func (v PatternViolation) Error() string {
+	return fmt.Sprintf("pattern guard: field %q: %s", v.Field, v.Reason)
+}
+
+// NewPatternGuardPlugin creates a pattern guard with the given config
+func NewPatternGuardPlugin(config PatternGuardConfig) *PatternGuardPlugin {
+	return &PatternGuardPlugin{config: config, noLead: make(map[string]bool)}
+}
+
+// Name implements Plugin
+func (p *PatternGuardPlugin) Name() string { return "figo-pattern-guard" }
+
+// Version implements Plugin
+func (p *PatternGuardPlugin) Version() string { return "1.0.0" }
+
+// Initialize implements Plugin
+func (p *PatternGuardPlugin) Initialize(figo.Figo) error { return nil }
+
+// BeforeQuery implements Plugin
+func (p *PatternGuardPlugin) BeforeQuery(figo.Figo, any) error { return nil }
+
+// AfterQuery implements Plugin
+func (p *PatternGuardPlugin) AfterQuery(figo.Figo, any, any) error { return nil }
+
+// BeforeParse implements Plugin
+func (p *PatternGuardPlugin) BeforeParse(_ figo.Figo, dsl string) (string, error) { return dsl, nil }
+
+// SetConfig replaces the guard's config
+func (p *PatternGuardPlugin) SetConfig(config PatternGuardConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config = config
+}
+
+// GetConfig returns the guard's config
+func (p *PatternGuardPlugin) GetConfig() PatternGuardConfig {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.config
+}
+
+// BanLeadingWildcard refuses LIKE patterns starting with % or _ on the given
+// fields ("*" for every field). Fields match like FieldsPlugin's ignore list:
+// verbatim, naming-converted, case-folded and past a table qualifier.
+func (p *PatternGuardPlugin) BanLeadingWildcard(fields ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, field := range fields {
+		p.noLead[field] = true
+	}
+}
+
+// AfterParse rejects a freshly parsed DSL with a violating pattern, so that
+// AddFiltersFromString reports it. As with LimitsPlugin, the DSL is built on
+// a clone without clause finalizers (see cloneForInspection). In prune mode
+// there is nothing to reject and the finalizers do the work.
+func (p *PatternGuardPlugin) AfterParse(f figo.Figo, _ string) error {
+	chk := p.checker(f)
+	if chk.config.Action == PatternPrune {
+		return nil
+	}
+	c := cloneForInspection(f)
+	c.Build(nil)
+	vs := chk.violations(c.GetClauses())
+	for _, exprs := range c.GetPreloads() {
+		vs = append(vs, chk.violations(exprs)...)
+	}
+	return errors.Join(vs...)
+}
+
+// FinalizeClausesContext implements figo.ContextPlugin. It sees every clause,
+// including those added through AddFilter, which no parse hook screens: a
+// violation is refused (the build fails closed) or pruned, per Action.
+func (p *PatternGuardPlugin) FinalizeClausesContext(_ context.Context, f figo.Figo, clauses []figo.Expr) ([]figo.Expr, error) {
+	return p.checker(f).apply(clauses)
+}
+
+// FinalizePreloadsContext implements figo.ContextPlugin for a preload's
+// conditions, as FinalizeClausesContext does for the clauses.
+func (p *PatternGuardPlugin) FinalizePreloadsContext(_ context.Context, f figo.Figo, _ string, conds []figo.Expr) ([]figo.Expr, error) {
+	return p.checker(f).apply(conds)
+}
+
+// patternChecker is a snapshot of the guard's config for one check.
+type patternChecker struct {
+	config PatternGuardConfig
+	noLead func(string) bool
+}
+
+func (p *PatternGuardPlugin) checker(f figo.Figo) patternChecker {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	names := make([]string, 0, len(p.noLead))
+	all := false
+	for n := range p.noLead {
+		if n == "*" {
+			all = true
+		}
+		names = append(names, n)
+	}
+	noLead := newDenyMatcher(names, f.GetNamingFunc())
+	if all {
+		noLead = func(string) bool { return true }
+	}
+	return patternChecker{config: p.config, noLead: noLead}
+}
+
+// apply refuses or prunes the violations in exprs, per the configured action.
+func (c patternChecker) apply(exprs []figo.Expr) ([]figo.Expr, error) {
+	if c.config.Action != PatternPrune {
+		if vs := c.violations(exprs); len(vs) > 0 {
+			return nil, errors.Join(vs...)
+		}
+		return exprs, nil
+	}
+	out := make([]figo.Expr, 0, len(exprs))
+	for _, e := range exprs {
+		if kept := c.prune(e); kept != nil {
+			out = append(out, kept)
+		}
+	}
+	return out, nil
+}
+
+// violations lists the violations in exprs' trees.
+func (c patternChecker) violations(exprs []figo.Expr) []error {
+	var vs []error
+	for _, e := range exprs {
+		c.walk(e, func(viol PatternViolation) { vs = append(vs, viol) })
+	}
+	return vs
+}
+
+// walk calls found for each violation in e's tree.
+func (c patternChecker) walk(e figo.Expr, found func(PatternViolation)) {
+	figo.Walk(e, func(n figo.Expr) {
+		if viol, bad := c.check(n); bad {
+			found(viol)
+		}
+	})
+}
+
+// prune returns e without its violating leaves (nil when nothing is left),
+// reporting each to OnPrune. An emptied logical node is dropped from its
+// parent, as PruneExprFields does, and so is a relation predicate whose
+// condition is gone — an empty condition would mean "any related row".
+// figo.Walk cannot drop a node, so the logical structure is rebuilt here and
+// only the leaves go through walk.
+func (c patternChecker) prune(e figo.Expr) figo.Expr {
+	operands := func(ops []figo.Expr) []figo.Expr {
+		var out []figo.Expr
+		for _, o := range ops {
+			if kept := c.prune(o); kept != nil {
+				out = append(out, kept)
+			}
+		}
+		return out
+	}
+	switch v := e.(type) {
+	case figo.AndExpr:
+		if ops := operands(v.Operands); len(ops) > 0 || len(v.Operands) == 0 {
+			return figo.AndExpr{Operands: ops}
+		}
+		return nil
+	case figo.OrExpr:
+		// An empty OrExpr is the never-true clause and stays as it is.
+		if ops := operands(v.Operands); len(ops) > 0 || len(v.Operands) == 0 {
+			return figo.OrExpr{Operands: ops}
+		}
+		return nil
+	case figo.NotExpr:
+		if ops := operands(v.Operands); len(ops) > 0 || len(v.Operands) == 0 {
+			return figo.NotExpr{Operands: ops}
+		}
+		return nil
+	case figo.RelationExpr:
+		if v.Cond == nil {
+			return v
+		}
+		if v.Cond = c.prune(v.Cond); v.Cond == nil {
+			return nil
+		}
+		return v
+	}
+	bad := false
+	c.walk(e, func(viol PatternViolation) {
+		bad = true
+		if c.config.OnPrune != nil {
+			c.config.OnPrune(viol)
+		}
+	})
+	if bad {
+		return nil
+	}
+	return e
+}
+
+// check screens one node as figo.Walk passes it.
+func (c patternChecker) check(n figo.Expr) (PatternViolation, bool) {
+	var value any
+	var isRegex bool
+	switch v := n.(type) {
+	case *figo.RegexExpr:
+		value, isRegex = v.Value, true
+	case *figo.LikeExpr:
+		value = v.Value
+	case *figo.ILikeExpr:
+		value = v.Value
+	default:
+		return PatternViolation{}, false
+	}
+	field, _ := figo.NodeField(n)
+	pattern, ok := value.(string)
+	if !ok {
+		pattern = fmt.Sprint(value)
+	}
+	bad := func(format string, args ...any) (PatternViolation, bool) {
+		return PatternViolation{Field: field, Reason: fmt.Sprintf(format, args...)}, true
+	}
+
+	if max := c.config.MaxPatternLength; max > 0 && len(pattern) > max {
+		return bad("pattern is %d bytes, over MaxPatternLength %d", len(pattern), max)
+	}
+	if !isRegex {
+		if (strings.HasPrefix(pattern, "%") || strings.HasPrefix(pattern, "_")) && c.noLead(field) {
+			return bad("LIKE pattern starts with a wildcard")
+		}
+		return PatternViolation{}, false
+	}
+	if reason := regexCost(pattern, c.config.MaxRepeat); reason != "" {
+		return bad("%s", reason)
+	}
+	return PatternViolation{}, false
+}
+
+// regexCost returns why pattern is refused, or "".
+func regexCost(pattern string, maxRepeat int) string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		var se *syntax.Error
+		if !errors.As(err, &se) {
+			return "regex cannot be analysed"
+		}
+		switch {
+		case se.Code == syntax.ErrInvalidEscape && isBackreference(se.Expr):
+			return fmt.Sprintf("regex uses a backreference (%s)", se.Expr)
+		case se.Code == syntax.ErrInvalidRepeatOp:
+			return fmt.Sprintf("regex nests quantifiers (%s)", se.Expr)
+		case se.Code == syntax.ErrInvalidRepeatSize:
+			return fmt.Sprintf("regex repetition count is too large (%s)", se.Expr)
+		}
+		return fmt.Sprintf("regex cannot be analysed: %s", se.Code)
+	}
+	if s := nestedQuantifier(re, false); s != "" {
+		return fmt.Sprintf("regex nests quantifiers (%s)", s)
+	}
+	if maxRepeat > 0 {
+		if s := largeRepeat(re, maxRepeat); s != "" {
+			return fmt.Sprintf("regex repetition count exceeds MaxRepeat %d (%s)", maxRepeat, s)
+		}
+	}
+	return ""
+}
+
+// isBackreference reports whether an escape the parser refused is a
+// backreference: \1..\9 or a named \k<name>.
+func isBackreference(esc string) bool {
+	return len(esc) >= 2 && esc[0] == '\\' && (esc[1] >= '1' && esc[1] <= '9' || esc[1] == 'k')
+}
+
+// nestedQuantifier returns the first repeating quantifier that has a
+// variable quantifier inside it — `(a+)+`, `(a?)*`, `(x{1,3})+` — or "". A
+// fixed count, `(ab){3}`, matches in one way only and is not counted.
+func nestedQuantifier(re *syntax.Regexp, inRepeat bool) string {
+	variable := false
+	repeating := false
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus:
+		variable, repeating = true, true
+	case syntax.OpQuest:
+		variable = true
+	case syntax.OpRepeat:
+		variable = re.Min != re.Max
+		repeating = re.Max == -1 || re.Max > 1
+	}
+	if variable && inRepeat {
+		return re.String()
+	}
+	for _, sub := range re.Sub {
+		if s := nestedQuantifier(sub, inRepeat || repeating); s != "" {
+			if repeating && !inRepeat {
+				return re.String()
+			}
+			return s
+		}
+	}
+	return ""
+}
+
+// largeRepeat returns the first {n,m} repetition with a count over max, or "".
+func largeRepeat(re *syntax.Regexp, max int) string {
+	if re.Op == syntax.OpRepeat && (re.Min > max || re.Max > max) {
+		return re.String()
+	}
+	for _, sub := range re.Sub {
+		if s := largeRepeat(sub, max); s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package plugins
+
+import (
+	. "github.com/bi0dread/figo/v4"
+	. "github.com/bi0dread/figo/v4/adapters"
+
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatternGuardRejectsCostlyRegexes(t *testing.T) {
+	g := NewPatternGuardPlugin(DefaultPatternGuardConfig())
+
+	for dsl, reason := range map[string]string{
+		`email=~"(a+)+$"`:     `field "email": regex nests quantifiers ((a+)+)`,
+		`email=~"(x|y?)*z"`:   `regex nests quantifiers`,
+		`email=~"a**"`:        `regex nests quantifiers (**)`,
+		`email=~"(a)\1"`:      `regex uses a backreference (\1)`,
+		`email=~"a{5000}"`:    `regex repetition count is too large`,
+		`email=~"a{3,150}"`:   `regex repetition count exceeds MaxRepeat 100 (a{3,150})`,
+		`email=~"(?=x)y"`:     `regex cannot be analysed`,
+		`not(email=~"(a*)*")`: `regex nests quantifiers`,
+		`a=1 or email=~"` + strings.Repeat("a", 300) + `"`: `pattern is 300 bytes, over MaxPatternLength 256`,
+	} {
+		f := New()
+		require.NoError(t, f.RegisterPlugin(g))
+		err := f.AddFiltersFromString(dsl)
+		require.Error(t, err, dsl)
+		assert.Contains(t, err.Error(), "pattern guard: field \"email\": ", dsl)
+		assert.Contains(t, err.Error(), reason, dsl)
+		f.Build(RawAdapter{})
+		where, _, _ := BuildRawWhere(f)
+		assert.Equal(t, "1=0", where, dsl)
+	}
+
+	for _, dsl := range []string{
+		`email=~"^[a-z0-9._%+-]+@[a-z0-9.-]+\\.[a-z]{2,}$"`,
+		`code=~"(ab){3}c+"`,
+		`name=^"%son"`, // no field bans a leading wildcard yet
+	} {
+		f := New()
+		require.NoError(t, f.RegisterPlugin(g))
+		assert.NoError(t, f.AddFiltersFromString(dsl), dsl)
+	}
+}
+
+func TestPatternGuardLeadingWildcard(t *testing.T) {
+	g := NewPatternGuardPlugin(PatternGuardConfig{})
+	g.BanLeadingWildcard("userName")
+
+	for dsl, bad := range map[string]bool{
+		`userName=^"%son"`:             true,
+		`user_name.=^"_on%"`:           true,
+		`UserName!=^"%x"`:              true,
+		`userName=^"jo%"`:              false,
+		`title=^"%x"`:                  false,
+		`load=[Orders:userName=^"%x"]`: true,
+	} {
+		f := New()
+		require.NoError(t, f.RegisterPlugin(g))
+		err := f.AddFiltersFromString(dsl)
+		if bad {
+			assert.ErrorContains(t, err, "LIKE pattern starts with a wildcard", dsl)
+		} else {
+			assert.NoError(t, err, dsl)
+		}
+	}
+
+	g.BanLeadingWildcard("*")
+	f := New()
+	require.NoError(t, f.RegisterPlugin(g))
+	assert.Error(t, f.AddFiltersFromString(`title=^"%x"`))
+}
+
+func TestPatternGuardScreensAddFilter(t *testing.T) {
+	g := NewPatternGuardPlugin(DefaultPatternGuardConfig())
+	f := New()
+	require.NoError(t, f.RegisterPlugin(g))
+	f.AddFilter(RegexExpr{Field: "email", Value: "(a+)+"})
+
+	err := f.BuildE(RawAdapter{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `plugin figo-pattern-guard FinalizeClausesContext error: pattern guard: field "email": regex nests quantifiers`)
+	where, _, _ := BuildRawWhere(f)
+	assert.Equal(t, "1=0", where)
+}
+
+func TestPatternGuardPrunes(t *testing.T) {
+	var pruned []PatternViolation
+	cfg := DefaultPatternGuardConfig()
+	cfg.Action = PatternPrune
+	cfg.OnPrune = func(v PatternViolation) { pruned = append(pruned, v) }
+	g := NewPatternGuardPlugin(cfg)
+	g.BanLeadingWildcard("name")
+
+	f := New()
+	require.NoError(t, f.RegisterPlugin(g))
+	require.NoError(t, f.AddFiltersFromString(`a=1 and (name=^"%x" or email=~"(a+)+") and b=~"^ok$" load=[Orders:name=^"%y"]`))
+	require.NoError(t, f.BuildE(RawAdapter{}))
+
+	where, args, err := BuildRawWhere(f)
+	require.NoError(t, err)
+	assert.Equal(t, "(`a` = ? AND `b` REGEXP ?)", where)
+	assert.Equal(t, []any{int64(1), "^ok$"}, args)
+	_, loaded := f.GetPreloads()["Orders"]
+	assert.False(t, loaded, "a preload whose every condition is pruned is dropped")
+	assert.ElementsMatch(t, []PatternViolation{
+		{Field: "name", Reason: "LIKE pattern starts with a wildcard"},
+		{Field: "email", Reason: "regex nests quantifiers ((a+)+)"},
+		{Field: "name", Reason: "LIKE pattern starts with a wildcard"},
+	}, pruned, "reported once each per build")
+}
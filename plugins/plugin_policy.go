@@ -0,0 +1,588 @@
+package plugins
+
+import (
+	figo "github.com/bi0dread/figo/v4"
+)
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PolicyPlugin enforces a declarative access policy on every build: which
+// fields each role may filter and sort on, with which operators, and which
+// rows each role may see at all. The policy is a document rather than code,
+// so it can live next to the service's other configuration:
+//
+//	{
+//	  "roles": {
+//	    "agent": {
+//	      "fields": {"status": ["=", "<in>"], "created_at": ["*"]},
+//	      "rows": ["region=${claim.region}", "deleted=false"]
+//	    },
+//	    "admin": {"fields": {"*": ["*"]}}
+//	  }
+//	}
+//
+// It is evaluated against the Principal carried by the build context, so one
+// shared plugin serves every request:
+//
+//	doc, err := plugins.ParsePolicy(data)
+//	pp, err := plugins.NewPolicyPlugin(doc)
+//	f.RegisterPlugin(pp)
+//	// per request:
+//	ctx := plugins.WithPrincipal(r.Context(), plugins.Principal{Roles: roles, Claims: claims})
+//	err = f.BuildContext(ctx, adapters.RawAdapter{})
+//
+// A condition on a field the principal's roles do not grant, or with an
+// operator they do not grant on it, is a DENIAL: the build fails closed (the
+// query matches nothing, see figo.ContextPlugin) and BuildContext returns one
+// error per denial. Denials are not pruned the way FieldsPlugin prunes —
+// silently dropping a condition widens the result, and a caller who asked for
+// something they may not ask for should hear about it. A build without a
+// principal, or whose principal holds none of the policy's roles, is refused.
+//
+// Row predicates are figo DSL. A ${claim.name} placeholder stands for a whole
+// VALUE and is substituted with the claim's typed value after the template
+// has been parsed — the claim is never spliced into the DSL text, so a claim
+// containing `" or 1=1` is compared as a string rather than parsed as a
+// filter. A list claim fills an <in>/<nin> list. A principal lacking a claim
+// a row predicate needs is refused.
+//
+// Roles add up: a principal holding several roles may use any field a role
+// grants, and sees the rows ANY of its roles grants (each role's predicates
+// are ANDed, the roles ORed). A role without row predicates grants every row.
+//
+// The field check sees the clause list as it stands when the plugin's
+// finalizer runs, so register PolicyPlugin BEFORE plugins injecting clauses of
+// their own (ScopePlugin): their clauses would otherwise be checked as if the
+// caller had written them. Row predicates apply to the top-level query only;
+// a preload's conditions are checked as "Relation.field", and scoping the
+// preloaded rows themselves is ScopePlugin's AddPreloadScopeFunc.
+type PolicyPlugin struct {
+	// roles is built by NewPolicyPlugin and never written afterwards, so the
+	// plugin is safe to share between concurrent builds without a lock.
+	roles map[string]compiledRole
+}
+
+// Policy is the policy document: the grants of each role, keyed by role name.
+type Policy struct {
+	Roles map[string]RolePolicy `json:"roles"`
+}
+
+// RolePolicy is one role's grants. Fields maps a field name (as written in
+// the DSL, or "*" for every field) to the operators allowed on it, spelled as
+// in the DSL ("=", "!=", ">", ">=", "<", "<=", "=^", ".=^", "=~", "<in>",
+// "<nin>", "<bet>", "<null>", "<notnull>", "<any>", "<all>", "<none>", "q"),
+// plus "sort" to order by the field and "*" for every operator. Negation
+// needs no grant of its own: `!=^` and not(...) are checked as the operator
+// they negate. Programmatic expressions use "json", "array_contains",
+// "array_overlaps", "geo" and "custom". A RelationExpr is checked as its
+// quantifier on the relation name, and its condition as "relation.field".
+//
+// Rows are the role's row predicates, in figo DSL, with ${claim.name}
+// placeholders.
+type RolePolicy struct {
+	Fields map[string][]string `json:"fields,omitempty"`
+	Rows   []string            `json:"rows,omitempty"`
+}
+
+// Principal is who a build runs for: the roles a policy grants by, and the
+// claims its row predicates read (typically a verified token's claims).
+type Principal struct {
+	Roles  []string
+	Claims map[string]any
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p, for PolicyPlugin to
+// evaluate during BuildContext.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal WithPrincipal stored in ctx.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// policyOperators is the operator vocabulary a RolePolicy may grant.
+var policyOperators = map[string]bool{
+	"*": true, "sort": true,
+	"=": true, "!=": true, ">": true, ">=": true, "<": true, "<=": true,
+	"=^": true, ".=^": true, "=~": true,
+	"<in>": true, "<nin>": true, "<bet>": true, "<null>": true, "<notnull>": true,
+	"<any>": true, "<all>": true, "<none>": true, "q": true,
+	"json": true, "array_contains": true, "array_overlaps": true, "geo": true, "custom": true,
+}
+
+// claimPlaceholder matches one ${claim.name} placeholder; claimValue matches a
+// parsed value that is exactly one.
+var (
+	claimPlaceholder = regexp.MustCompile(`\$\{claim\.([A-Za-z0-9_.-]+)\}`)
+	claimValue       = regexp.MustCompile(`^\$\{claim\.([A-Za-z0-9_.-]+)\}$`)
+)
+
+type compiledRole struct {
+	fields map[string]map[string]bool // field -> granted operators
+	rows   []figo.Expr                // parsed templates, placeholders unresolved
+}
+
+// ParsePolicy decodes a JSON policy document. Unknown members are an error,
+// so a misspelled "rows" cannot silently grant every row.
+func ParsePolicy(data []byte) (Policy, error) {
+	var p Policy
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return Policy{}, fmt.Errorf("policy: %w", err)
+	}
+	if dec.More() {
+		return Policy{}, errors.New("policy: unexpected data after the document")
+	}
+	return p, nil
+}
+
+// NewPolicyPlugin compiles a policy document. Every operator must be one the
+// policy knows and every row predicate must parse to filters only — no sort=,
+// page= or load= — with each placeholder standing for a whole value, so
+// that a mistake in the document fails here instead of during a request.
+func NewPolicyPlugin(p Policy) (*PolicyPlugin, error) {
+	if len(p.Roles) == 0 {
+		return nil, errors.New("policy: the document grants no roles")
+	}
+	pp := &PolicyPlugin{roles: make(map[string]compiledRole, len(p.Roles))}
+	for name, rp := range p.Roles {
+		if name == "" {
+			return nil, errors.New("policy: empty role name")
+		}
+		role := compiledRole{fields: make(map[string]map[string]bool, len(rp.Fields))}
+		for field, ops := range rp.Fields {
+			if field == "" {
+				return nil, fmt.Errorf("policy: role %q: empty field name", name)
+			}
+			granted := make(map[string]bool, len(ops))
+			for _, op := range ops {
+				if !policyOperators[op] {
+					return nil, fmt.Errorf("policy: role %q: field %q: unknown operator %q", name, field, op)
+				}
+				granted[op] = true
+			}
+			role.fields[field] = granted
+		}
+		for i, dsl := range rp.Rows {
+			row, err := compileRow(dsl)
+			if err != nil {
+				return nil, fmt.Errorf("policy: role %q: row %d: %w", name, i, err)
+			}
+			role.rows = append(role.rows, row)
+		}
+		pp.roles[name] = role
+	}
+	return pp, nil
+}
+
+// compileRow parses one row predicate template. It is parsed without naming
+// conversion; the instance's naming func applies when the predicate is added,
+// as it does for ScopePlugin's scopes.
+func compileRow(dsl string) (figo.Expr, error) {
+	f := figo.New()
+	f.SetNamingFunc(figo.NoChangeNaming)
+	if err := f.AddFiltersFromString(dsl); err != nil {
+		return nil, err
+	}
+	if err := f.BuildE(nil); err != nil {
+		return nil, err
+	}
+	if f.GetSort() != nil || f.GetPage() != (figo.Page{}) || len(f.GetPreloads()) > 0 {
+		return nil, errors.New("a row predicate may only filter")
+	}
+	clauses := f.GetClauses()
+	var row figo.Expr
+	switch len(clauses) {
+	case 0:
+		return nil, errors.New("empty row predicate")
+	case 1:
+		row = clauses[0]
+	default:
+		row = figo.AndExpr{Operands: clauses}
+	}
+	// A placeholder the substitution cannot reach (inside a longer string,
+	// or in a position without a plain value) would otherwise reach the
+	// database as a literal.
+	seen := 0
+	if _, err := substituteClaims(row, func(string) (any, error) {
+		seen++
+		return nil, nil
+	}); err != nil {
+		return nil, err
+	}
+	if want := len(claimPlaceholder.FindAllString(dsl, -1)); seen != want {
+		return nil, errors.New("a ${claim...} placeholder must be a whole value")
+	}
+	return row, nil
+}
+
+// Name implements Plugin
+func (p *PolicyPlugin) Name() string { return "figo-policy" }
+
+// Version implements Plugin
+func (p *PolicyPlugin) Version() string { return "1.0.0" }
+
+// Initialize implements Plugin
+func (p *PolicyPlugin) Initialize(figo.Figo) error { return nil }
+
+// BeforeQuery implements Plugin
+func (p *PolicyPlugin) BeforeQuery(figo.Figo, any) error { return nil }
+
+// AfterQuery implements Plugin
+func (p *PolicyPlugin) AfterQuery(figo.Figo, any, any) error { return nil }
+
+// BeforeParse implements Plugin
+func (p *PolicyPlugin) BeforeParse(_ figo.Figo, dsl string) (string, error) { return dsl, nil }
+
+// AfterParse implements Plugin
+func (p *PolicyPlugin) AfterParse(figo.Figo, string) error { return nil }
+
+// FinalizeClausesContext implements figo.ContextPlugin: it checks every
+// condition and sort key against the grants of the principal's roles, then
+// appends the row predicate. The predicate itself is exempt from the check,
+// and is not appended twice when a rebuild keeps the clause list.
+func (p *PolicyPlugin) FinalizeClausesContext(ctx context.Context, f figo.Figo, clauses []figo.Expr) ([]figo.Expr, error) {
+	pr, roles, err := p.rolesFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	naming := f.GetNamingFunc()
+	row, err := rowPredicate(pr, roles)
+	if err != nil {
+		return nil, err
+	}
+	if row != nil {
+		row = figo.NormalizeExprFields(row, naming)
+	}
+
+	g := newGrant(roles, naming)
+	var denials []string
+	for _, c := range clauses {
+		if row != nil && reflect.DeepEqual(c, row) {
+			continue
+		}
+		g.check(c, "", &denials)
+	}
+	if s := f.GetSort(); s != nil {
+		for _, col := range s.Columns {
+			// The relevance pseudo-column reads no stored value.
+			if col.Name != figo.ScoreSortField && !g.allows(col.Name, "sort") {
+				denials = append(denials, fmt.Sprintf("sorting by %q is not allowed", col.Name))
+			}
+		}
+	}
+	if err := denialError(denials); err != nil {
+		return nil, err
+	}
+	if row != nil && !containsEqualExpr(clauses, row) {
+		clauses = append(clauses, row)
+	}
+	return clauses, nil
+}
+
+// FinalizePreloadsContext implements figo.ContextPlugin: it checks a
+// preload's conditions, each field qualified by the relation name.
+func (p *PolicyPlugin) FinalizePreloadsContext(ctx context.Context, f figo.Figo, relation string, conds []figo.Expr) ([]figo.Expr, error) {
+	_, roles, err := p.rolesFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	g := newGrant(roles, f.GetNamingFunc())
+	var denials []string
+	for _, c := range conds {
+		g.check(c, relation+".", &denials)
+	}
+	if err := denialError(denials); err != nil {
+		return nil, err
+	}
+	return conds, nil
+}
+
+// rolesFor returns ctx's principal and the policy roles it holds, in the
+// principal's order.
+func (p *PolicyPlugin) rolesFor(ctx context.Context) (Principal, []compiledRole, error) {
+	pr, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return Principal{}, nil, errors.New("policy: the build context carries no principal")
+	}
+	var roles []compiledRole
+	for _, name := range pr.Roles {
+		if r, ok := p.roles[name]; ok {
+			roles = append(roles, r)
+		}
+	}
+	if len(roles) == 0 {
+		return Principal{}, nil, fmt.Errorf("policy: none of the principal's roles %v is granted by the policy", pr.Roles)
+	}
+	return pr, roles, nil
+}
+
+// rowPredicate resolves the principal's row predicate: nil when a role grants
+// every row, otherwise the roles' resolved predicates ORed together.
+func rowPredicate(pr Principal, roles []compiledRole) (figo.Expr, error) {
+	for _, r := range roles {
+		if len(r.rows) == 0 {
+			return nil, nil
+		}
+	}
+	var alts []figo.Expr
+	for _, r := range roles {
+		var conj []figo.Expr
+		for _, tmpl := range r.rows {
+			e, err := substituteClaims(tmpl, pr.claim)
+			if err != nil {
+				return nil, err
+			}
+			conj = append(conj, e)
+		}
+		if len(conj) == 1 {
+			alts = append(alts, conj[0])
+		} else {
+			alts = append(alts, figo.AndExpr{Operands: conj})
+		}
+	}
+	if len(alts) == 1 {
+		return alts[0], nil
+	}
+	return figo.OrExpr{Operands: alts}, nil
+}
+
+// claim looks a placeholder's name up in the claims: verbatim first, then as
+// a dotted path through nested objects (${claim.org.id}).
+func (pr Principal) claim(name string) (any, error) {
+	if v, ok := pr.Claims[name]; ok && v != nil {
+		return v, nil
+	}
+	var cur any = pr.Claims
+	for _, part := range strings.Split(name, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			cur = nil
+			break
+		}
+		cur = m[part]
+	}
+	if cur == nil {
+		return nil, fmt.Errorf("policy: the principal has no claim %q", name)
+	}
+	return cur, nil
+}
+
+// substituteClaims returns a copy of e with every value that is exactly one
+// placeholder replaced by resolve's value for it. A list value fills an
+// <in>/<nin> list and is refused anywhere else.
+func substituteClaims(e figo.Expr, resolve func(string) (any, error)) (figo.Expr, error) {
+	var err error
+	scalar := func(v *any) {
+		s, ok := (*v).(string)
+		m := claimValue.FindStringSubmatch(s)
+		if err != nil || !ok || m == nil {
+			return
+		}
+		out, rerr := resolve(m[1])
+		switch {
+		case rerr != nil:
+			err = rerr
+		case isListClaim(out):
+			err = fmt.Errorf("policy: claim %q is a list; only an <in>/<nin> list can take it", m[1])
+		default:
+			*v = out
+		}
+	}
+	list := func(vs *[]any) {
+		if err != nil {
+			return
+		}
+		out := make([]any, 0, len(*vs))
+		for _, v := range *vs {
+			s, ok := v.(string)
+			m := claimValue.FindStringSubmatch(s)
+			if !ok || m == nil {
+				out = append(out, v)
+				continue
+			}
+			r, rerr := resolve(m[1])
+			if rerr != nil {
+				err = rerr
+				return
+			}
+			if !isListClaim(r) {
+				out = append(out, r)
+				continue
+			}
+			rv := reflect.ValueOf(r)
+			for i := 0; i < rv.Len(); i++ {
+				out = append(out, rv.Index(i).Interface())
+			}
+		}
+		*vs = out
+	}
+
+	// Walk hands out copies of the leaves, so e itself is never written.
+	out := figo.Walk(e, func(n figo.Expr) {
+		switch v := n.(type) {
+		case *figo.EqExpr:
+			scalar(&v.Value)
+		case *figo.NeqExpr:
+			scalar(&v.Value)
+		case *figo.GtExpr:
+			scalar(&v.Value)
+		case *figo.GteExpr:
+			scalar(&v.Value)
+		case *figo.LtExpr:
+			scalar(&v.Value)
+		case *figo.LteExpr:
+			scalar(&v.Value)
+		case *figo.LikeExpr:
+			scalar(&v.Value)
+		case *figo.ILikeExpr:
+			scalar(&v.Value)
+		case *figo.RegexExpr:
+			scalar(&v.Value)
+		case *figo.BetweenExpr:
+			scalar(&v.Low)
+			scalar(&v.High)
+		case *figo.InExpr:
+			list(&v.Values)
+		case *figo.NotInExpr:
+			list(&v.Values)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// isListClaim reports whether a claim value is a list (a JSON array decodes
+// to []any; a Go caller may pass any slice but a byte slice).
+func isListClaim(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8
+}
+
+// grant is the union of some roles' field grants: field -> operators. Each
+// field is keyed verbatim and in its naming-converted form, because the
+// expressions it is checked against have been through the naming func. The
+// allow side is not case-folded (see newDenyMatcher).
+type grant map[string]map[string]bool
+
+func newGrant(roles []compiledRole, naming figo.NamingFunc) grant {
+	g := grant{}
+	add := func(field string, ops map[string]bool) {
+		if g[field] == nil {
+			g[field] = map[string]bool{}
+		}
+		for op := range ops {
+			g[field][op] = true
+		}
+	}
+	for _, r := range roles {
+		for field, ops := range r.fields {
+			add(field, ops)
+			if field != "*" && naming != nil {
+				add(convertField(field, naming), ops)
+			}
+		}
+	}
+	return g
+}
+
+// convertField applies naming to each '.'-separated segment of field, as
+// figo does to the fields it parses.
+func convertField(field string, naming figo.NamingFunc) string {
+	parts := strings.Split(field, ".")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = naming(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// allows reports whether op is granted on field, directly or through "*".
+func (g grant) allows(field, op string) bool {
+	for _, key := range []string{field, "*"} {
+		if ops := g[key]; ops["*"] || ops[op] {
+			return true
+		}
+	}
+	return false
+}
+
+// check appends a denial for every condition in e the grant does not allow.
+// prefix qualifies the fields of a relation's rows.
+func (g grant) check(e figo.Expr, prefix string, denials *[]string) {
+	switch v := e.(type) {
+	case nil:
+		return
+	case figo.AndExpr:
+		for _, o := range v.Operands {
+			g.check(o, prefix, denials)
+		}
+		return
+	case figo.OrExpr:
+		for _, o := range v.Operands {
+			g.check(o, prefix, denials)
+		}
+		return
+	case figo.NotExpr:
+		for _, o := range v.Operands {
+			g.check(o, prefix, denials)
+		}
+		return
+	case figo.RelationExpr:
+		field := prefix + v.Relation
+		if op := "<" + string(v.Quantifier) + ">"; !g.allows(field, op) {
+			*denials = append(*denials, fmt.Sprintf("%s on %q is not allowed", op, field))
+		}
+		g.check(v.Cond, field+".", denials)
+		return
+	}
+	op, ok := figo.ExprOperator(e)
+	if !ok {
+		*denials = append(*denials, fmt.Sprintf("expression %T is not covered by the policy", e))
+		return
+	}
+	field := prefix + figo.ExprField(e)
+	if !g.allows(field, op) {
+		if figo.ExprField(e) == "" {
+			*denials = append(*denials, fmt.Sprintf("%s is not allowed", op))
+		} else {
+			*denials = append(*denials, fmt.Sprintf("%s on %q is not allowed", op, field))
+		}
+	}
+}
+
+// denialError joins denials, each once and in a stable order, into the
+// build's error.
+func denialError(denials []string) error {
+	if len(denials) == 0 {
+		return nil
+	}
+	sort.Strings(denials)
+	var errs []error
+	for i, d := range denials {
+		if i > 0 && d == denials[i-1] {
+			continue
+		}
+		errs = append(errs, errors.New("policy: "+d))
+	}
+	return errors.Join(errs...)
+}
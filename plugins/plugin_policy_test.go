@@ -0,0 +1,175 @@
+package plugins
+
+import (
+	. "github.com/bi0dread/figo/v4"
+	. "github.com/bi0dread/figo/v4/adapters"
+
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `{
+  "roles": {
+    "agent": {
+      "fields": {"status": ["=", "<in>", "sort"], "createdAt": ["*"], "Orders.total": [">"]},
+      "rows": ["region=${claim.region}", "deleted=false"]
+    },
+    "owner": {
+      "fields": {"name": ["=^"]},
+      "rows": ["ownerId=${claim.sub}"]
+    },
+    "admin": {"fields": {"*": ["*"]}}
+  }
+}`
+
+func newTestPolicy(t *testing.T) *PolicyPlugin {
+	t.Helper()
+	doc, err := ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+	pp, err := NewPolicyPlugin(doc)
+	require.NoError(t, err)
+	return pp
+}
+
+func policyBuild(t *testing.T, pp *PolicyPlugin, ctx context.Context, dsl string) (Figo, error) {
+	t.Helper()
+	f := New()
+	require.NoError(t, f.RegisterPlugin(pp))
+	require.NoError(t, f.AddFiltersFromString(dsl))
+	return f, f.BuildContext(ctx, RawAdapter{})
+}
+
+func TestPolicyPluginGrantsFieldsAndRows(t *testing.T) {
+	pp := newTestPolicy(t)
+	agent := WithPrincipal(context.Background(), Principal{
+		Roles:  []string{"agent"},
+		Claims: map[string]any{"region": "eu"},
+	})
+
+	f, err := policyBuild(t, pp, agent, `status<in>["open","new"] and createdAt>"2024-01-01" sort=status:asc`)
+	require.NoError(t, err)
+	where, args, err := BuildRawWhere(f)
+	require.NoError(t, err)
+	assert.Equal(t, "(`status` IN (?,?) AND `created_at` > ?) AND (`region` = ? AND `deleted` = ?)", where)
+	assert.Equal(t, []any{"open", "new", "2024-01-01", "eu", false}, args)
+
+	// A rebuild that keeps the clause list neither rechecks nor repeats the
+	// row predicate.
+	require.NoError(t, f.BuildContext(agent, nil))
+	where, _, _ = BuildRawWhere(f)
+	assert.Equal(t, 1, strings.Count(where, "`region`"))
+
+	// Roles add up: the fields of both, the rows of either.
+	both := WithPrincipal(context.Background(), Principal{
+		Roles:  []string{"agent", "owner", "unknown"},
+		Claims: map[string]any{"region": "eu", "sub": 7},
+	})
+	f, err = policyBuild(t, pp, both, `name=^"a%" and status="open"`)
+	require.NoError(t, err)
+	where, args, _ = BuildRawWhere(f)
+	assert.Equal(t, "(`name` LIKE ? AND `status` = ?) AND ((`region` = ? AND `deleted` = ?) OR `owner_id` = ?)", where)
+	assert.Equal(t, []any{"a%", "open", "eu", false, 7}, args)
+
+	// A role without row predicates sees every row.
+	admin := WithPrincipal(context.Background(), Principal{Roles: []string{"owner", "admin"}})
+	f, err = policyBuild(t, pp, admin, `salary>1 sort=salary:desc`)
+	require.NoError(t, err)
+	where, _, _ = BuildRawWhere(f)
+	assert.Equal(t, "`salary` > ?", where)
+}
+
+func TestPolicyPluginReportsDenials(t *testing.T) {
+	pp := newTestPolicy(t)
+	agent := WithPrincipal(context.Background(), Principal{
+		Roles:  []string{"agent"},
+		Claims: map[string]any{"region": "eu"},
+	})
+
+	f, err := policyBuild(t, pp, agent, `status!="x" and (ssn="1" or createdAt<null>) sort=createdAt:asc,salary:desc`)
+	require.Error(t, err)
+	assert.Equal(t, "plugin figo-policy FinalizeClausesContext error: "+
+		"policy: != on \"status\" is not allowed\n"+
+		"policy: = on \"ssn\" is not allowed\n"+
+		"policy: sorting by \"salary\" is not allowed", err.Error())
+	where, _, _ := BuildRawWhere(f)
+	assert.Equal(t, "1=0", where, "a denied query matches nothing")
+
+	// Negation is checked as the operator it negates.
+	_, err = policyBuild(t, pp, agent, `not(status="x")`)
+	assert.NoError(t, err)
+
+	// Preload conditions are checked under the relation's name.
+	f, err = policyBuild(t, pp, agent, `status="open" load=[Orders:total>5|Items:sku="x"]`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `relation "Items"): policy: = on "Items.sku" is not allowed`)
+	assert.Equal(t, []Expr{GtExpr{Field: "total", Value: int64(5)}}, f.GetPreloads()["Orders"])
+
+	// No principal, no role the policy knows, or a missing claim: refused.
+	for name, ctx := range map[string]context.Context{
+		"no principal":  context.Background(),
+		"unknown role":  WithPrincipal(context.Background(), Principal{Roles: []string{"guest"}}),
+		"missing claim": WithPrincipal(context.Background(), Principal{Roles: []string{"agent"}}),
+	} {
+		f, err := policyBuild(t, pp, ctx, `status="open"`)
+		assert.Error(t, err, name)
+		where, _, _ := BuildRawWhere(f)
+		assert.Equal(t, "1=0", where, name)
+	}
+}
+
+func TestPolicyPluginSubstitutesClaimsAsValues(t *testing.T) {
+	doc := Policy{Roles: map[string]RolePolicy{
+		"member": {
+			Fields: map[string][]string{"*": {"="}},
+			Rows:   []string{`team<in>[${claim.teams}] and orgId=${claim.org.id}`},
+		},
+	}}
+	pp, err := NewPolicyPlugin(doc)
+	require.NoError(t, err)
+
+	ctx := WithPrincipal(context.Background(), Principal{
+		Roles: []string{"member"},
+		Claims: map[string]any{
+			"teams": []any{"a", "b"},
+			"org":   map[string]any{"id": `1" or 1=1 or x="`},
+		},
+	})
+	f, err := policyBuild(t, pp, ctx, `a=1`)
+	require.NoError(t, err)
+	where, args, _ := BuildRawWhere(f)
+	assert.Equal(t, "`a` = ? AND (`team` IN (?,?) AND `org_id` = ?)", where)
+	assert.Equal(t, []any{int64(1), "a", "b", `1" or 1=1 or x="`}, args, "a claim is a value, never DSL")
+
+	// A list claim in a scalar position is refused.
+	ctx = WithPrincipal(context.Background(), Principal{
+		Roles:  []string{"member"},
+		Claims: map[string]any{"teams": []string{"a"}, "org": map[string]any{"id": []any{1}}},
+	})
+	_, err = policyBuild(t, pp, ctx, `a=1`)
+	assert.ErrorContains(t, err, `claim "org.id" is a list`)
+}
+
+func TestNewPolicyPluginValidatesTheDocument(t *testing.T) {
+	for name, doc := range map[string]string{
+		"no roles":         `{"roles":{}}`,
+		"unknown operator": `{"roles":{"r":{"fields":{"a":["~~"]}}}}`,
+		"bad row":          `{"roles":{"r":{"rows":["a=1 and"]}}}`,
+		"directive row":    `{"roles":{"r":{"rows":["a=1 sort=a:asc"]}}}`,
+		"partial claim":    `{"roles":{"r":{"rows":["a=\"x-${claim.a}\""]}}}`,
+		"empty row":        `{"roles":{"r":{"rows":[""]}}}`,
+	} {
+		p, err := ParsePolicy([]byte(doc))
+		require.NoError(t, err, name)
+		_, err = NewPolicyPlugin(p)
+		assert.Error(t, err, name)
+	}
+
+	_, err := ParsePolicy([]byte(`{"roles":{"r":{"row":["a=1"]}}}`))
+	assert.Error(t, err, "a misspelled member is an error")
+	_, err = ParsePolicy([]byte(`{"roles":{}} {}`))
+	assert.Error(t, err)
+}
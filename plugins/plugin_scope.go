@@ -5,6 +5,9 @@ import (
 )
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"sync"
 )
@@ -31,11 +34,46 @@ import (
 // correctly scoped parent. Which column scopes a child table is a property of
 // that table, not of the parent, so it is not inferred: register it with
 // AddPreloadScope (per relation) or AddPreloadScopeAll (every relation).
+//
+// A scope that depends on the request — the caller's tenant, their roles — is
+// registered as a ScopeFunc, which resolves it from the context of each
+// BuildContext. One shared plugin then serves every request:
+//
+//	sp := plugins.NewScopePlugin()
+//	sp.AddScopeFunc(plugins.ContextValueScope("tenant_id", tenantKey{}))
+//	// per request:
+//	err := f.BuildContext(r.Context(), adapters.RawAdapter{})
+//
+// A ScopeFunc that errors fails the build closed (see figo.ContextPlugin).
 type ScopePlugin struct {
 	mu            sync.RWMutex
 	scopes        []figo.Expr
 	preloadScopes map[string][]figo.Expr // relation -> mandatory conditions
 	allPreloads   []figo.Expr            // applied to every preloaded relation
+
+	scopeFuncs        []ScopeFunc
+	preloadScopeFuncs map[string][]ScopeFunc
+	allPreloadFuncs   []ScopeFunc
+}
+
+// ScopeFunc resolves mandatory filters from a build's context. It runs on
+// every BuildContext (Build and BuildE pass context.Background()). Returning
+// no expressions applies no scope — the deliberate answer for, say, an
+// administrator; returning an error refuses the build, which is the answer
+// when the value the scope needs is missing.
+type ScopeFunc func(ctx context.Context) ([]figo.Expr, error)
+
+// ContextValueScope returns a ScopeFunc scoping field to the context value
+// under key (field = value), and refusing the build when ctx has no such
+// value.
+func ContextValueScope(field string, key any) ScopeFunc {
+	return func(ctx context.Context) ([]figo.Expr, error) {
+		v := ctx.Value(key)
+		if v == nil {
+			return nil, fmt.Errorf("scope %s: the build context has no value for %v", field, key)
+		}
+		return []figo.Expr{figo.EqExpr{Field: field, Value: v}}, nil
+	}
 }
 
 // NewScopePlugin creates a scope plugin enforcing the given filters
@@ -77,6 +115,44 @@ func (p *ScopePlugin) AddPreloadScopeAll(scopes ...figo.Expr) {
 	}
 }
 
+// AddScopeFunc registers mandatory filters resolved per build from its
+// context, applied like AddScope's.
+func (p *ScopePlugin) AddScopeFunc(fns ...ScopeFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, fn := range fns {
+		if fn != nil {
+			p.scopeFuncs = append(p.scopeFuncs, fn)
+		}
+	}
+}
+
+// AddPreloadScopeFunc is AddPreloadScope resolved per build from its context.
+func (p *ScopePlugin) AddPreloadScopeFunc(relation string, fns ...ScopeFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.preloadScopeFuncs == nil {
+		p.preloadScopeFuncs = make(map[string][]ScopeFunc)
+	}
+	for _, fn := range fns {
+		if fn != nil {
+			p.preloadScopeFuncs[relation] = append(p.preloadScopeFuncs[relation], fn)
+		}
+	}
+}
+
+// AddPreloadScopeFuncAll is AddPreloadScopeAll resolved per build from its
+// context.
+func (p *ScopePlugin) AddPreloadScopeFuncAll(fns ...ScopeFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, fn := range fns {
+		if fn != nil {
+			p.allPreloadFuncs = append(p.allPreloadFuncs, fn)
+		}
+	}
+}
+
 // Name implements Plugin
 func (p *ScopePlugin) Name() string { return "figo-scope" }
 
@@ -118,12 +194,31 @@ func (p *ScopePlugin) GetScopes() []figo.Expr {
 	return out
 }
 
-// FinalizeClauses implements ClauseFinalizer: it appends each scope as a
+// FinalizeClauses implements ClauseFinalizer: FinalizeClausesContext with
+// context.Background(), failing closed to the never-true clause when a
+// ScopeFunc errors. The plugin manager calls FinalizeClausesContext instead.
+func (p *ScopePlugin) FinalizeClauses(f figo.Figo, clauses []figo.Expr) []figo.Expr {
+	out, err := p.FinalizeClausesContext(context.Background(), f, clauses)
+	if err != nil {
+		return []figo.Expr{figo.OrExpr{}}
+	}
+	return out
+}
+
+// FinalizeClausesContext implements figo.ContextPlugin: it appends each scope
+// — the static ones, then those the ScopeFuncs resolve from ctx — as a
 // top-level clause (adapters AND all top-level clauses together). A scope
 // already present is not appended again, so repeated Builds on an instance
 // that keeps its clauses (empty-DSL rebuilds) don't accumulate duplicates.
-func (p *ScopePlugin) FinalizeClauses(f figo.Figo, clauses []figo.Expr) []figo.Expr {
-	for _, s := range p.GetScopes() {
+func (p *ScopePlugin) FinalizeClausesContext(ctx context.Context, f figo.Figo, clauses []figo.Expr) ([]figo.Expr, error) {
+	p.mu.RLock()
+	fns := append([]ScopeFunc(nil), p.scopeFuncs...)
+	p.mu.RUnlock()
+	scopes, err := resolveScopes(ctx, p.GetScopes(), fns)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range scopes {
 		// Clone so callers mutating the scope expression (or Walk rewriting
 		// the tree) can't alias the plugin's copy, and apply the instance's
 		// naming func: the scope enters through this hook rather than through
@@ -135,25 +230,59 @@ func (p *ScopePlugin) FinalizeClauses(f figo.Figo, clauses []figo.Expr) []figo.E
 			clauses = append(clauses, scoped)
 		}
 	}
-	return clauses
+	return clauses, nil
 }
 
-// FinalizePreloads implements figo.PreloadFinalizer: it appends the mandatory
-// conditions registered for this relation (plus any registered for every
-// relation) to the preload's own conditions, which the adapters AND together.
+// FinalizePreloads implements figo.PreloadFinalizer: FinalizePreloadsContext
+// with context.Background(), failing closed like FinalizeClauses.
 func (p *ScopePlugin) FinalizePreloads(f figo.Figo, relation string, conds []figo.Expr) []figo.Expr {
+	out, err := p.FinalizePreloadsContext(context.Background(), f, relation, conds)
+	if err != nil {
+		return []figo.Expr{figo.OrExpr{}}
+	}
+	return out
+}
+
+// FinalizePreloadsContext implements figo.ContextPlugin: it appends the
+// mandatory conditions registered for this relation (plus any registered for
+// every relation), static and resolved from ctx, to the preload's own
+// conditions, which the adapters AND together.
+func (p *ScopePlugin) FinalizePreloadsContext(ctx context.Context, f figo.Figo, relation string, conds []figo.Expr) ([]figo.Expr, error) {
 	p.mu.RLock()
-	relScopes := append([]figo.Expr(nil), p.preloadScopes[relation]...)
-	allScopes := append([]figo.Expr(nil), p.allPreloads...)
+	static := append(append([]figo.Expr(nil), p.preloadScopes[relation]...), p.allPreloads...)
+	fns := append(append([]ScopeFunc(nil), p.preloadScopeFuncs[relation]...), p.allPreloadFuncs...)
 	p.mu.RUnlock()
-
-	for _, s := range append(relScopes, allScopes...) {
+	scopes, err := resolveScopes(ctx, static, fns)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range scopes {
 		scoped := scopeExprFor(f, s)
 		if !containsEqualExpr(conds, scoped) {
 			conds = append(conds, scoped)
 		}
 	}
-	return conds
+	return conds, nil
+}
+
+// resolveScopes appends what each ScopeFunc resolves from ctx to the static
+// scopes. Every func runs, so one error cannot hide another.
+func resolveScopes(ctx context.Context, static []figo.Expr, fns []ScopeFunc) ([]figo.Expr, error) {
+	scopes := static
+	var errs []error
+	for _, fn := range fns {
+		resolved, err := fn(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, e := range resolved {
+			if e != nil {
+				scopes = append(scopes, e)
+			}
+		}
+	}
+	return scopes, errors.Join(errs...)
 }
 
 // scopeExprFor returns an independent copy of s with the instance's naming
@@ -186,6 +186,8 @@ func parsedExprLooksClean(e figo.Expr) bool {
 		return allParsedExprsLookClean(x.Operands)
 	case figo.NotExpr:
 		return allParsedExprsLookClean(x.Operands)
+	case figo.RelationExpr:
+		return cleanParsedField(x.Relation) && (x.Cond == nil || parsedExprLooksClean(x.Cond))
 	default:
 		return true
 	}
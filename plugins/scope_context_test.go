@@ -0,0 +1,95 @@
+package plugins
+
+import (
+	. "github.com/bi0dread/figo/v4"
+	. "github.com/bi0dread/figo/v4/adapters"
+
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tenantKey struct{}
+
+func TestScopePluginResolvesFromTheBuildContext(t *testing.T) {
+	// One plugin shared by every request.
+	sp := NewScopePlugin(EqExpr{Field: "deleted", Value: false})
+	sp.AddScopeFunc(ContextValueScope("tenant_id", tenantKey{}))
+	sp.AddPreloadScopeFunc("Orders", ContextValueScope("tenant_id", tenantKey{}))
+
+	build := func(ctx context.Context, dsl string) (Figo, error) {
+		f := New()
+		require.NoError(t, f.RegisterPlugin(sp))
+		require.NoError(t, f.AddFiltersFromString(dsl))
+		return f, f.BuildContext(ctx, RawAdapter{})
+	}
+
+	var wg sync.WaitGroup
+	for _, tenant := range []string{"t1", "t2", "t3"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f, err := build(context.WithValue(context.Background(), tenantKey{}, tenant), `a=1 load=[Orders:total>5]`)
+			assert.NoError(t, err)
+			where, args, err := BuildRawWhere(f)
+			assert.NoError(t, err)
+			assert.Equal(t, "`a` = ? AND `deleted` = ? AND `tenant_id` = ?", where)
+			assert.Equal(t, []any{int64(1), false, tenant}, args)
+			assert.Contains(t, f.GetPreloads()["Orders"], EqExpr{Field: "tenant_id", Value: tenant})
+		}()
+	}
+	wg.Wait()
+
+	// No tenant in the context: refused, and the query matches nothing.
+	f, err := build(context.Background(), `a=1 load=[Orders:total>5]`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "plugin figo-scope FinalizeClausesContext error")
+	assert.Contains(t, err.Error(), `relation "Orders"`)
+	where, _, _ := BuildRawWhere(f)
+	assert.Equal(t, "1=0", where)
+	assert.Equal(t, []Expr{OrExpr{}}, f.GetPreloads()["Orders"])
+
+	// Plain Build passes context.Background(), so it fails closed the same way.
+	f = New()
+	require.NoError(t, f.RegisterPlugin(sp))
+	f.Build(RawAdapter{})
+	where, _, _ = BuildRawWhere(f)
+	assert.Equal(t, "1=0", where)
+}
+
+func TestScopeFuncMayResolveNothing(t *testing.T) {
+	type roleKey struct{}
+	sp := NewScopePlugin()
+	sp.AddScopeFunc(func(ctx context.Context) ([]Expr, error) {
+		switch ctx.Value(roleKey{}) {
+		case "admin":
+			return nil, nil
+		case "agent":
+			return []Expr{EqExpr{Field: "region", Value: "eu"}}, nil
+		}
+		return nil, errors.New("no role")
+	})
+
+	f := New()
+	require.NoError(t, f.RegisterPlugin(sp))
+	require.NoError(t, f.AddFiltersFromString(`a=1`))
+
+	require.NoError(t, f.BuildContext(context.WithValue(context.Background(), roleKey{}, "admin"), RawAdapter{}))
+	where, _, _ := BuildRawWhere(f)
+	assert.Equal(t, "`a` = ?", where)
+
+	require.NoError(t, f.BuildContext(context.WithValue(context.Background(), roleKey{}, "agent"), nil))
+	where, _, _ = BuildRawWhere(f)
+	assert.Equal(t, "`a` = ? AND `region` = ?", where)
+
+	assert.Error(t, f.BuildContext(context.Background(), nil))
+	where, _, _ = BuildRawWhere(f)
+	assert.Equal(t, "1=0", where)
+
+	// The direct ClauseFinalizer call fails closed too.
+	assert.Equal(t, []Expr{OrExpr{}}, sp.FinalizeClauses(f, nil))
+}
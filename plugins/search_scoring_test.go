@@ -0,0 +1,43 @@
+package plugins
+
+import (
+	"testing"
+
+	figo "github.com/bi0dread/figo/v4"
+	"github.com/bi0dread/figo/v4/adapters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// _score is the relevance pseudo-column, not a stored field: a whitelist that
+// does not list it keeps it, an explicit ignore still drops it.
+func TestFieldsPluginKeepsScoreSort(t *testing.T) {
+	fp := NewFieldsPlugin()
+	fp.SetAllowedFields("status")
+	fp.EnableFieldWhitelist()
+	f := figo.New()
+	require.NoError(t, f.RegisterPlugin(fp))
+	require.NoError(t, f.AddFiltersFromString(`status="open" sort=_score:desc,salary:asc`))
+	require.NoError(t, f.BuildE(adapters.ElasticsearchAdapter{}))
+	assert.Equal(t, &figo.OrderBy{Columns: []figo.OrderByColumn{{Name: "_score", Desc: true}}}, f.GetSort())
+
+	ig := NewFieldsPlugin()
+	ig.AddIgnoreFields("_score")
+	g := figo.New()
+	require.NoError(t, g.RegisterPlugin(ig))
+	require.NoError(t, g.AddFiltersFromString(`status="open" sort=_score:desc`))
+	g.Build(adapters.ElasticsearchAdapter{})
+	assert.Nil(t, g.GetSort())
+}
+
+// A field-less search names no identifier; the guard must not refuse it.
+func TestInjectionGuardAcceptsSearchDirective(t *testing.T) {
+	f := figo.New()
+	require.NoError(t, f.RegisterPlugin(NewInjectionGuardPlugin()))
+	require.NoError(t, f.AddFiltersFromString(`status="open" q="red shoes"`))
+	require.NoError(t, f.BuildE(adapters.ElasticsearchAdapter{}))
+	assert.Equal(t, []figo.Expr{figo.AndExpr{Operands: []figo.Expr{
+		figo.EqExpr{Field: "status", Value: "open"},
+		figo.FullTextSearchExpr{Query: "red shoes"},
+	}}}, f.GetClauses())
+}
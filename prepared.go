@@ -0,0 +1,584 @@
+package figo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A DSL string built per request has two costs: the parse, paid again for
+// every request, and the values, spliced into the string where a quote in a
+// user's input changes what the query says. Compile parses a template once,
+// with named placeholders where the values go, and Bind puts each request's
+// values into the parsed tree — they are never parsed as DSL.
+//
+//	listOrders, err := figo.Compile(`tenant_id=:tenant and created_at>=:since sort=:sort`)
+//	...
+//	f, err := listOrders.Bind(map[string]any{
+//	    "tenant": tenantID,
+//	    "since":  time.Now().AddDate(0, 0, -7),
+//	    "sort":   r.URL.Query().Get("sort"), // "created_at:desc"
+//	})
+//
+// A placeholder is `:name` where a value goes: after an operator
+// (`age>=:min`, `name=^:prefix`), as an <in>/<nin> list or one of its
+// elements (`id<in>:ids`, `id<in>[1,:other]`), as a <bet> bound
+// (`<bet>(:lo..:hi)`), as a q= search, and inside load=[...] conditions.
+// `sort=:name` and `page=:name` stand for a whole directive. A `:name`
+// opening a token is an error, as is one the parse did not take as a value;
+// any other `:` — `sort=id:desc`, `load=[Orders:...]`, inside a quoted
+// string — is the DSL's own.
+//
+// A Prepared is immutable, so one can be compiled at startup and bound from
+// any number of goroutines at once.
+type Prepared struct {
+	template string
+	naming   NamingFunc
+	params   []string // sorted
+	prefix   string   // sentinel prefix standing in for the values in the parse
+	parse    *boundDSL
+	search   *boundDSL // the Elasticsearch parse, when q= makes it differ
+
+	sortParam, pageParam string
+}
+
+// boundDSL is a Prepared's parse with one request's values in place. An
+// instance it is bound to builds from it instead of parsing its DSL.
+type boundDSL struct {
+	expr     Expr
+	preloads map[string][]Expr
+	sort     *OrderBy
+	page     Page
+	pageFrom pageOrigin
+	search   *boundDSL // the parse for Elasticsearch, where q= is a search
+}
+
+// apply leaves what the template's directives say on f, as parsing it would,
+// and returns a copy of its filter. f.mu must be held.
+func (b *boundDSL) apply(f *figo) Expr {
+	if b.search != nil && AdapterStore(f.adapterObj) == StoreElasticsearch {
+		b = b.search
+	}
+	f.preloads = clonePreloads(b.preloads)
+	if b.sort != nil {
+		f.sort = cloneOrderBy(b.sort)
+		f.sortFromDSL = true
+	}
+	if b.pageFrom&pageSkipFromDSL != 0 {
+		f.page.Skip = b.page.Skip
+	}
+	if b.pageFrom&pageTakeFromDSL != 0 {
+		f.page.Take = b.page.Take
+	}
+	f.pageFromDSL |= b.pageFrom
+	if b.expr == nil {
+		return nil
+	}
+	return CloneExpr(b.expr)
+}
+
+// Compile parses a DSL template with named placeholders, converting field
+// names with SnakeCaseNaming as New does. A template that does not parse
+// cleanly — anything BuildE would report — is an error.
+func Compile(template string) (*Prepared, error) {
+	return CompileNaming(template, SnakeCaseNaming)
+}
+
+// CompileNaming is Compile with the NamingFunc the template's field names are
+// converted with; instances a Prepared is bound to take it over.
+func CompileNaming(template string, naming NamingFunc) (*Prepared, error) {
+	if naming == nil {
+		naming = NoChangeNaming
+	}
+	if strings.TrimSpace(template) == "" {
+		return nil, fmt.Errorf("empty template")
+	}
+	p := &Prepared{template: template, naming: naming, prefix: sentinelPrefix(template)}
+
+	dsl, values, err := p.scan()
+	if err != nil {
+		return nil, err
+	}
+	if reason := dslResourceGuard(dsl); reason != "" {
+		return nil, errors.New(reason)
+	}
+
+	if p.parse, err = p.compileParse(dsl, values, nil); err != nil {
+		return nil, err
+	}
+	// q= is a search only on Elasticsearch (see searchDirective), so there
+	// the template may parse differently; Bind fills in both parses and the
+	// instance's adapter picks one at Build.
+	search, err := p.compileParse(dsl, values, searchStore{})
+	if err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(search, p.parse) {
+		p.search = search
+	}
+	return p, nil
+}
+
+// compileParse parses the scanned template for adapter's store and checks
+// that the parse took every value placeholder as a value.
+func (p *Prepared) compileParse(dsl string, values map[string]int, adapter Adapter) (*boundDSL, error) {
+	scratch := New().(*figo)
+	scratch.namingFunc = p.naming
+	scratch.adapterObj = adapter
+	var diags []error
+	scratch.mu.Lock()
+	expr := scratch.parseFinal(dsl, &diags)
+	scratch.mu.Unlock()
+	if len(diags) > 0 {
+		return nil, errors.Join(diags...)
+	}
+	parse := &boundDSL{expr: expr, preloads: scratch.preloads, sort: scratch.sort, page: scratch.page, pageFrom: scratch.pageFromDSL}
+	if p.sortParam != "" && parse.sort != nil {
+		return nil, fmt.Errorf("sort=:%s and another sort= directive both sort the query", p.sortParam)
+	}
+	if p.pageParam != "" && parse.pageFrom != 0 {
+		return nil, fmt.Errorf("page=:%s and another page= directive both page the query", p.pageParam)
+	}
+
+	// Every value placeholder must have come through the parse as a value;
+	// one that did not (written where a field goes, say) would otherwise be
+	// bound to nothing and silently ignored.
+	seen := map[string]int{}
+	count := func(v any) (any, error) {
+		if name, ok := p.param(v); ok {
+			seen[name]++
+		}
+		return v, nil
+	}
+	element := func(v any) ([]any, error) {
+		_, err := count(v)
+		return []any{v}, err
+	}
+	exprs := []Expr{parse.expr}
+	for _, conds := range parse.preloads {
+		exprs = append(exprs, conds...)
+	}
+	for _, e := range exprs {
+		if e != nil {
+			_, _ = substituteValues(e, count, element)
+		}
+	}
+	for _, name := range sortedKeys(values) {
+		if seen[name] != values[name] {
+			return nil, fmt.Errorf("placeholder :%s is not in a value position", name)
+		}
+	}
+	return parse, nil
+}
+
+// scan replaces each value placeholder with a quoted sentinel naming it and
+// cuts out the sort=/page= directive placeholders, returning the DSL to
+// parse and how many times each value placeholder occurs.
+func (p *Prepared) scan() (string, map[string]int, error) {
+	t := p.template
+	values := map[string]int{}
+	names := map[string]bool{}
+	var b strings.Builder
+	inQuote := false
+	for i := 0; i < len(t); i++ {
+		c := t[i]
+		if c == '"' {
+			inQuote = !inQuote
+		}
+		if inQuote || c != ':' || i+1 >= len(t) || !isParamStart(t[i+1]) {
+			b.WriteByte(c)
+			continue
+		}
+		j := i + 1
+		for j < len(t) && isParamChar(t[j]) {
+			j++
+		}
+		name := t[i+1 : j]
+		before := strings.TrimRight(t[:i], " \t\r\n")
+		if before == "" || !strings.ContainsRune("=><^~[,(.", rune(before[len(before)-1])) {
+			if before == "" || len(before) < i {
+				// `:name` opening a token, where only a field can go.
+				return "", nil, fmt.Errorf("placeholder :%s is not in a value position", name)
+			}
+			b.WriteByte(c)
+			continue
+		}
+		names[name] = true
+
+		if directive := directiveBefore(t[:i]); directive != "" {
+			if j < len(t) && !isDSLSpace(t[j]) {
+				return "", nil, fmt.Errorf("%s=:%s must be the whole %s= directive", directive, name, directive)
+			}
+			owner := &p.sortParam
+			if directive == string(OperationPage) {
+				owner = &p.pageParam
+			}
+			if *owner != "" {
+				return "", nil, fmt.Errorf("the template has two %s=:name directives", directive)
+			}
+			*owner = name
+			// Drop the directive already written, "sort=".
+			out := b.String()
+			b.Reset()
+			b.WriteString(out[:len(out)-len(directive)-1])
+			i = j - 1
+			continue
+		}
+
+		sentinel := strconv.Quote(p.prefix + name)
+		if strings.HasSuffix(before, "<in>") || strings.HasSuffix(before, "<nin>") {
+			sentinel = "[" + sentinel + "]"
+		}
+		b.WriteString(sentinel)
+		values[name]++
+		i = j - 1
+	}
+	p.params = sortedKeys(names)
+	return b.String(), values, nil
+}
+
+// directiveBefore returns "sort" or "page" when s ends with that directive's
+// `name=`, written as a token of its own.
+func directiveBefore(s string) string {
+	for _, d := range []Operation{OperationSort, OperationPage} {
+		head := string(d) + "="
+		if strings.HasSuffix(s, head) && (len(s) == len(head) || isDSLSpace(s[len(s)-len(head)-1])) {
+			return string(d)
+		}
+	}
+	return ""
+}
+
+func isParamStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isParamChar(c byte) bool {
+	return isParamStart(c) || ('0' <= c && c <= '9')
+}
+
+// sentinelPrefix returns a prefix that occurs nowhere in the template, so no
+// value the template itself spells can be mistaken for a placeholder.
+func sentinelPrefix(template string) string {
+	for n := 0; ; n++ {
+		prefix := "figoparam" + strconv.Itoa(n) + ":"
+		if !strings.Contains(template, prefix) {
+			return prefix
+		}
+	}
+}
+
+// param returns the placeholder a parsed value stands for.
+func (p *Prepared) param(v any) (string, bool) {
+	s, ok := v.(string)
+	if !ok || !strings.HasPrefix(s, p.prefix) {
+		return "", false
+	}
+	return s[len(p.prefix):], true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Template returns the template the Prepared was compiled from.
+func (p *Prepared) Template() string { return p.template }
+
+// Params returns the placeholder names, sorted.
+func (p *Prepared) Params() []string { return append([]string(nil), p.params...) }
+
+// Bind returns a new instance built from the template with params bound —
+// every placeholder to a value, by name without the colon. It is an error to
+// leave a placeholder unbound or to bind a name the template does not have.
+//
+// A value is bound as given, typed by Go rather than by the DSL: 7 is an
+// int, "7" a string. A slice fills an <in>/<nin> list and may be bound
+// nowhere else; nil and an empty list are refused — write <null>, or leave
+// the condition out of the template. `sort=:name` takes a string in the
+// directive's syntax, "created_at:desc,id" (a key without a direction is
+// ascending, and "" means no sort), or an OrderBy; `page=:name` takes a Page
+// or a string such as "skip:40,take:20".
+//
+// To bind into an instance with plugins registered, use BindTo.
+func (p *Prepared) Bind(params map[string]any) (Figo, error) {
+	f := New()
+	if err := p.BindTo(f, params); err != nil {
+		return nil, err
+	}
+	if err := f.BuildE(nil); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// BindTo binds params (see Bind) into f in place of a DSL, as
+// AddFiltersFromString would set one: f builds from the template's parse
+// with the values in place, GetDSL returns the template, and the next
+// AddFiltersFromString replaces it. f takes over the Prepared's NamingFunc,
+// which its field names were converted with. Plugin AfterParse hooks run,
+// BeforeParse hooks do not — there is no string left to rewrite. A binding
+// error or a rejecting hook leaves f refused, matching nothing.
+func (p *Prepared) BindTo(f Figo, params map[string]any) error {
+	inst, ok := f.(*figo)
+	if !ok {
+		return fmt.Errorf("BindTo: %T is not an instance created by figo.New", f)
+	}
+	bound, err := p.bind(params)
+	if err != nil {
+		inst.refuseDSL()
+		return err
+	}
+
+	inst.mu.Lock()
+	inst.dsl = p.template
+	inst.bound = bound
+	inst.namingFunc = p.naming
+	pm := inst.pluginManager
+	inst.mu.Unlock()
+
+	if pm != nil {
+		// As in AddFiltersFromString: refuse on a rejection or a panic alike.
+		committed := false
+		defer func() {
+			if !committed {
+				inst.refuseDSL()
+			}
+		}()
+		if err := pm.ExecuteAfterParse(f, p.template); err != nil {
+			return err
+		}
+		committed = true
+	}
+	return nil
+}
+
+// bind checks params against the placeholders and substitutes them into a
+// copy of the parse.
+func (p *Prepared) bind(params map[string]any) (*boundDSL, error) {
+	var errs []error
+	for _, name := range p.params {
+		if _, ok := params[name]; !ok {
+			errs = append(errs, fmt.Errorf("unbound parameter :%s", name))
+		}
+	}
+	known := make(map[string]bool, len(p.params))
+	for _, name := range p.params {
+		known[name] = true
+	}
+	for _, name := range sortedKeys(params) {
+		if !known[name] {
+			errs = append(errs, fmt.Errorf("unknown parameter :%s", name))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	scalar := func(v any) (any, error) {
+		name, ok := p.param(v)
+		if !ok {
+			return v, nil
+		}
+		value := params[name]
+		if value == nil {
+			return nil, fmt.Errorf("parameter :%s is nil (match NULL with <null>)", name)
+		}
+		if _, isList := listValues(value); isList {
+			return nil, fmt.Errorf("parameter :%s is a list; only an <in>/<nin> list takes one", name)
+		}
+		return value, nil
+	}
+	element := func(v any) ([]any, error) {
+		name, ok := p.param(v)
+		if !ok {
+			return []any{v}, nil
+		}
+		if values, isList := listValues(params[name]); isList {
+			if len(values) == 0 {
+				return nil, fmt.Errorf("parameter :%s is an empty list", name)
+			}
+			return values, nil
+		}
+		value, err := scalar(v)
+		return []any{value}, err
+	}
+
+	b, err := bindParse(p.parse, scalar, element)
+	if err != nil {
+		return nil, err
+	}
+	if p.search != nil {
+		if b.search, err = bindParse(p.search, scalar, element); err != nil {
+			return nil, err
+		}
+	}
+	if p.sortParam != "" {
+		sort, err := p.bindSort(params[p.sortParam])
+		if err != nil {
+			return nil, fmt.Errorf("parameter :%s: %w", p.sortParam, err)
+		}
+		b.sort = sort
+		if b.search != nil {
+			b.search.sort = cloneOrderBy(sort)
+		}
+	}
+	if p.pageParam != "" {
+		page, from, err := bindPage(params[p.pageParam])
+		if err != nil {
+			return nil, fmt.Errorf("parameter :%s: %w", p.pageParam, err)
+		}
+		b.page, b.pageFrom = page, from
+		if b.search != nil {
+			b.search.page, b.search.pageFrom = page, from
+		}
+	}
+	return b, nil
+}
+
+// bindParse substitutes the values into a copy of one of the template's
+// parses.
+func bindParse(parse *boundDSL, scalar func(any) (any, error), element func(any) ([]any, error)) (*boundDSL, error) {
+	b := &boundDSL{preloads: make(map[string][]Expr, len(parse.preloads)), sort: cloneOrderBy(parse.sort), page: parse.page, pageFrom: parse.pageFrom}
+	var err error
+	if parse.expr != nil {
+		if b.expr, err = substituteValues(parse.expr, scalar, element); err != nil {
+			return nil, err
+		}
+	}
+	for table, conds := range parse.preloads {
+		out := make([]Expr, len(conds))
+		for i, c := range conds {
+			if out[i], err = substituteValues(c, scalar, element); err != nil {
+				return nil, fmt.Errorf("load=[%s:...]: %w", table, err)
+			}
+		}
+		b.preloads[table] = out
+	}
+	return b, nil
+}
+
+// listValues returns the elements of a slice or array value ([]byte is a
+// scalar: a blob, not a list of bytes).
+func listValues(v any) ([]any, bool) {
+	if v == nil {
+		return nil, false
+	}
+	if _, blob := v.([]byte); blob {
+		return nil, false
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out, true
+}
+
+// bindSort turns a sort parameter into the OrderBy a sort= directive would
+// have produced.
+func (p *Prepared) bindSort(v any) (*OrderBy, error) {
+	var cols []OrderByColumn
+	switch s := v.(type) {
+	case string:
+		if strings.TrimSpace(s) == "" {
+			return nil, nil
+		}
+		for _, seg := range strings.Split(s, ",") {
+			field, dir, hasDir := strings.Cut(strings.TrimSpace(seg), ":")
+			desc := false
+			if hasDir {
+				switch strings.ToLower(dir) {
+				case "asc":
+				case "desc":
+					desc = true
+				default:
+					return nil, fmt.Errorf("invalid sort direction %q for field %q (expected asc or desc)", dir, field)
+				}
+			}
+			cols = append(cols, OrderByColumn{Name: field, Desc: desc})
+		}
+	case OrderBy:
+		cols = append(cols, s.Columns...)
+	case *OrderBy:
+		if s == nil {
+			return nil, nil
+		}
+		cols = append(cols, s.Columns...)
+	default:
+		return nil, fmt.Errorf("a sort is a string or an OrderBy, not %T", v)
+	}
+	for i, c := range cols {
+		if !isSortIdentifier(c.Name) {
+			return nil, fmt.Errorf("invalid sort field %q", c.Name)
+		}
+		if c.Name != ScoreSortField {
+			cols[i].Name = convertFieldName(p.naming, c.Name)
+		}
+	}
+	if len(cols) == 0 {
+		return nil, nil
+	}
+	return &OrderBy{Columns: cols}, nil
+}
+
+// isSortIdentifier reports whether s is a plain, possibly dotted, field
+// name: a sort key from a request must not carry anything else into ORDER BY.
+func isSortIdentifier(s string) bool {
+	if s == ScoreSortField {
+		return true
+	}
+	for _, part := range strings.Split(s, ".") {
+		if part == "" || !isParamStart(part[0]) {
+			return false
+		}
+		for i := 1; i < len(part); i++ {
+			if !isParamChar(part[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// bindPage turns a page parameter into the components a page= directive
+// would have set.
+func bindPage(v any) (Page, pageOrigin, error) {
+	switch pg := v.(type) {
+	case Page:
+		if pg.Skip < 0 || pg.Take < 0 {
+			return Page{}, 0, fmt.Errorf("negative page %+v", pg)
+		}
+		return pg, pageSkipFromDSL | pageTakeFromDSL, nil
+	case string:
+		var page Page
+		var from pageOrigin
+		for _, seg := range strings.Split(pg, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(seg), ":")
+			n, err := strconv.Atoi(value)
+			if !ok || err != nil || n < 0 {
+				return Page{}, 0, fmt.Errorf("malformed page segment %q (expected skip:N or take:N)", seg)
+			}
+			switch key {
+			case "skip":
+				page.Skip, from = n, from|pageSkipFromDSL
+			case "take":
+				page.Take, from = n, from|pageTakeFromDSL
+			default:
+				return Page{}, 0, fmt.Errorf("unknown page key %q (expected skip or take)", key)
+			}
+		}
+		return page, from, nil
+	}
+	return Page{}, 0, fmt.Errorf("a page is a Page or a string, not %T", v)
+}
@@ -0,0 +1,180 @@
+package figo_test
+
+import (
+	. "github.com/bi0dread/figo/v4"
+	. "github.com/bi0dread/figo/v4/adapters"
+	"github.com/bi0dread/figo/v4/plugins"
+
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreparedBind(t *testing.T) {
+	p, err := Compile(`tenantId=:tenant and created_at>=:since sort=:sort`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"since", "sort", "tenant"}, p.Params())
+
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f, err := p.Bind(map[string]any{"tenant": 7, "since": since, "sort": "createdAt:desc,id"})
+	require.NoError(t, err)
+	f.SetAdapterObject(RawAdapter{})
+	sql, args, err := BuildRawSelect(f, "orders")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `orders` WHERE (`tenant_id` = ? AND `created_at` >= ?) ORDER BY `created_at` DESC, `id` ASC", sql)
+	assert.Equal(t, []any{7, since}, args)
+	assert.Equal(t, p.Template(), f.GetDSL(), "values stay out of the DSL string")
+
+	// Rebuilding keeps the bound values; a new DSL replaces them.
+	require.NoError(t, f.BuildE(nil))
+	assert.Len(t, f.GetClauses(), 1)
+	require.NoError(t, f.AddFiltersFromString(`id=1`))
+	require.NoError(t, f.BuildE(nil))
+	assert.Equal(t, []Expr{EqExpr{Field: "id", Value: int64(1)}}, f.GetClauses())
+	assert.Nil(t, f.GetSort())
+}
+
+func TestPreparedValuePositions(t *testing.T) {
+	p, err := Compile(`(id<in>:ids or id<in>[1,:id] or age<bet>(:lo..:hi)) and name=^:prefix and note=":not_a_param" load=[Orders:total>:min] page=:page`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hi", "id", "ids", "lo", "min", "page", "prefix"}, p.Params())
+
+	f, err := p.Bind(map[string]any{"ids": []int{4, 5}, "id": 9, "lo": 1, "hi": 3, "prefix": `a" or 1=1 or b="`, "min": 5.5, "page": "skip:40,take:20"})
+	require.NoError(t, err)
+	where, args, err := BuildRawWhere(f)
+	require.NoError(t, err)
+	assert.Equal(t, "((`id` IN (?,?) OR `id` IN (?,?) OR `age` BETWEEN ? AND ?) AND `name` LIKE ? AND `note` = ?)", where)
+	assert.Equal(t, []any{4, 5, int64(1), 9, 1, 3, `a" or 1=1 or b="`, ":not_a_param"}, args)
+	assert.Equal(t, []Expr{GtExpr{Field: "total", Value: 5.5}}, f.GetPreloads()["Orders"])
+	assert.Equal(t, Page{Skip: 40, Take: 20}, f.GetPage())
+
+	q, err := Compile(`q=:term sort=:sort page=:page`)
+	require.NoError(t, err)
+	f, err = q.Bind(map[string]any{"term": "red shoes", "sort": "", "page": Page{Take: 10}})
+	require.NoError(t, err)
+	assert.Equal(t, []Expr{EqExpr{Field: "q", Value: "red shoes"}}, f.GetClauses())
+	assert.Nil(t, f.GetSort())
+	assert.Equal(t, Page{Take: 10}, f.GetPage())
+
+	// q= searches on Elasticsearch only; the adapter picks the parse.
+	require.NoError(t, f.BuildE(ElasticsearchAdapter{}))
+	assert.Equal(t, []Expr{FullTextSearchExpr{Query: "red shoes"}}, f.GetClauses())
+	assert.Equal(t, Page{Take: 10}, f.GetPage())
+}
+
+func TestPreparedBindValidates(t *testing.T) {
+	p, err := Compile(`tenant_id=:tenant and created_at>=:since and id<in>:ids sort=:sort`)
+	require.NoError(t, err)
+	ok := map[string]any{"tenant": 1, "since": "2026-01-01", "ids": []int64{1}, "sort": "id:asc"}
+	with := func(k string, v any) map[string]any {
+		m := map[string]any{}
+		for key, val := range ok {
+			m[key] = val
+		}
+		if v == nil {
+			delete(m, k)
+		} else {
+			m[k] = v
+		}
+		return m
+	}
+
+	for name, tc := range map[string]struct {
+		params map[string]any
+		want   string
+	}{
+		"unbound":      {map[string]any{"tenant": 1}, "unbound parameter :ids\nunbound parameter :since\nunbound parameter :sort"},
+		"unknown":      {with("extra", 1), "unknown parameter :extra"},
+		"list scalar":  {with("tenant", []int{1, 2}), "parameter :tenant is a list; only an <in>/<nin> list takes one"},
+		"empty list":   {with("ids", []int{}), "parameter :ids is an empty list"},
+		"bad sort dir": {with("sort", "id:sideways"), `parameter :sort: invalid sort direction "sideways"`},
+		"bad sort key": {with("sort", "id;drop table"), `parameter :sort: invalid sort field "id;drop table"`},
+	} {
+		_, err := p.Bind(tc.params)
+		require.Error(t, err, name)
+		assert.Contains(t, err.Error(), tc.want, name)
+	}
+
+	m := with("tenant", nil)
+	m["tenant"] = nil
+	_, err = p.Bind(m)
+	assert.EqualError(t, err, "parameter :tenant is nil (match NULL with <null>)")
+
+	// A failed bind refuses the instance instead of leaving it unfiltered.
+	f := New()
+	require.Error(t, p.BindTo(f, nil))
+	require.NoError(t, f.BuildE(RawAdapter{}))
+	where, _, _ := BuildRawWhere(f)
+	assert.Equal(t, "1=0", where)
+}
+
+func TestCompileValidates(t *testing.T) {
+	for tpl, want := range map[string]string{
+		``:                           "empty template",
+		`a=1 and`:                    `dangling "and" connector dropped`,
+		`:x=1`:                       "placeholder :x is not in a value position",
+		`a=[:x]`:                     "placeholder :x is not in a value position",
+		`a=:x sort=:s,id:asc`:        "sort=:s must be the whole sort= directive",
+		`a=:x sort=:s sort=id:asc`:   "sort=:s and another sort= directive both sort the query",
+		`page=:p page=:q`:            "the template has two page=:name directives",
+		`a<null> and b=:x<in>[1]`:    `operator "<in>" with no field name`,
+		`load=[Orders:a=:x] page=:p`: "-",
+	} {
+		_, err := Compile(tpl)
+		if want == "-" {
+			assert.NoError(t, err, tpl)
+			continue
+		}
+		require.Error(t, err, tpl)
+		assert.Contains(t, err.Error(), want, tpl)
+	}
+}
+
+func TestPreparedConcurrentBind(t *testing.T) {
+	p, err := Compile(`tenant_id=:tenant and id<in>:ids`)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f, err := p.Bind(map[string]any{"tenant": i, "ids": []int{i, i + 1}})
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, args, err := BuildRawWhere(f)
+			assert.NoError(t, err)
+			assert.Equal(t, []any{i, i, i + 1}, args, fmt.Sprint(i))
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestPreparedBindToRunsPlugins(t *testing.T) {
+	p, err := Compile(`id<in>:ids`)
+	require.NoError(t, err)
+
+	f := New()
+	require.NoError(t, f.RegisterPlugin(plugins.NewLimitsPlugin(plugins.QueryLimits{MaxInListSize: 2})))
+	require.NoError(t, p.BindTo(f, map[string]any{"ids": []int{1, 2}}))
+	require.NoError(t, f.BuildE(RawAdapter{}))
+
+	// The plugins judge the bound values, not the template.
+	assert.Error(t, p.BindTo(f, map[string]any{"ids": []int{1, 2, 3}}))
+	require.NoError(t, f.BuildE(RawAdapter{}))
+	where, _, _ := BuildRawWhere(f)
+	assert.Equal(t, "1=0", where)
+	assert.Equal(t, "", f.GetDSL())
+
+	clone := f.Clone()
+	require.NoError(t, p.BindTo(f, map[string]any{"ids": []int{3}}))
+	require.NoError(t, f.BuildE(nil))
+	assert.Equal(t, []Expr{InExpr{Field: "id", Values: []any{3}}}, f.Clone().GetClauses())
+	require.NoError(t, clone.BuildE(nil))
+	assert.Equal(t, []Expr{OrExpr{}}, clone.GetClauses())
+}
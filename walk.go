@@ -36,6 +36,12 @@ func Walk(e Expr, visit func(Expr)) Expr {
 		v.Operands = walkOperands(v.Operands, visit)
 		visit(&v)
 		return v
+	case RelationExpr:
+		// The condition on the related row is walked like an operand list, so
+		// a visitor screening or renaming fields reaches it too.
+		v.Cond = Walk(v.Cond, visit)
+		visit(&v)
+		return v
 
 	// Leaf nodes: visit via a pointer to an addressable copy, then write it back.
 	// Leaves carrying slices or a dynamic Value copy them first: the struct copy
@@ -310,6 +316,8 @@ func NodeField(e Expr) (string, bool) {
 		return v.Field, true
 	case *CustomExpr:
 		return v.Field, true
+	case *RelationExpr:
+		return v.Relation, true
 	default:
 		return "", false
 	}
@@ -363,6 +371,8 @@ func SetNodeField(e Expr, field string) bool {
 		v.Field = field
 	case *CustomExpr:
 		v.Field = field
+	case *RelationExpr:
+		v.Relation = field
 	default:
 		return false
 	}